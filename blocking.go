@@ -0,0 +1,101 @@
+package resp3
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNil is returned with the null reply of a blocking command, which means it timed out on the server.
+var ErrNil = errors.New("resp: nil reply")
+
+// blockingCommands return the timeout argument of the blocking commands.
+var blockingCommands = map[string]func(args []string) (time.Duration, bool){
+	"BLPOP":      lastSeconds,
+	"BRPOP":      lastSeconds,
+	"BRPOPLPUSH": lastSeconds,
+	"BLMOVE":     lastSeconds,
+	"BZPOPMIN":   lastSeconds,
+	"BZPOPMAX":   lastSeconds,
+	"BLMPOP":     firstSeconds,
+	"BZMPOP":     firstSeconds,
+	"XREAD":      blockOption,
+	"XREADGROUP": blockOption,
+	"WAIT": func(args []string) (time.Duration, bool) {
+		// WAIT numreplicas timeout
+		if len(args) < 3 {
+			return 0, false
+		}
+		return parseMillis(args[2])
+	},
+	"WAITAOF": func(args []string) (time.Duration, bool) {
+		// WAITAOF numlocal numreplicas timeout
+		if len(args) < 4 {
+			return 0, false
+		}
+		return parseMillis(args[3])
+	},
+}
+
+// blockingTimeout returns how long a command may block on the server, 0 if it blocks until it's served.
+// It returns false if the command doesn't block.
+func blockingTimeout(args []string) (time.Duration, bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	timeoutOf, ok := blockingCommands[strings.ToUpper(args[0])]
+	if !ok {
+		return 0, false
+	}
+	return timeoutOf(args)
+}
+
+// lastSeconds is the timeout in seconds at the end, like BLPOP key [key ...] timeout.
+func lastSeconds(args []string) (time.Duration, bool) {
+	if len(args) < 3 {
+		return 0, false
+	}
+	return parseSeconds(args[len(args)-1])
+}
+
+// firstSeconds is the timeout in seconds before the keys, like BLMPOP timeout numkeys key [key ...].
+func firstSeconds(args []string) (time.Duration, bool) {
+	if len(args) < 2 {
+		return 0, false
+	}
+	return parseSeconds(args[1])
+}
+
+// blockOption is the BLOCK option in milliseconds of XREAD and XREADGROUP, which don't block without it.
+func blockOption(args []string) (time.Duration, bool) {
+	for i := 1; i+1 < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "BLOCK":
+			return parseMillis(args[i+1])
+		case "STREAMS":
+			return 0, false
+		case "GROUP":
+			i += 2
+		case "COUNT":
+			i++
+		}
+	}
+	return 0, false
+}
+
+func parseSeconds(s string) (time.Duration, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 {
+		return 0, false
+	}
+	return time.Duration(f * float64(time.Second)), true
+}
+
+func parseMillis(s string) (time.Duration, bool) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Millisecond, true
+}
@@ -0,0 +1,34 @@
+package resp3
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBlockingTimeout(t *testing.T) {
+	tests := []struct {
+		args     []string
+		timeout  time.Duration
+		blocking bool
+	}{
+		{[]string{"blpop", "a", "b", "1.5"}, 1500 * time.Millisecond, true},
+		{[]string{"BRPOPLPUSH", "a", "b", "0"}, 0, true},
+		{[]string{"BLMOVE", "a", "b", "LEFT", "RIGHT", "2"}, 2 * time.Second, true},
+		{[]string{"BZPOPMIN", "z", "0.01"}, 10 * time.Millisecond, true},
+		{[]string{"BLMPOP", "3", "1", "a", "LEFT"}, 3 * time.Second, true},
+		{[]string{"XREAD", "COUNT", "10", "BLOCK", "100", "STREAMS", "s", "$"}, 100 * time.Millisecond, true},
+		{[]string{"XREADGROUP", "GROUP", "block", "c", "BLOCK", "0", "STREAMS", "s", ">"}, 0, true},
+		{[]string{"XREAD", "STREAMS", "block", "0"}, 0, false},
+		{[]string{"WAIT", "1", "250"}, 250 * time.Millisecond, true},
+		{[]string{"WAITAOF", "1", "0", "0"}, 0, true},
+		{[]string{"BLPOP", "a", "-1"}, 0, false},
+		{[]string{"GET", "a"}, 0, false},
+		{nil, 0, false},
+	}
+	for _, tt := range tests {
+		timeout, blocking := blockingTimeout(tt.args)
+		if timeout != tt.timeout || blocking != tt.blocking {
+			t.Errorf("%v: expected %v, %v but got %v, %v", tt.args, tt.timeout, tt.blocking, timeout, blocking)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package resp3
+
+import (
+	"context"
+	"strconv"
+)
+
+// The builders make the commands of the core families with checked options,
+// and decode their replies to Go types. They understand both the RESP3 and the RESP2 shapes of the replies,
+// for example HGETALL returns a map in RESP3 and a flat array in RESP2.
+//
+// Every builder has Args, which returns the command to send it by a Doer or to queue it in a Tx,
+// Decode, which decodes a reply, and Do, which sends the command and decodes the reply:
+//
+//	ok, err := resp3.Set("key", "value").EX(10).NX().Do(ctx, conn)
+//	n, err := resp3.ZAdd("zset").GT().Member(1.5, "a").Do(ctx, conn)
+//	id, err := resp3.XAdd("stream").MaxLen(1000).Field("k", "v").Do(ctx, conn)
+
+// IntCmd is a command which replies an integer.
+type IntCmd struct {
+	args []string
+}
+
+// Args returns the command.
+func (c *IntCmd) Args() []string {
+	return c.args
+}
+
+// Decode decodes a reply.
+func (c *IntCmd) Decode(v *Value) (int64, error) {
+	if v.Type != TypeNumber {
+		return 0, ErrUnexpectedReply
+	}
+	return v.Integer, nil
+}
+
+// Do sends the command and decodes the reply.
+func (c *IntCmd) Do(ctx context.Context, d Doer) (int64, error) {
+	v, err := d.DoContext(ctx, c.args...)
+	if err != nil {
+		return 0, err
+	}
+	return c.Decode(v)
+}
+
+// BoolCmd is a command which replies a boolean, or an integer 1 or 0 in RESP2 and in most commands.
+type BoolCmd struct {
+	args []string
+}
+
+// Args returns the command.
+func (c *BoolCmd) Args() []string {
+	return c.args
+}
+
+// Decode decodes a reply.
+func (c *BoolCmd) Decode(v *Value) (bool, error) {
+	switch v.Type {
+	case TypeBoolean:
+		return v.Boolean, nil
+	case TypeNumber:
+		return v.Integer != 0, nil
+	}
+	return false, ErrUnexpectedReply
+}
+
+// Do sends the command and decodes the reply.
+func (c *BoolCmd) Do(ctx context.Context, d Doer) (bool, error) {
+	v, err := d.DoContext(ctx, c.args...)
+	if err != nil {
+		return false, err
+	}
+	return c.Decode(v)
+}
+
+// FloatCmd is a command which replies a double, or a bulk string in RESP2.
+type FloatCmd struct {
+	args []string
+}
+
+// Args returns the command.
+func (c *FloatCmd) Args() []string {
+	return c.args
+}
+
+// Decode decodes a reply. ok is false if the reply is null.
+func (c *FloatCmd) Decode(v *Value) (f float64, ok bool, err error) {
+	switch {
+	case isNil(v):
+		return 0, false, nil
+	case v.Type == TypeDouble:
+		return v.Double, true, nil
+	case v.Type == TypeBlobString:
+		f, err := strconv.ParseFloat(v.Str, 64)
+		if err != nil {
+			return 0, false, ErrUnexpectedReply
+		}
+		return f, true, nil
+	}
+	return 0, false, ErrUnexpectedReply
+}
+
+// Do sends the command and decodes the reply.
+func (c *FloatCmd) Do(ctx context.Context, d Doer) (float64, bool, error) {
+	v, err := d.DoContext(ctx, c.args...)
+	if err != nil {
+		return 0, false, err
+	}
+	return c.Decode(v)
+}
+
+// StringCmd is a command which replies a string or null.
+type StringCmd struct {
+	args []string
+}
+
+// Args returns the command.
+func (c *StringCmd) Args() []string {
+	return c.args
+}
+
+// Decode decodes a reply. ok is false if the reply is null.
+func (c *StringCmd) Decode(v *Value) (s string, ok bool, err error) {
+	switch {
+	case isNil(v):
+		return "", false, nil
+	case v.Type == TypeBlobString || v.Type == TypeSimpleString || v.Type == TypeVerbatimString:
+		return v.Str, true, nil
+	}
+	return "", false, ErrUnexpectedReply
+}
+
+// Do sends the command and decodes the reply.
+func (c *StringCmd) Do(ctx context.Context, d Doer) (string, bool, error) {
+	v, err := d.DoContext(ctx, c.args...)
+	if err != nil {
+		return "", false, err
+	}
+	return c.Decode(v)
+}
+
+// StringsCmd is a command which replies an array or a set of strings.
+type StringsCmd struct {
+	args []string
+}
+
+// Args returns the command.
+func (c *StringsCmd) Args() []string {
+	return c.args
+}
+
+// Decode decodes a reply. Null elements are returned as empty strings.
+func (c *StringsCmd) Decode(v *Value) ([]string, error) {
+	if v.Type != TypeArray && v.Type != TypeSet {
+		return nil, ErrUnexpectedReply
+	}
+	rt := make([]string, len(v.Elems))
+	for i, elem := range v.Elems {
+		rt[i] = elem.Str
+	}
+	return rt, nil
+}
+
+// Do sends the command and decodes the reply.
+func (c *StringsCmd) Do(ctx context.Context, d Doer) ([]string, error) {
+	v, err := d.DoContext(ctx, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(v)
+}
+
+// StringMapCmd is a command which replies a map of strings, or a flat array of keys and values in RESP2.
+type StringMapCmd struct {
+	args []string
+}
+
+// Args returns the command.
+func (c *StringMapCmd) Args() []string {
+	return c.args
+}
+
+// Decode decodes a reply.
+func (c *StringMapCmd) Decode(v *Value) (map[string]string, error) {
+	keys, values, err := pairs(v)
+	if err != nil {
+		return nil, err
+	}
+	rt := make(map[string]string, len(keys))
+	for i := range keys {
+		rt[keys[i].Str] = values[i].Str
+	}
+	return rt, nil
+}
+
+// Do sends the command and decodes the reply.
+func (c *StringMapCmd) Do(ctx context.Context, d Doer) (map[string]string, error) {
+	v, err := d.DoContext(ctx, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(v)
+}
+
+// isNil checks the null of RESP3, and the null bulk string and the null array of RESP2.
+func isNil(v *Value) bool {
+	return v.Type == TypeNull || (v.Type == TypeBlobString && v.NullBulkString) || (v.Type == TypeArray && v.NullArray)
+}
+
+// pairs returns the entries of a map, or of a flat array of keys and values.
+func pairs(v *Value) (keys, values []*Value, err error) {
+	switch v.Type {
+	case TypeMap:
+		keys, values = mapEntries(v.KV)
+		return keys, values, nil
+	case TypeArray:
+		if len(v.Elems)%2 != 0 {
+			return nil, nil, ErrUnexpectedReply
+		}
+		for i := 0; i < len(v.Elems); i += 2 {
+			keys = append(keys, v.Elems[i])
+			values = append(values, v.Elems[i+1])
+		}
+		return keys, values, nil
+	}
+	return nil, nil, ErrUnexpectedReply
+}
@@ -0,0 +1,84 @@
+package resp3
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func mustValue(t *testing.T, data string) *Value {
+	t.Helper()
+	v, err := FromString(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestBuilder_Decode(t *testing.T) {
+	for _, data := range []string{"#t\r\n", ":1\r\n"} {
+		if ok, err := (&BoolCmd{}).Decode(mustValue(t, data)); !ok || err != nil {
+			t.Errorf("%q: expected true but got %v, %v", data, ok, err)
+		}
+	}
+
+	for _, data := range []string{",1.5\r\n", "$3\r\n1.5\r\n"} {
+		if f, ok, err := (&FloatCmd{}).Decode(mustValue(t, data)); f != 1.5 || !ok || err != nil {
+			t.Errorf("%q: expected 1.5 but got %v, %v, %v", data, f, ok, err)
+		}
+	}
+	for _, data := range []string{"_\r\n", "$-1\r\n"} {
+		if _, ok, err := (&FloatCmd{}).Decode(mustValue(t, data)); ok || err != nil {
+			t.Errorf("%q: expected null but got %v, %v", data, ok, err)
+		}
+		if _, ok, err := (&StringCmd{}).Decode(mustValue(t, data)); ok || err != nil {
+			t.Errorf("%q: expected null but got %v, %v", data, ok, err)
+		}
+	}
+
+	for _, data := range []string{"~2\r\n+a\r\n+b\r\n", "*2\r\n$1\r\na\r\n$1\r\nb\r\n"} {
+		if s, err := (&StringsCmd{}).Decode(mustValue(t, data)); !reflect.DeepEqual(s, []string{"a", "b"}) || err != nil {
+			t.Errorf("%q: unexpected %v, %v", data, s, err)
+		}
+	}
+
+	want := map[string]string{"f1": "v1", "f2": "v2"}
+	for _, data := range []string{"%2\r\n+f1\r\n+v1\r\n+f2\r\n+v2\r\n", "*4\r\n+f1\r\n+v1\r\n+f2\r\n+v2\r\n"} {
+		if m, err := (&StringMapCmd{}).Decode(mustValue(t, data)); !reflect.DeepEqual(m, want) || err != nil {
+			t.Errorf("%q: unexpected %v, %v", data, m, err)
+		}
+	}
+
+	if _, err := (&IntCmd{}).Decode(NewSimpleStringValue("OK")); err != ErrUnexpectedReply {
+		t.Errorf("expected ErrUnexpectedReply but got %v", err)
+	}
+	if _, err := (&StringMapCmd{}).Decode(mustValue(t, "*1\r\n+f1\r\n")); err != ErrUnexpectedReply {
+		t.Errorf("expected ErrUnexpectedReply but got %v", err)
+	}
+}
+
+func TestBuilder_Do(t *testing.T) {
+	var got []string
+	d := doerFunc(func(ctx context.Context, args ...string) (*Value, error) {
+		got = args
+		switch args[0] {
+		case "HGETALL":
+			return FromString("%1\r\n+f\r\n+v\r\n")
+		case "SET":
+			return NewNullValue(), nil
+		}
+		return nil, Error("ERR unknown command")
+	})
+	ctx := context.Background()
+
+	m, err := HGetAll("h").Do(ctx, d)
+	if err != nil || m["f"] != "v" || !reflect.DeepEqual(got, []string{"HGETALL", "h"}) {
+		t.Errorf("unexpected %v, %v, %v", m, err, got)
+	}
+	if ok, err := Set("k", "v").NX().Do(ctx, d); ok || err != nil {
+		t.Errorf("expected false but got %v, %v", ok, err)
+	}
+	if _, err := Incr("k").Do(ctx, d); err != Error("ERR unknown command") {
+		t.Errorf("expected the error reply but got %v", err)
+	}
+}
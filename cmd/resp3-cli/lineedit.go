@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// errInterrupted is returned by readLine when the line is aborted by Ctrl-C.
+var errInterrupted = errors.New("interrupted")
+
+// maxHistory is the number of lines kept in the history.
+const maxHistory = 1000
+
+// lineEditor reads lines from a terminal with basic editing keys and a history:
+// left and right, Home and End or Ctrl-A and Ctrl-E, Backspace and Delete, Ctrl-U, Ctrl-K and Ctrl-W,
+// up and down or Ctrl-P and Ctrl-N for the history. Ctrl-C aborts the line and Ctrl-D on an empty line ends the input.
+type lineEditor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	history []string
+
+	// raw switches the terminal to raw mode and returns the function which restores it.
+	raw func() (restore func(), err error)
+}
+
+func newLineEditor(in io.Reader, out io.Writer) *lineEditor {
+	return &lineEditor{in: bufio.NewReader(in), out: out}
+}
+
+// readLine shows the prompt and reads a line. Non-empty lines are added to the history.
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	if e.raw != nil {
+		restore, err := e.raw()
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+
+	var line []rune
+	pos := 0
+	hist := len(e.history)
+	saved := "" // the line being edited when the history is browsed
+	refresh := func() {
+		buf := new(strings.Builder)
+		buf.WriteString("\r" + prompt + string(line) + "\x1b[K")
+		if n := len(line) - pos; n > 0 {
+			buf.WriteString("\x1b[" + strconv.Itoa(n) + "D")
+		}
+		io.WriteString(e.out, buf.String())
+	}
+	browse := func(i int) {
+		if i < 0 || i > len(e.history) || i == hist {
+			return
+		}
+		if hist == len(e.history) {
+			saved = string(line)
+		}
+		hist = i
+		if i == len(e.history) {
+			line = []rune(saved)
+		} else {
+			line = []rune(e.history[i])
+		}
+		pos = len(line)
+		refresh()
+	}
+	refresh()
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				break
+			}
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			io.WriteString(e.out, "\r\n")
+			s := string(line)
+			e.add(s)
+			return s, nil
+		case 3: // Ctrl-C
+			io.WriteString(e.out, "^C\r\n")
+			return "", errInterrupted
+		case 4: // Ctrl-D
+			if len(line) == 0 {
+				io.WriteString(e.out, "\r\n")
+				return "", io.EOF
+			}
+			if pos < len(line) {
+				line = append(line[:pos], line[pos+1:]...)
+			}
+		case 1: // Ctrl-A
+			pos = 0
+		case 5: // Ctrl-E
+			pos = len(line)
+		case 2: // Ctrl-B
+			if pos > 0 {
+				pos--
+			}
+		case 6: // Ctrl-F
+			if pos < len(line) {
+				pos++
+			}
+		case 8, 127: // Backspace
+			if pos > 0 {
+				line = append(line[:pos-1], line[pos:]...)
+				pos--
+			}
+		case 11: // Ctrl-K
+			line = line[:pos]
+		case 21: // Ctrl-U
+			line = append(line[:0], line[pos:]...)
+			pos = 0
+		case 23: // Ctrl-W
+			start := pos
+			for start > 0 && line[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && line[start-1] != ' ' {
+				start--
+			}
+			line = append(line[:start], line[pos:]...)
+			pos = start
+		case 16: // Ctrl-P
+			browse(hist - 1)
+			continue
+		case 14: // Ctrl-N
+			browse(hist + 1)
+			continue
+		case 27: // escape sequence
+			switch e.escape() {
+			case "[A", "OA":
+				browse(hist - 1)
+				continue
+			case "[B", "OB":
+				browse(hist + 1)
+				continue
+			case "[C", "OC":
+				if pos < len(line) {
+					pos++
+				}
+			case "[D", "OD":
+				if pos > 0 {
+					pos--
+				}
+			case "[H", "OH", "[1~", "[7~":
+				pos = 0
+			case "[F", "OF", "[4~", "[8~":
+				pos = len(line)
+			case "[3~":
+				if pos < len(line) {
+					line = append(line[:pos], line[pos+1:]...)
+				}
+			}
+		default:
+			if r < ' ' {
+				continue
+			}
+			line = append(line, 0)
+			copy(line[pos+1:], line[pos:])
+			line[pos] = r
+			pos++
+		}
+		refresh()
+	}
+
+	// the input ends without a newline
+	s := string(line)
+	e.add(s)
+	return s, nil
+}
+
+// escape reads the rest of an escape sequence after ESC, like "[A" or "[3~".
+func (e *lineEditor) escape() string {
+	b, err := e.in.ReadByte()
+	if err != nil || (b != '[' && b != 'O') {
+		return ""
+	}
+	seq := []byte{b}
+	for {
+		c, err := e.in.ReadByte()
+		if err != nil {
+			return ""
+		}
+		seq = append(seq, c)
+		// a sequence ends with a letter or ~
+		if c >= '@' && c <= '~' {
+			return string(seq)
+		}
+	}
+}
+
+// add appends a line to the history, unless it's empty or the same as the last one.
+func (e *lineEditor) add(line string) {
+	if strings.TrimSpace(line) == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+}
+
+// loadHistory reads the history from a file, one line per entry.
+func (e *lineEditor) loadHistory(name string) {
+	f, err := os.Open(name)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		e.add(scanner.Text())
+	}
+}
+
+// saveHistory writes the history to a file.
+func (e *lineEditor) saveHistory(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, line := range e.history {
+		w.WriteString(line + "\n")
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLineEditor(t *testing.T) {
+	input := "helo\x1b[Dl\r" + // left
+		"SET a b\x17c\r" + // Ctrl-W
+		"xyz\x15GET k\r" + // Ctrl-U
+		"héllo\x7f\r" + // backspace
+		"\x1b[A\x1b[A\x1b[A\x1b[B\x01\x1b[3~g\r" + // history, Ctrl-A and Delete
+		"abc\x03" + // Ctrl-C
+		"\x04" // Ctrl-D
+	e := newLineEditor(strings.NewReader(input), io.Discard)
+
+	for _, want := range []string{"hello", "SET a c", "GET k", "héll", "gET k"} {
+		line, err := e.readLine("> ")
+		if err != nil || line != want {
+			t.Errorf("expected %q but got %q, %v", want, line, err)
+		}
+	}
+	if _, err := e.readLine("> "); err != errInterrupted {
+		t.Errorf("expected errInterrupted but got %v", err)
+	}
+	if _, err := e.readLine("> "); err != io.EOF {
+		t.Errorf("expected EOF but got %v", err)
+	}
+
+	name := filepath.Join(t.TempDir(), "history")
+	if err := e.saveHistory(name); err != nil {
+		t.Fatal(err)
+	}
+	loaded := newLineEditor(strings.NewReader(""), io.Discard)
+	loaded.loadHistory(name)
+	want := []string{"hello", "SET a c", "GET k", "héll", "gET k"}
+	if !reflect.DeepEqual(loaded.history, want) {
+		t.Errorf("expected history %q but got %q", want, loaded.history)
+	}
+}
@@ -0,0 +1,387 @@
+// resp3-cli is an interactive RESP3 shell which works with any RESP3 server.
+//
+// Unlike redis-cli it shows the RESP3 details of the replies, like attributes and verbatim formats,
+// and it prints push messages as soon as they arrive.
+//
+//	resp3-cli -h 127.0.0.1 -p 6379
+//	resp3-cli --raw GET key
+//	cat commands.txt | resp3-cli --pipe
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/smallnest/resp3"
+)
+
+var (
+	host     = flag.String("h", "127.0.0.1", "server hostname")
+	port     = flag.Int("p", 6379, "server port")
+	user     = flag.String("user", "", "username to authenticate with")
+	password = flag.String("a", "", "password to authenticate with")
+	db       = flag.Int("n", 0, "database number")
+	raw      = flag.Bool("raw", false, "use raw formatting for replies")
+	resp2    = flag.Bool("resp2", false, "use the RESP2 protocol")
+	resp3Flg = flag.Bool("resp3", true, "use the RESP3 protocol")
+	pipe     = flag.Bool("pipe", false, "read commands from stdin and pipeline them to the server")
+	timeout  = flag.Duration("t", 5*time.Second, "dial timeout")
+)
+
+// commands whose replies are push messages in RESP3, so no normal reply is waited for.
+var subscribeCommands = map[string]bool{
+	"SUBSCRIBE":    true,
+	"PSUBSCRIBE":   true,
+	"SSUBSCRIBE":   true,
+	"UNSUBSCRIBE":  true,
+	"PUNSUBSCRIBE": true,
+	"SUNSUBSCRIBE": true,
+}
+
+func main() {
+	flag.Parse()
+	if *resp2 {
+		*resp3Flg = false
+	}
+
+	addr := net.JoinHostPort(*host, strconv.Itoa(*port))
+	conn, err := net.DialTimeout("tcp", addr, *timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not connect to %s: %v\n", addr, err)
+		os.Exit(1)
+	}
+
+	c := &client{
+		conn:    conn,
+		w:       resp3.NewWriter(conn),
+		r:       resp3.NewReader(conn),
+		replies: make(chan reply),
+		out:     os.Stdout,
+	}
+	defer c.close()
+	if err := c.handshake(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	switch {
+	case *pipe:
+		os.Exit(runPipe(conn, os.Stdin, os.Stdout))
+	case flag.NArg() > 0:
+		go c.readLoop()
+		if err := c.do(flag.Args()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	default:
+		go c.readLoop()
+		c.repl(addr)
+	}
+}
+
+type reply struct {
+	v   *resp3.Value
+	err error
+}
+
+type client struct {
+	conn   net.Conn
+	w      *resp3.Writer
+	r      *resp3.Reader
+	closed int32
+
+	// pending is the number of commands waiting for a reply.
+	pending int32
+	replies chan reply
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// handshake sends HELLO, AUTH and SELECT before the reader loop starts.
+func (c *client) handshake() error {
+	var cmds [][]string
+	if *resp3Flg {
+		hello := []string{"HELLO", "3"}
+		if *password != "" {
+			u := *user
+			if u == "" {
+				u = "default"
+			}
+			hello = append(hello, "AUTH", u, *password)
+		}
+		cmds = append(cmds, hello)
+	} else if *password != "" {
+		if *user != "" {
+			cmds = append(cmds, []string{"AUTH", *user, *password})
+		} else {
+			cmds = append(cmds, []string{"AUTH", *password})
+		}
+	}
+	if *db != 0 {
+		cmds = append(cmds, []string{"SELECT", strconv.Itoa(*db)})
+	}
+
+	for _, cmd := range cmds {
+		if err := c.w.WriteCommand(cmd...); err != nil {
+			return err
+		}
+		v, _, err := c.r.ReadValue()
+		if err != nil {
+			return err
+		}
+		if v.Type == resp3.TypeSimpleError || v.Type == resp3.TypeBlobError {
+			return fmt.Errorf("%s failed: %s", cmd[0], v.Err)
+		}
+	}
+	return nil
+}
+
+// readLoop reads all values of the connection. Push messages and values nobody waits for are printed at once,
+// other values are replies of the pending commands.
+func (c *client) readLoop() {
+	for {
+		v, _, err := c.r.ReadValue()
+		if err != nil {
+			if atomic.LoadInt32(&c.pending) > 0 {
+				c.replies <- reply{err: err}
+			} else if atomic.LoadInt32(&c.closed) == 0 {
+				c.mu.Lock()
+				fmt.Fprintf(c.out, "\nconnection closed: %v\n", err)
+				c.mu.Unlock()
+			}
+			return
+		}
+
+		if v.Type != resp3.TypePush && c.takePending() {
+			c.replies <- reply{v: v}
+			continue
+		}
+		c.print(v)
+	}
+}
+
+// takePending decreases pending if a command is waiting for a reply.
+// do decreases it too when a command can't be sent, so it's compared and swapped.
+func (c *client) takePending() bool {
+	for {
+		n := atomic.LoadInt32(&c.pending)
+		if n <= 0 {
+			return false
+		}
+		if atomic.CompareAndSwapInt32(&c.pending, n, n-1) {
+			return true
+		}
+	}
+}
+
+func (c *client) close() {
+	atomic.StoreInt32(&c.closed, 1)
+	c.conn.Close()
+}
+
+// do sends a command and prints its reply.
+func (c *client) do(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+
+	wait := !subscribeCommands[strings.ToUpper(args[0])]
+	if wait {
+		atomic.AddInt32(&c.pending, 1)
+	}
+	if err := c.w.WriteCommand(args...); err != nil {
+		if wait && !c.takePending() {
+			// readLoop took a value as the reply
+			<-c.replies
+		}
+		return err
+	}
+	if !wait {
+		return nil
+	}
+
+	rep := <-c.replies
+	if rep.err != nil {
+		return rep.err
+	}
+	c.print(rep.v)
+	return nil
+}
+
+func (c *client) print(v *resp3.Value) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if *raw {
+		io.WriteString(c.out, rawFormat(v))
+		return
+	}
+	io.WriteString(c.out, v.Pretty())
+}
+
+func (c *client) repl(addr string) {
+	prompt := addr + "> "
+	out := &lockedWriter{mu: &c.mu, w: c.out}
+	var readLine func() (string, error)
+	if fd := os.Stdin.Fd(); isTerminal(fd) {
+		e := newLineEditor(os.Stdin, out)
+		e.raw = func() (func(), error) {
+			return makeRaw(fd)
+		}
+		historyFile := filepath.Join(os.Getenv("HOME"), ".resp3cli_history")
+		e.loadHistory(historyFile)
+		defer e.saveHistory(historyFile)
+		readLine = func() (string, error) {
+			return e.readLine(prompt)
+		}
+	} else {
+		// the input isn't edited if it's not a terminal
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 64*1024), 512*1024*1024)
+		readLine = func() (string, error) {
+			io.WriteString(out, prompt)
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return "", err
+				}
+				return "", io.EOF
+			}
+			return scanner.Text(), nil
+		}
+	}
+
+	for {
+		input, err := readLine()
+		if err == errInterrupted {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		args, err := splitArgs(input)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		switch strings.ToLower(args[0]) {
+		case "quit", "exit":
+			return
+		}
+		if err := c.do(args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+	}
+}
+
+// lockedWriter writes with the lock of the output, so the prompt isn't mixed with push messages.
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// runPipe sends all commands of in without waiting for replies and then counts the replies.
+func runPipe(conn net.Conn, in io.Reader, out io.Writer) int {
+	bw := bufio.NewWriter(conn)
+	w := resp3.NewWriter(bw)
+	r := resp3.NewReader(conn)
+
+	var sent int64
+	var writeErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64*1024), 512*1024*1024)
+		for scanner.Scan() {
+			args, err := splitArgs(scanner.Text())
+			if err != nil {
+				writeErr = err
+				return
+			}
+			if len(args) == 0 {
+				continue
+			}
+			if writeErr = w.WriteCommand(args...); writeErr != nil {
+				return
+			}
+			// subscribe commands are confirmed by push messages for every channel,
+			// which are unknown for UNSUBSCRIBE without channels, so they are not counted
+			if !subscribeCommands[strings.ToUpper(args[0])] {
+				atomic.AddInt64(&sent, 1)
+			}
+		}
+		if writeErr = scanner.Err(); writeErr == nil {
+			writeErr = bw.Flush()
+		}
+	}()
+
+	var replies, errors int64
+	finished := false
+	for {
+		if !finished {
+			select {
+			case <-done:
+				if writeErr != nil {
+					fmt.Fprintln(os.Stderr, writeErr)
+					return 1
+				}
+				finished = true
+			default:
+			}
+		}
+
+		if replies == atomic.LoadInt64(&sent) {
+			if finished {
+				break
+			}
+			// nothing is outstanding, wait for the writer to send more commands
+			select {
+			case <-done:
+			case <-time.After(10 * time.Millisecond):
+			}
+			continue
+		}
+
+		v, _, err := r.ReadValue()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if v.Type == resp3.TypePush || (!*resp3Flg && resp3.IsPubSubArray(v)) {
+			continue
+		}
+		replies++
+		if v.Type == resp3.TypeSimpleError || v.Type == resp3.TypeBlobError {
+			errors++
+			fmt.Fprintln(out, v.Err)
+		}
+	}
+
+	fmt.Fprintf(out, "All data transferred. errors: %d, replies: %d\n", errors, replies)
+	if errors > 0 {
+		return 1
+	}
+	return 0
+}
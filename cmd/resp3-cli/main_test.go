@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smallnest/resp3"
+)
+
+func TestRunPipe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := resp3.NewReader(conn)
+		subscribed := 0
+		for {
+			cmd, _, err := r.ReadValue()
+			if err != nil {
+				return
+			}
+			switch name := cmd.Elems[0].Str; name {
+			case "SUBSCRIBE", "UNSUBSCRIBE":
+				channels := cmd.Elems[1:]
+				if name == "UNSUBSCRIBE" {
+					channels = []*resp3.Value{resp3.NewBlobStringValue("a"), resp3.NewBlobStringValue("b")}
+				}
+				for _, ch := range channels {
+					if name == "SUBSCRIBE" {
+						subscribed++
+					} else {
+						subscribed--
+					}
+					push := resp3.NewPushValue([]*resp3.Value{resp3.NewBlobStringValue(strings.ToLower(name)), ch, resp3.NewNumberValue(int64(subscribed))})
+					conn.Write([]byte(push.ToRESP3String()))
+				}
+			case "NOPE":
+				conn.Write([]byte("-ERR unknown command\r\n"))
+			default:
+				conn.Write([]byte("+OK\r\n"))
+			}
+		}
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var out bytes.Buffer
+	code := make(chan int, 1)
+	go func() {
+		code <- runPipe(conn, strings.NewReader("SET a 1\nSUBSCRIBE a b\nUNSUBSCRIBE\nNOPE\nPING\n"), &out)
+	}()
+	select {
+	case c := <-code:
+		if c != 1 || !strings.HasSuffix(out.String(), "errors: 1, replies: 3\n") {
+			t.Errorf("unexpected exit code %d and output %q", c, out.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runPipe hangs")
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/smallnest/resp3"
+)
+
+// rawFormat formats a reply as redis-cli --raw does: no quoting and no type information,
+// elements of aggregate types and map keys and values are printed line by line.
+func rawFormat(v *resp3.Value) string {
+	buf := new(strings.Builder)
+	writeRaw(buf, v)
+	buf.WriteByte('\n')
+	return buf.String()
+}
+
+func writeRaw(buf *strings.Builder, v *resp3.Value) {
+	switch v.Type {
+	case resp3.TypeSimpleString, resp3.TypeBlobString, resp3.TypeVerbatimString:
+		buf.WriteString(v.Str)
+	case resp3.TypeSimpleError, resp3.TypeBlobError:
+		buf.WriteString(v.Err)
+	case resp3.TypeNumber:
+		buf.WriteString(strconv.FormatInt(v.Integer, 10))
+	case resp3.TypeDouble:
+		s := v.ToRESP3String()
+		buf.WriteString(s[1 : len(s)-2])
+	case resp3.TypeBigNumber:
+		buf.WriteString(v.BigInt.String())
+	case resp3.TypeBoolean:
+		if v.Boolean {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case resp3.TypeArray, resp3.TypeSet, resp3.TypePush:
+		for i, elem := range v.Elems {
+			if i > 0 {
+				buf.WriteByte('\n')
+			}
+			writeRaw(buf, elem)
+		}
+	case resp3.TypeMap:
+		first := true
+		v.KV.Each(func(key, val interface{}) {
+			if !first {
+				buf.WriteByte('\n')
+			}
+			first = false
+			writeRaw(buf, key.(*resp3.Value))
+			buf.WriteByte('\n')
+			writeRaw(buf, val.(*resp3.Value))
+		})
+	}
+}
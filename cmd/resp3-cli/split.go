@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+)
+
+var errUnbalancedQuotes = errors.New("Invalid argument(s)")
+
+// splitArgs splits a line into arguments with the quoting rules of redis-cli.
+// Double quoted arguments support \n, \r, \t, \b, \a, \\, \" and \xHH escapes,
+// single quoted arguments only support \'. A closing quote must be followed by a space or the end of the line.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	i := 0
+	for {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i >= len(line) {
+			return args, nil
+		}
+
+		var cur []byte
+		inDQ, inSQ := false, false
+		for done := false; !done; {
+			if inDQ {
+				switch {
+				case i >= len(line):
+					return nil, errUnbalancedQuotes
+				case line[i] == '\\' && i+3 < len(line) && line[i+1] == 'x' && isHex(line[i+2]) && isHex(line[i+3]):
+					b, _ := strconv.ParseUint(line[i+2:i+4], 16, 8)
+					cur = append(cur, byte(b))
+					i += 3
+				case line[i] == '\\' && i+1 < len(line):
+					i++
+					switch line[i] {
+					case 'n':
+						cur = append(cur, '\n')
+					case 'r':
+						cur = append(cur, '\r')
+					case 't':
+						cur = append(cur, '\t')
+					case 'b':
+						cur = append(cur, '\b')
+					case 'a':
+						cur = append(cur, '\a')
+					default:
+						cur = append(cur, line[i])
+					}
+				case line[i] == '"':
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, errUnbalancedQuotes
+					}
+					done = true
+				default:
+					cur = append(cur, line[i])
+				}
+			} else if inSQ {
+				switch {
+				case i >= len(line):
+					return nil, errUnbalancedQuotes
+				case line[i] == '\\' && i+1 < len(line) && line[i+1] == '\'':
+					i++
+					cur = append(cur, '\'')
+				case line[i] == '\'':
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, errUnbalancedQuotes
+					}
+					done = true
+				default:
+					cur = append(cur, line[i])
+				}
+			} else {
+				switch {
+				case i >= len(line) || isSpace(line[i]):
+					done = true
+				case line[i] == '"':
+					inDQ = true
+				case line[i] == '\'':
+					inSQ = true
+				default:
+					cur = append(cur, line[i])
+				}
+			}
+			if i < len(line) {
+				i++
+			}
+		}
+		args = append(args, string(cur))
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\v' || c == '\f'
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
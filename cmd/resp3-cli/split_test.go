@@ -0,0 +1,34 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	var cases = map[string][]string{
+		"":                        nil,
+		"  GET  key ":             {"GET", "key"},
+		`SET "hello world" 'a b'`: {"SET", "hello world", "a b"},
+		`SET k "\x41\n\"\\"`:      {"SET", "k", "A\n\"\\"},
+		`SET k 'it\'s'`:           {"SET", "k", "it's"},
+		`SET k ""`:                {"SET", "k", ""},
+		`a"b c"`:                  {"ab c"},
+	}
+	for line, expected := range cases {
+		args, err := splitArgs(line)
+		if err != nil {
+			t.Errorf("failed to split %q: %v", line, err)
+			continue
+		}
+		if !reflect.DeepEqual(args, expected) {
+			t.Errorf("expected %q but got %q", expected, args)
+		}
+	}
+
+	for _, line := range []string{`GET "key`, `GET 'key`, `GET "a"b`} {
+		if _, err := splitArgs(line); err == nil {
+			t.Errorf("expected an error for %q", line)
+		}
+	}
+}
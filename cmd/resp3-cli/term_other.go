@@ -0,0 +1,14 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package main
+
+import "errors"
+
+// isTerminal is false without terminal support, lines are read without editing.
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+func makeRaw(fd uintptr) (restore func(), err error) {
+	return nil, errors.New("raw mode is not supported")
+}
@@ -0,0 +1,109 @@
+// resp3-dump decodes captured RESP3 byte streams and prints every top-level value
+// with its byte offset, its type and a tree view. Corrupt regions are reported and skipped.
+//
+//	resp3-dump capture.bin
+//	resp3-dump -client client.bin -server server.bin
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/smallnest/resp3"
+)
+
+var (
+	clientFile = flag.String("client", "", "bytes sent by the client")
+	serverFile = flag.String("server", "", "bytes sent by the server")
+	rawBytes   = flag.Int("raw", 64, "max raw bytes printed for corrupt frames, 0 for all")
+)
+
+func main() {
+	flag.Parse()
+
+	var err error
+	switch {
+	case *clientFile != "" || *serverFile != "":
+		err = dumpPair(os.Stdout, *clientFile, *serverFile)
+	case flag.NArg() == 1:
+		var frames []*resp3.Frame
+		frames, err = decodeFile(flag.Arg(0))
+		for _, f := range frames {
+			printFrame(os.Stdout, "", f)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "usage: resp3-dump <file> | resp3-dump -client <file> -server <file>")
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func decodeFile(name string) ([]*resp3.Frame, error) {
+	if name == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return resp3.DecodeTrace(data), nil
+}
+
+// dumpPair prints every client frame followed by the server frames up to its reply.
+// Push messages don't answer a command so they are printed without consuming a command.
+func dumpPair(w io.Writer, client, server string) error {
+	requests, err := decodeFile(client)
+	if err != nil {
+		return err
+	}
+	replies, err := decodeFile(server)
+	if err != nil {
+		return err
+	}
+
+	j := 0
+	for _, req := range requests {
+		printFrame(w, "C ", req)
+		if req.Err != nil {
+			continue
+		}
+		for j < len(replies) {
+			rep := replies[j]
+			j++
+			printFrame(w, "S ", rep)
+			if rep.Err == nil && rep.Value.Type != resp3.TypePush {
+				break
+			}
+		}
+	}
+	for ; j < len(replies); j++ {
+		printFrame(w, "S ", replies[j])
+	}
+	return nil
+}
+
+func printFrame(w io.Writer, direction string, f *resp3.Frame) {
+	if f.Err != nil {
+		raw := f.Raw
+		suffix := ""
+		if *rawBytes > 0 && len(raw) > *rawBytes {
+			raw, suffix = raw[:*rawBytes], "..."
+		}
+		fmt.Fprintf(w, "%s@%d (%d bytes) corrupt: %v\n    %s%s\n", direction, f.Offset, len(f.Raw), f.Err, strconv.Quote(string(raw)), suffix)
+		return
+	}
+
+	fmt.Fprintf(w, "%s@%d (%d bytes)\n", direction, f.Offset, len(f.Raw))
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(f.Value.Tree(), "\n"), "\n") {
+		fmt.Fprint(w, "    ", line)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,467 @@
+package resp3
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CommandInfo is the metadata of a command, from COMMAND INFO and COMMAND DOCS.
+type CommandInfo struct {
+	// Name is lower case, subcommands are named like "xinfo|stream".
+	Name string
+	// Arity is the number of arguments including the name, -N means at least N.
+	Arity int
+	Flags []string
+	// FirstKey, LastKey and Step are the legacy key positions, LastKey is negative to count from the end.
+	// They are 0 if the command has no keys or if its keys are movable.
+	FirstKey, LastKey, Step int
+	ACLCategories           []string
+	Tips                    []string
+	KeySpecs                []KeySpec
+	Subcommands             []*CommandInfo
+
+	// From COMMAND DOCS.
+	Summary, Since, Group, Complexity string
+}
+
+// KeySpec tells how to find keys in the arguments of a command.
+// The search begins at an index or after a keyword, then the keys are found in a range or by a number of keys.
+type KeySpec struct {
+	Flags []string
+
+	// BeginSearch is "index" or "keyword".
+	BeginSearch string
+	Index       int
+	Keyword     string
+	// StartFrom is where the keyword is searched from, it's negative to search backwards from the end.
+	StartFrom int
+
+	// FindKeys is "range" or "keynum".
+	FindKeys string
+	// LastKey is relative to the beginning, it's negative to count from the end.
+	// With Limit the keys are in the first 1/Limit of the remaining arguments.
+	LastKey, KeyStep, Limit int
+	// KeyNumIdx is the index of the number of keys and FirstKey is the index of the first key, relative to the beginning.
+	KeyNumIdx, FirstKey int
+}
+
+// HasFlag checks a flag of the command, like "write" or "blocking".
+func (info *CommandInfo) HasFlag(flag string) bool {
+	for _, f := range info.Flags {
+		if strings.EqualFold(f, flag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadOnly checks the readonly flag. Readonly commands are safe to retry and can be sent to replicas.
+func (info *CommandInfo) ReadOnly() bool {
+	return info.HasFlag("readonly")
+}
+
+// Write checks the write flag.
+func (info *CommandInfo) Write() bool {
+	return info.HasFlag("write")
+}
+
+// Blocking checks the blocking flag.
+func (info *CommandInfo) Blocking() bool {
+	return info.HasFlag("blocking")
+}
+
+// PubSub checks the pubsub flag.
+func (info *CommandInfo) PubSub() bool {
+	return info.HasFlag("pubsub")
+}
+
+// KeysOf returns the keys in the arguments of the command, with its name.
+// The key specs are used if there are, otherwise the legacy key positions.
+// Keys which are not found because the arguments are invalid are skipped.
+func (info *CommandInfo) KeysOf(args []string) []string {
+	var positions []int
+	if len(info.KeySpecs) == 0 {
+		positions = keyRange(len(args), info.FirstKey, legacyLastKey(len(args), info.LastKey), info.Step)
+	}
+	for _, spec := range info.KeySpecs {
+		positions = append(positions, spec.positions(args)...)
+	}
+
+	var keys []string
+	seen := make(map[int]bool)
+	for _, i := range positions {
+		if !seen[i] {
+			seen[i] = true
+			keys = append(keys, args[i])
+		}
+	}
+	return keys
+}
+
+func legacyLastKey(argc, last int) int {
+	if last < 0 {
+		return argc + last
+	}
+	return last
+}
+
+// positions returns the indexes of the keys, as getKeysUsingKeySpecs of Redis.
+func (spec KeySpec) positions(args []string) []int {
+	first := 0
+	switch spec.BeginSearch {
+	case "index":
+		first = spec.Index
+	case "keyword":
+		start, end, step := spec.StartFrom, len(args)-1, 1
+		if spec.StartFrom < 0 {
+			start, end, step = len(args)+spec.StartFrom, 0, -1
+		}
+		found := false
+		for i := start; i >= 0 && i < len(args) && (i-end)*step <= 0; i += step {
+			if strings.EqualFold(args[i], spec.Keyword) {
+				first, found = i+1, true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	switch spec.FindKeys {
+	case "range":
+		last := first + spec.LastKey
+		if spec.LastKey < 0 {
+			if spec.Limit == 0 {
+				last = len(args) + spec.LastKey
+			} else {
+				last = first + (len(args)-first)/spec.Limit + spec.LastKey
+			}
+		}
+		return keyRange(len(args), first, last, spec.KeyStep)
+	case "keynum":
+		if first+spec.KeyNumIdx >= len(args) {
+			return nil
+		}
+		n, err := strconv.Atoi(args[first+spec.KeyNumIdx])
+		if err != nil || n < 0 {
+			return nil
+		}
+		first += spec.FirstKey
+		return keyRange(len(args), first, first+n-1, spec.KeyStep)
+	}
+	return nil
+}
+
+// keyRange returns the indexes from first to last which are in the arguments.
+func keyRange(argc, first, last, step int) []int {
+	if first <= 0 || step <= 0 {
+		return nil
+	}
+	var rt []int
+	for i := first; i <= last && i < argc; i += step {
+		rt = append(rt, i)
+	}
+	return rt
+}
+
+// CommandRegistry is a registry of command metadata, to find the keys of commands and check their flags.
+// It's safe for concurrent use.
+type CommandRegistry struct {
+	mu   sync.RWMutex
+	cmds map[string]*CommandInfo
+}
+
+// NewCommandRegistry returns a registry with the built-in snapshot of the commands of Redis 7.2,
+// it can be refreshed from a server by Load.
+func NewCommandRegistry() *CommandRegistry {
+	r := &CommandRegistry{cmds: make(map[string]*CommandInfo)}
+	for _, info := range staticCommands() {
+		r.cmds[info.Name] = info
+	}
+	return r
+}
+
+var defaultRegistry = NewCommandRegistry()
+
+// KeysOf returns the keys of a command by the built-in snapshot, or nil if the command is unknown.
+func KeysOf(args []string) []string {
+	return defaultRegistry.KeysOf(args)
+}
+
+// Lookup returns the metadata of a command, or of its subcommand if the command has subcommands, like XINFO STREAM.
+// It returns nil if the command is unknown.
+func (r *CommandRegistry) Lookup(args ...string) *CommandInfo {
+	if len(args) == 0 {
+		return nil
+	}
+	r.mu.RLock()
+	info := r.cmds[strings.ToLower(args[0])]
+	r.mu.RUnlock()
+	if info == nil || len(info.Subcommands) == 0 || len(args) < 2 {
+		return info
+	}
+	name := info.Name + "|" + strings.ToLower(args[1])
+	for _, sub := range info.Subcommands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return info
+}
+
+// KeysOf returns the keys of a command, or nil if the command is unknown.
+func (r *CommandRegistry) KeysOf(args []string) []string {
+	info := r.Lookup(args...)
+	if info == nil {
+		return nil
+	}
+	return info.KeysOf(args)
+}
+
+// Load replaces the commands by the replies of COMMAND and COMMAND DOCS from a server.
+// The docs are skipped if the server doesn't have COMMAND DOCS, which is added in Redis 7.0.
+func (r *CommandRegistry) Load(ctx context.Context, d Doer) error {
+	v, err := d.DoContext(ctx, "COMMAND")
+	if err != nil {
+		return err
+	}
+	infos, err := ParseCommandInfo(v)
+	if err != nil {
+		return err
+	}
+	docs, err := d.DoContext(ctx, "COMMAND", "DOCS")
+	if err != nil && !isReplyError(err) {
+		return err
+	}
+	if err == nil {
+		if err := ParseCommandDocs(docs, infos); err != nil {
+			return err
+		}
+	}
+
+	cmds := make(map[string]*CommandInfo, len(infos))
+	for _, info := range infos {
+		cmds[info.Name] = info
+	}
+	r.mu.Lock()
+	r.cmds = cmds
+	r.mu.Unlock()
+	return nil
+}
+
+// Add adds or replaces commands.
+func (r *CommandRegistry) Add(infos ...*CommandInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, info := range infos {
+		r.cmds[info.Name] = info
+	}
+}
+
+// ParseCommandInfo parses a reply of COMMAND or COMMAND INFO, in RESP3 or RESP2.
+// Unknown commands, which are null in the reply of COMMAND INFO, are skipped.
+func ParseCommandInfo(v *Value) ([]*CommandInfo, error) {
+	if v.Type != TypeArray {
+		return nil, ErrUnexpectedReply
+	}
+	var infos []*CommandInfo
+	for _, elem := range v.Elems {
+		if isNil(elem) {
+			continue
+		}
+		info, err := parseCommandInfo(elem)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// parseCommandInfo parses [name, arity, flags, first key, last key, step, ACL categories, tips, key specs, subcommands],
+// the last four are added in Redis 6.0 and 7.0.
+func parseCommandInfo(v *Value) (*CommandInfo, error) {
+	if v.Type != TypeArray || len(v.Elems) < 6 {
+		return nil, ErrUnexpectedReply
+	}
+	e := v.Elems
+	for _, i := range []int{1, 3, 4, 5} {
+		if e[i].Type != TypeNumber {
+			return nil, ErrUnexpectedReply
+		}
+	}
+	info := &CommandInfo{
+		Name:     strings.ToLower(e[0].Str),
+		Arity:    int(e[1].Integer),
+		Flags:    stringElems(e[2]),
+		FirstKey: int(e[3].Integer),
+		LastKey:  int(e[4].Integer),
+		Step:     int(e[5].Integer),
+	}
+	if len(e) > 6 {
+		info.ACLCategories = stringElems(e[6])
+	}
+	if len(e) > 7 {
+		info.Tips = stringElems(e[7])
+	}
+	if len(e) > 8 {
+		for _, elem := range e[8].Elems {
+			spec, err := parseKeySpec(elem)
+			if err != nil {
+				return nil, err
+			}
+			info.KeySpecs = append(info.KeySpecs, spec)
+		}
+	}
+	if len(e) > 9 {
+		for _, elem := range e[9].Elems {
+			sub, err := parseCommandInfo(elem)
+			if err != nil {
+				return nil, err
+			}
+			info.Subcommands = append(info.Subcommands, sub)
+		}
+	}
+	return info, nil
+}
+
+func parseKeySpec(v *Value) (KeySpec, error) {
+	var spec KeySpec
+	f, err := fieldsOf(v)
+	if err != nil {
+		return spec, err
+	}
+	if flags := f["flags"]; flags != nil {
+		spec.Flags = stringElems(flags)
+	}
+
+	if bs := f["begin_search"]; bs != nil {
+		typ, params, err := searchSpec(bs)
+		if err != nil {
+			return spec, err
+		}
+		spec.BeginSearch = typ
+		spec.Index = params.int("index")
+		spec.Keyword = params.str("keyword")
+		spec.StartFrom = params.int("startfrom")
+	}
+	if fk := f["find_keys"]; fk != nil {
+		typ, params, err := searchSpec(fk)
+		if err != nil {
+			return spec, err
+		}
+		spec.FindKeys = typ
+		spec.LastKey = params.int("lastkey")
+		spec.KeyStep = params.int("keystep")
+		spec.Limit = params.int("limit")
+		spec.KeyNumIdx = params.int("keynumidx")
+		spec.FirstKey = params.int("firstkey")
+	}
+	return spec, nil
+}
+
+// searchSpec parses {type: ..., spec: {...}} of begin_search and find_keys.
+func searchSpec(v *Value) (string, fields, error) {
+	f, err := fieldsOf(v)
+	if err != nil {
+		return "", nil, err
+	}
+	if f["spec"] == nil {
+		return f.str("type"), nil, nil
+	}
+	params, err := fieldsOf(f["spec"])
+	return f.str("type"), params, err
+}
+
+// ParseCommandDocs parses a reply of COMMAND DOCS, in RESP3 or RESP2, and sets the docs of the commands and their subcommands.
+func ParseCommandDocs(v *Value, infos []*CommandInfo) error {
+	byName := make(map[string]*CommandInfo)
+	var index func(infos []*CommandInfo)
+	index = func(infos []*CommandInfo) {
+		for _, info := range infos {
+			byName[info.Name] = info
+			index(info.Subcommands)
+		}
+	}
+	index(infos)
+
+	var parse func(v *Value) error
+	parse = func(v *Value) error {
+		names, docs, err := pairs(v)
+		if err != nil {
+			return err
+		}
+		for i, name := range names {
+			f, err := fieldsOf(docs[i])
+			if err != nil {
+				return err
+			}
+			if info := byName[strings.ToLower(name.Str)]; info != nil {
+				info.Summary = f.str("summary")
+				info.Since = f.str("since")
+				info.Group = f.str("group")
+				info.Complexity = f.str("complexity")
+			}
+			if subs := f["subcommands"]; subs != nil {
+				if err := parse(subs); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return parse(v)
+}
+
+// fields are the entries of a map with string keys.
+type fields map[string]*Value
+
+func fieldsOf(v *Value) (fields, error) {
+	keys, values, err := pairs(v)
+	if err != nil {
+		return nil, err
+	}
+	rt := make(fields, len(keys))
+	for i, k := range keys {
+		rt[k.Str] = values[i]
+	}
+	return rt, nil
+}
+
+// str returns a string field, or "" if it's missing.
+func (f fields) str(name string) string {
+	if v := f[name]; v != nil {
+		return v.Str
+	}
+	return ""
+}
+
+// int returns an integer field, or 0 if it's missing.
+func (f fields) int(name string) int {
+	if v := f[name]; v != nil {
+		return int(v.Integer)
+	}
+	return 0
+}
+
+// integer returns an integer field, or 0 if it's missing.
+func (f fields) integer(name string) int64 {
+	if v := f[name]; v != nil {
+		return v.Integer
+	}
+	return 0
+}
+
+// stringElems returns the strings of an array or a set.
+func stringElems(v *Value) []string {
+	rt := make([]string, 0, len(v.Elems))
+	for _, elem := range v.Elems {
+		rt = append(rt, elem.Str)
+	}
+	return rt
+}
@@ -0,0 +1,101 @@
+package resp3
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeysOf(t *testing.T) {
+	tests := []struct {
+		args string
+		want []string
+	}{
+		{"GET a", []string{"a"}},
+		{"MSET a 1 b 2", []string{"a", "b"}},
+		{"BLPOP a b 0", []string{"a", "b"}},
+		{"EVAL script 2 a b arg", []string{"a", "b"}},
+		{"ZUNIONSTORE dst 2 a b WEIGHTS 1 2", []string{"dst", "a", "b"}},
+		{"XREAD COUNT 1 STREAMS a b 0 0", []string{"a", "b"}},
+		{"XREADGROUP GROUP g c STREAMS a >", []string{"a"}},
+		{"SORT a BY w LIMIT 0 1 STORE dst", []string{"a", "dst"}},
+		{"XINFO STREAM s", []string{"s"}},
+		{"ping", nil},
+		{"NOPE a", nil},
+		{"EVAL script x a", nil},
+	}
+	for _, tt := range tests {
+		if got := KeysOf(strings.Fields(tt.args)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v but got %v", tt.args, tt.want, got)
+		}
+	}
+}
+
+func TestCommandRegistry_Lookup(t *testing.T) {
+	r := NewCommandRegistry()
+	if info := r.Lookup("xgroup", "CREATE", "s", "g", "$"); info == nil || info.Name != "xgroup|create" || !info.Write() {
+		t.Errorf("unexpected %+v", info)
+	}
+	if info := r.Lookup("XINFO"); info == nil || len(info.Subcommands) != 3 {
+		t.Errorf("unexpected %+v", info)
+	}
+	if info := r.Lookup("BRPOP"); !info.Blocking() || info.ReadOnly() {
+		t.Errorf("unexpected %+v", info)
+	}
+	if info := r.Lookup("SUBSCRIBE"); !info.PubSub() {
+		t.Errorf("unexpected %+v", info)
+	}
+}
+
+// the replies of Redis 7.2 for GET and XINFO
+const (
+	commandInfoReply = "*2\r\n" +
+		"*10\r\n$3\r\nget\r\n:2\r\n~2\r\n+readonly\r\n+fast\r\n:1\r\n:1\r\n:1\r\n" +
+		"~3\r\n+@read\r\n+@string\r\n+@fast\r\n~0\r\n" +
+		"*1\r\n%3\r\n+flags\r\n~2\r\n+RO\r\n+access\r\n" +
+		"+begin_search\r\n%2\r\n+type\r\n+index\r\n+spec\r\n%1\r\n+index\r\n:1\r\n" +
+		"+find_keys\r\n%2\r\n+type\r\n+range\r\n+spec\r\n%3\r\n+lastkey\r\n:0\r\n+keystep\r\n:1\r\n+limit\r\n:0\r\n" +
+		"*0\r\n" +
+		"*10\r\n$5\r\nxinfo\r\n:-2\r\n~0\r\n:0\r\n:0\r\n:0\r\n~1\r\n+@slow\r\n~0\r\n*0\r\n" +
+		"*1\r\n*10\r\n$12\r\nxinfo|stream\r\n:-3\r\n~1\r\n+readonly\r\n:2\r\n:2\r\n:1\r\n~0\r\n~0\r\n" +
+		"*1\r\n%3\r\n+flags\r\n~1\r\n+RO\r\n" +
+		"+begin_search\r\n%2\r\n+type\r\n+index\r\n+spec\r\n%1\r\n+index\r\n:2\r\n" +
+		"+find_keys\r\n%2\r\n+type\r\n+range\r\n+spec\r\n%3\r\n+lastkey\r\n:0\r\n+keystep\r\n:1\r\n+limit\r\n:0\r\n" +
+		"*0\r\n"
+	commandDocsReply = "%1\r\n$5\r\nxinfo\r\n%2\r\n+summary\r\n$12\r\nA container.\r\n" +
+		"+subcommands\r\n%1\r\n$12\r\nxinfo|stream\r\n%2\r\n+group\r\n+stream\r\n+since\r\n+5.0.0\r\n"
+)
+
+func TestCommandRegistry_Load(t *testing.T) {
+	d := doerFunc(func(ctx context.Context, args ...string) (*Value, error) {
+		if len(args) == 1 {
+			return FromString(commandInfoReply)
+		}
+		return FromString(commandDocsReply)
+	})
+	r := NewCommandRegistry()
+	if err := r.Load(context.Background(), d); err != nil {
+		t.Fatal(err)
+	}
+
+	get := r.Lookup("GET")
+	want := &CommandInfo{
+		Name: "get", Arity: 2, Flags: []string{"readonly", "fast"}, FirstKey: 1, LastKey: 1, Step: 1,
+		ACLCategories: []string{"@read", "@string", "@fast"}, Tips: []string{},
+		KeySpecs: []KeySpec{{Flags: []string{"RO", "access"}, BeginSearch: "index", Index: 1, FindKeys: "range", KeyStep: 1}},
+	}
+	if !reflect.DeepEqual(get, want) {
+		t.Errorf("expected %+v but got %+v", want, get)
+	}
+	stream := r.Lookup("XINFO", "stream", "s")
+	if stream.Name != "xinfo|stream" || stream.Group != "stream" || stream.Since != "5.0.0" {
+		t.Errorf("unexpected %+v", stream)
+	}
+	if keys := r.KeysOf([]string{"XINFO", "STREAM", "s"}); !reflect.DeepEqual(keys, []string{"s"}) {
+		t.Errorf("unexpected keys %v", keys)
+	}
+	if r.Lookup("SET") != nil {
+		t.Errorf("expected the snapshot to be replaced")
+	}
+}
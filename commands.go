@@ -0,0 +1,555 @@
+package resp3
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// Strings and keys
+
+// Get returns GET key.
+func Get(key string) *StringCmd {
+	return &StringCmd{args: []string{"GET", key}}
+}
+
+// Incr returns INCR key.
+func Incr(key string) *IntCmd {
+	return &IntCmd{args: []string{"INCR", key}}
+}
+
+// IncrBy returns INCRBY key increment.
+func IncrBy(key string, increment int64) *IntCmd {
+	return &IntCmd{args: []string{"INCRBY", key, strconv.FormatInt(increment, 10)}}
+}
+
+// IncrByFloat returns INCRBYFLOAT key increment.
+func IncrByFloat(key string, increment float64) *FloatCmd {
+	return &FloatCmd{args: []string{"INCRBYFLOAT", key, formatDouble(increment)}}
+}
+
+// Del returns DEL key [key ...].
+func Del(keys ...string) *IntCmd {
+	return &IntCmd{args: append([]string{"DEL"}, keys...)}
+}
+
+// Exists returns EXISTS key [key ...].
+func Exists(keys ...string) *IntCmd {
+	return &IntCmd{args: append([]string{"EXISTS"}, keys...)}
+}
+
+// Expire returns PEXPIRE key milliseconds.
+func Expire(key string, ttl time.Duration) *BoolCmd {
+	return &BoolCmd{args: []string{"PEXPIRE", key, strconv.FormatInt(ttl.Milliseconds(), 10)}}
+}
+
+// PTTL returns PTTL key. The reply is -2 if the key doesn't exist and -1 if it has no expiration.
+func PTTL(key string) *IntCmd {
+	return &IntCmd{args: []string{"PTTL", key}}
+}
+
+// SetCmd is SET key value [NX | XX] [EX seconds | PX milliseconds | EXAT unix-time-seconds | KEEPTTL].
+type SetCmd struct {
+	key, value string
+	cond       string
+	expiration []string
+}
+
+// Set returns SET key value.
+func Set(key, value string) *SetCmd {
+	return &SetCmd{key: key, value: value}
+}
+
+// NX sets the key only if it doesn't exist. It replaces XX.
+func (c *SetCmd) NX() *SetCmd {
+	c.cond = "NX"
+	return c
+}
+
+// XX sets the key only if it exists. It replaces NX.
+func (c *SetCmd) XX() *SetCmd {
+	c.cond = "XX"
+	return c
+}
+
+// EX sets the expiration in seconds.
+func (c *SetCmd) EX(seconds int64) *SetCmd {
+	c.expiration = []string{"EX", strconv.FormatInt(seconds, 10)}
+	return c
+}
+
+// PX sets the expiration in milliseconds.
+func (c *SetCmd) PX(milliseconds int64) *SetCmd {
+	c.expiration = []string{"PX", strconv.FormatInt(milliseconds, 10)}
+	return c
+}
+
+// EXAT sets the time when the key expires.
+func (c *SetCmd) EXAT(t time.Time) *SetCmd {
+	c.expiration = []string{"EXAT", strconv.FormatInt(t.Unix(), 10)}
+	return c
+}
+
+// KeepTTL keeps the expiration of the key.
+func (c *SetCmd) KeepTTL() *SetCmd {
+	c.expiration = []string{"KEEPTTL"}
+	return c
+}
+
+// Args returns the command.
+func (c *SetCmd) Args() []string {
+	args := []string{"SET", c.key, c.value}
+	if c.cond != "" {
+		args = append(args, c.cond)
+	}
+	return append(args, c.expiration...)
+}
+
+// Decode decodes a reply. It's false if the key is not set because of NX or XX.
+func (c *SetCmd) Decode(v *Value) (bool, error) {
+	switch {
+	case isNil(v):
+		return false, nil
+	case v.Type == TypeSimpleString:
+		return true, nil
+	}
+	return false, ErrUnexpectedReply
+}
+
+// Do sends the command and decodes the reply.
+func (c *SetCmd) Do(ctx context.Context, d Doer) (bool, error) {
+	v, err := d.DoContext(ctx, c.Args()...)
+	if err != nil {
+		return false, err
+	}
+	return c.Decode(v)
+}
+
+// Hashes
+
+// HSetCmd is HSET key field value [field value ...].
+type HSetCmd struct {
+	args []string
+}
+
+// HSet returns HSET key, the fields are added by Field.
+func HSet(key string) *HSetCmd {
+	return &HSetCmd{args: []string{"HSET", key}}
+}
+
+// Field adds a field.
+func (c *HSetCmd) Field(field, value string) *HSetCmd {
+	c.args = append(c.args, field, value)
+	return c
+}
+
+// Args returns the command.
+func (c *HSetCmd) Args() []string {
+	return c.args
+}
+
+// Decode decodes a reply, the number of the added fields.
+func (c *HSetCmd) Decode(v *Value) (int64, error) {
+	return (&IntCmd{}).Decode(v)
+}
+
+// Do sends the command and decodes the reply.
+func (c *HSetCmd) Do(ctx context.Context, d Doer) (int64, error) {
+	return (&IntCmd{args: c.args}).Do(ctx, d)
+}
+
+// HGet returns HGET key field.
+func HGet(key, field string) *StringCmd {
+	return &StringCmd{args: []string{"HGET", key, field}}
+}
+
+// HGetAll returns HGETALL key.
+func HGetAll(key string) *StringMapCmd {
+	return &StringMapCmd{args: []string{"HGETALL", key}}
+}
+
+// HDel returns HDEL key field [field ...].
+func HDel(key string, fields ...string) *IntCmd {
+	return &IntCmd{args: append([]string{"HDEL", key}, fields...)}
+}
+
+// HExists returns HEXISTS key field.
+func HExists(key, field string) *BoolCmd {
+	return &BoolCmd{args: []string{"HEXISTS", key, field}}
+}
+
+// HIncrBy returns HINCRBY key field increment.
+func HIncrBy(key, field string, increment int64) *IntCmd {
+	return &IntCmd{args: []string{"HINCRBY", key, field, strconv.FormatInt(increment, 10)}}
+}
+
+// Lists
+
+// LPush returns LPUSH key element [element ...].
+func LPush(key string, elements ...string) *IntCmd {
+	return &IntCmd{args: append([]string{"LPUSH", key}, elements...)}
+}
+
+// RPush returns RPUSH key element [element ...].
+func RPush(key string, elements ...string) *IntCmd {
+	return &IntCmd{args: append([]string{"RPUSH", key}, elements...)}
+}
+
+// LPop returns LPOP key.
+func LPop(key string) *StringCmd {
+	return &StringCmd{args: []string{"LPOP", key}}
+}
+
+// RPop returns RPOP key.
+func RPop(key string) *StringCmd {
+	return &StringCmd{args: []string{"RPOP", key}}
+}
+
+// LRange returns LRANGE key start stop.
+func LRange(key string, start, stop int64) *StringsCmd {
+	return &StringsCmd{args: []string{"LRANGE", key, strconv.FormatInt(start, 10), strconv.FormatInt(stop, 10)}}
+}
+
+// LLen returns LLEN key.
+func LLen(key string) *IntCmd {
+	return &IntCmd{args: []string{"LLEN", key}}
+}
+
+// Sets
+
+// SAdd returns SADD key member [member ...].
+func SAdd(key string, members ...string) *IntCmd {
+	return &IntCmd{args: append([]string{"SADD", key}, members...)}
+}
+
+// SRem returns SREM key member [member ...].
+func SRem(key string, members ...string) *IntCmd {
+	return &IntCmd{args: append([]string{"SREM", key}, members...)}
+}
+
+// SMembers returns SMEMBERS key.
+func SMembers(key string) *StringsCmd {
+	return &StringsCmd{args: []string{"SMEMBERS", key}}
+}
+
+// SIsMember returns SISMEMBER key member.
+func SIsMember(key, member string) *BoolCmd {
+	return &BoolCmd{args: []string{"SISMEMBER", key, member}}
+}
+
+// SCard returns SCARD key.
+func SCard(key string) *IntCmd {
+	return &IntCmd{args: []string{"SCARD", key}}
+}
+
+// Sorted sets
+
+// ZMember is a member of a sorted set with its score.
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// ZAddCmd is ZADD key [NX | XX] [GT | LT] [CH] score member [score member ...].
+type ZAddCmd struct {
+	key     string
+	cond    string
+	cmp     string
+	ch      bool
+	members []string
+}
+
+// ZAdd returns ZADD key, the members are added by Member.
+func ZAdd(key string) *ZAddCmd {
+	return &ZAddCmd{key: key}
+}
+
+// NX only adds new members. It replaces XX, GT and LT.
+func (c *ZAddCmd) NX() *ZAddCmd {
+	c.cond, c.cmp = "NX", ""
+	return c
+}
+
+// XX only updates existing members. It replaces NX.
+func (c *ZAddCmd) XX() *ZAddCmd {
+	c.cond = "XX"
+	return c
+}
+
+// GT only updates existing members if the new score is greater. It replaces LT and NX.
+func (c *ZAddCmd) GT() *ZAddCmd {
+	c.cmp = "GT"
+	if c.cond == "NX" {
+		c.cond = ""
+	}
+	return c
+}
+
+// LT only updates existing members if the new score is less. It replaces GT and NX.
+func (c *ZAddCmd) LT() *ZAddCmd {
+	c.cmp = "LT"
+	if c.cond == "NX" {
+		c.cond = ""
+	}
+	return c
+}
+
+// CH makes the reply the number of the added and updated members.
+func (c *ZAddCmd) CH() *ZAddCmd {
+	c.ch = true
+	return c
+}
+
+// Member adds a member with its score.
+func (c *ZAddCmd) Member(score float64, member string) *ZAddCmd {
+	c.members = append(c.members, formatDouble(score), member)
+	return c
+}
+
+// Args returns the command.
+func (c *ZAddCmd) Args() []string {
+	args := []string{"ZADD", c.key}
+	for _, opt := range []string{c.cond, c.cmp} {
+		if opt != "" {
+			args = append(args, opt)
+		}
+	}
+	if c.ch {
+		args = append(args, "CH")
+	}
+	return append(args, c.members...)
+}
+
+// Decode decodes a reply, the number of the added members.
+func (c *ZAddCmd) Decode(v *Value) (int64, error) {
+	return (&IntCmd{}).Decode(v)
+}
+
+// Do sends the command and decodes the reply.
+func (c *ZAddCmd) Do(ctx context.Context, d Doer) (int64, error) {
+	return (&IntCmd{args: c.Args()}).Do(ctx, d)
+}
+
+// ZScore returns ZSCORE key member.
+func ZScore(key, member string) *FloatCmd {
+	return &FloatCmd{args: []string{"ZSCORE", key, member}}
+}
+
+// ZIncrBy returns ZINCRBY key increment member.
+func ZIncrBy(key string, increment float64, member string) *FloatCmd {
+	return &FloatCmd{args: []string{"ZINCRBY", key, formatDouble(increment), member}}
+}
+
+// ZRem returns ZREM key member [member ...].
+func ZRem(key string, members ...string) *IntCmd {
+	return &IntCmd{args: append([]string{"ZREM", key}, members...)}
+}
+
+// ZCard returns ZCARD key.
+func ZCard(key string) *IntCmd {
+	return &IntCmd{args: []string{"ZCARD", key}}
+}
+
+// ZRangeCmd is ZRANGE key start stop [BYSCORE | BYLEX] [REV] [LIMIT offset count] [WITHSCORES].
+type ZRangeCmd struct {
+	key, start, stop string
+	by               string
+	rev              bool
+	limit            []string
+	withScores       bool
+}
+
+// ZRange returns ZRANGE key start stop, by index unless ByScore or ByLex is set.
+func ZRange(key, start, stop string) *ZRangeCmd {
+	return &ZRangeCmd{key: key, start: start, stop: stop}
+}
+
+// ByScore makes start and stop scores.
+func (c *ZRangeCmd) ByScore() *ZRangeCmd {
+	c.by = "BYSCORE"
+	return c
+}
+
+// ByLex makes start and stop lexicographical ranges.
+func (c *ZRangeCmd) ByLex() *ZRangeCmd {
+	c.by = "BYLEX"
+	return c
+}
+
+// Rev reverses the order.
+func (c *ZRangeCmd) Rev() *ZRangeCmd {
+	c.rev = true
+	return c
+}
+
+// Limit returns count members from offset, with ByScore or ByLex.
+func (c *ZRangeCmd) Limit(offset, count int64) *ZRangeCmd {
+	c.limit = []string{"LIMIT", strconv.FormatInt(offset, 10), strconv.FormatInt(count, 10)}
+	return c
+}
+
+// WithScores returns the scores too.
+func (c *ZRangeCmd) WithScores() *ZRangeCmd {
+	c.withScores = true
+	return c
+}
+
+// Args returns the command.
+func (c *ZRangeCmd) Args() []string {
+	args := []string{"ZRANGE", c.key, c.start, c.stop}
+	if c.by != "" {
+		args = append(args, c.by)
+	}
+	if c.rev {
+		args = append(args, "REV")
+	}
+	args = append(args, c.limit...)
+	if c.withScores {
+		args = append(args, "WITHSCORES")
+	}
+	return args
+}
+
+// Decode decodes a reply. The scores are 0 without WithScores.
+//
+// With scores the reply is an array of [member, score] pairs in RESP3, and a flat array of members and scores in RESP2.
+func (c *ZRangeCmd) Decode(v *Value) ([]ZMember, error) {
+	if v.Type != TypeArray {
+		return nil, ErrUnexpectedReply
+	}
+	if !c.withScores {
+		rt := make([]ZMember, len(v.Elems))
+		for i, elem := range v.Elems {
+			rt[i].Member = elem.Str
+		}
+		return rt, nil
+	}
+
+	var members, scores []*Value
+	for i := 0; i < len(v.Elems); i++ {
+		if elem := v.Elems[i]; elem.Type == TypeArray {
+			if len(elem.Elems) != 2 {
+				return nil, ErrUnexpectedReply
+			}
+			members = append(members, elem.Elems[0])
+			scores = append(scores, elem.Elems[1])
+		} else if i+1 < len(v.Elems) {
+			members = append(members, elem)
+			scores = append(scores, v.Elems[i+1])
+			i++
+		} else {
+			return nil, ErrUnexpectedReply
+		}
+	}
+	rt := make([]ZMember, len(members))
+	for i := range members {
+		score, ok, err := (&FloatCmd{}).Decode(scores[i])
+		if err != nil || !ok {
+			return nil, ErrUnexpectedReply
+		}
+		rt[i] = ZMember{Member: members[i].Str, Score: score}
+	}
+	return rt, nil
+}
+
+// Do sends the command and decodes the reply.
+func (c *ZRangeCmd) Do(ctx context.Context, d Doer) ([]ZMember, error) {
+	v, err := d.DoContext(ctx, c.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(v)
+}
+
+// Streams
+
+// XAddCmd is XADD key [NOMKSTREAM] [MAXLEN | MINID [= | ~] threshold [LIMIT count]] <* | id> field value [field value ...].
+type XAddCmd struct {
+	key        string
+	noMkStream bool
+	trim       []string
+	approx     bool
+	limit      []string
+	id         string
+	fields     []string
+}
+
+// XAdd returns XADD key *, the fields are added by Field.
+func XAdd(key string) *XAddCmd {
+	return &XAddCmd{key: key, id: "*"}
+}
+
+// NoMkStream doesn't create the stream if it doesn't exist, the reply is null then.
+func (c *XAddCmd) NoMkStream() *XAddCmd {
+	c.noMkStream = true
+	return c
+}
+
+// MaxLen trims the stream to the length.
+func (c *XAddCmd) MaxLen(n int64) *XAddCmd {
+	c.trim = []string{"MAXLEN", strconv.FormatInt(n, 10)}
+	return c
+}
+
+// MinID trims the entries with IDs lower than id.
+func (c *XAddCmd) MinID(id string) *XAddCmd {
+	c.trim = []string{"MINID", id}
+	return c
+}
+
+// Approx trims approximately with ~, at most limit entries if it's positive.
+func (c *XAddCmd) Approx(limit int64) *XAddCmd {
+	c.approx = true
+	c.limit = nil
+	if limit > 0 {
+		c.limit = []string{"LIMIT", strconv.FormatInt(limit, 10)}
+	}
+	return c
+}
+
+// ID sets the ID of the entry instead of *.
+func (c *XAddCmd) ID(id string) *XAddCmd {
+	c.id = id
+	return c
+}
+
+// Field adds a field.
+func (c *XAddCmd) Field(field, value string) *XAddCmd {
+	c.fields = append(c.fields, field, value)
+	return c
+}
+
+// Args returns the command.
+func (c *XAddCmd) Args() []string {
+	args := []string{"XADD", c.key}
+	if c.noMkStream {
+		args = append(args, "NOMKSTREAM")
+	}
+	if c.trim != nil {
+		args = append(args, c.trim[0])
+		if c.approx {
+			args = append(args, "~")
+		}
+		args = append(args, c.trim[1])
+		if c.approx {
+			args = append(args, c.limit...)
+		}
+	}
+	args = append(args, c.id)
+	return append(args, c.fields...)
+}
+
+// Decode decodes a reply, the ID of the entry. ok is false if the stream doesn't exist with NoMkStream.
+func (c *XAddCmd) Decode(v *Value) (id string, ok bool, err error) {
+	return (&StringCmd{}).Decode(v)
+}
+
+// Do sends the command and decodes the reply.
+func (c *XAddCmd) Do(ctx context.Context, d Doer) (string, bool, error) {
+	return (&StringCmd{args: c.Args()}).Do(ctx, d)
+}
+
+// XLen returns XLEN key.
+func XLen(key string) *IntCmd {
+	return &IntCmd{args: []string{"XLEN", key}}
+}
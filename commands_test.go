@@ -0,0 +1,53 @@
+package resp3
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommands_Args(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{Set("k", "v").EX(10).NX().Args(), "SET k v NX EX 10"},
+		{Set("k", "v").XX().KeepTTL().Args(), "SET k v XX KEEPTTL"},
+		{Set("k", "v").NX().XX().EX(1).PX(500).Args(), "SET k v XX PX 500"},
+		{Set("k", "v").EXAT(time.Unix(100, 0)).Args(), "SET k v EXAT 100"},
+		{Expire("k", 1500*time.Millisecond).Args(), "PEXPIRE k 1500"},
+		{HSet("h").Field("a", "1").Field("b", "2").Args(), "HSET h a 1 b 2"},
+		{ZAdd("z").Member(1.5, "a").GT().CH().Member(2, "b").Args(), "ZADD z GT CH 1.5 a 2 b"},
+		{ZAdd("z").XX().LT().GT().Member(1, "a").Args(), "ZADD z XX GT 1 a"},
+		{ZAdd("z").XX().GT().NX().Member(1, "a").Args(), "ZADD z NX 1 a"},
+		{ZAdd("z").NX().LT().Member(1, "a").Args(), "ZADD z LT 1 a"},
+		{ZRange("z", "(1", "+inf").ByScore().Rev().Limit(0, 10).WithScores().Args(), "ZRANGE z (1 +inf BYSCORE REV LIMIT 0 10 WITHSCORES"},
+		{XAdd("s").MaxLen(1000).Field("k", "v").Args(), "XADD s MAXLEN 1000 * k v"},
+		{XAdd("s").NoMkStream().MinID("0-1").Approx(100).ID("1-1").Field("k", "v").Args(), "XADD s NOMKSTREAM MINID ~ 0-1 LIMIT 100 1-1 k v"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(tt.args, " "); got != tt.want {
+			t.Errorf("expected %q but got %q", tt.want, got)
+		}
+	}
+}
+
+func TestZRangeCmd_Decode(t *testing.T) {
+	want := []ZMember{{"a", 1}, {"b", 2.5}}
+	cmd := ZRange("z", "0", "-1").WithScores()
+	for _, data := range []string{
+		"*2\r\n*2\r\n$1\r\na\r\n,1\r\n*2\r\n$1\r\nb\r\n,2.5\r\n",
+		"*4\r\n$1\r\na\r\n$1\r\n1\r\n$1\r\nb\r\n$3\r\n2.5\r\n",
+	} {
+		got, err := cmd.Decode(mustValue(t, data))
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: unexpected %v, %v", data, got, err)
+		}
+	}
+
+	got, err := ZRange("z", "0", "-1").Decode(mustValue(t, "*1\r\n$1\r\na\r\n"))
+	if err != nil || !reflect.DeepEqual(got, []ZMember{{Member: "a"}}) {
+		t.Errorf("unexpected %v, %v", got, err)
+	}
+}
@@ -0,0 +1,311 @@
+package resp3
+
+import "strings"
+
+// commandTable is the built-in snapshot of the common commands of Redis 7.2, as reported by COMMAND INFO.
+// Subcommands are named like "xinfo|stream" and follow their container command.
+var commandTable = []struct {
+	name              string
+	arity             int
+	flags             string
+	first, last, step int
+	acl               string
+}{
+	// strings
+	{"get", 2, "readonly fast", 1, 1, 1, "@read @string @fast"},
+	{"set", -3, "write denyoom", 1, 1, 1, "@write @string @slow"},
+	{"setnx", 3, "write denyoom fast", 1, 1, 1, "@write @string @fast"},
+	{"setex", 4, "write denyoom", 1, 1, 1, "@write @string @slow"},
+	{"psetex", 4, "write denyoom", 1, 1, 1, "@write @string @slow"},
+	{"getset", 3, "write denyoom fast", 1, 1, 1, "@write @string @fast"},
+	{"getdel", 2, "write fast", 1, 1, 1, "@write @string @fast"},
+	{"getex", -2, "write fast", 1, 1, 1, "@write @string @fast"},
+	{"mget", -2, "readonly fast", 1, -1, 1, "@read @string @fast"},
+	{"mset", -3, "write denyoom", 1, -1, 2, "@write @string @slow"},
+	{"msetnx", -3, "write denyoom", 1, -1, 2, "@write @string @slow"},
+	{"append", 3, "write denyoom fast", 1, 1, 1, "@write @string @fast"},
+	{"strlen", 2, "readonly fast", 1, 1, 1, "@read @string @fast"},
+	{"getrange", 4, "readonly", 1, 1, 1, "@read @string @slow"},
+	{"setrange", 4, "write denyoom", 1, 1, 1, "@write @string @slow"},
+	{"incr", 2, "write denyoom fast", 1, 1, 1, "@write @string @fast"},
+	{"decr", 2, "write denyoom fast", 1, 1, 1, "@write @string @fast"},
+	{"incrby", 3, "write denyoom fast", 1, 1, 1, "@write @string @fast"},
+	{"decrby", 3, "write denyoom fast", 1, 1, 1, "@write @string @fast"},
+	{"incrbyfloat", 3, "write denyoom fast", 1, 1, 1, "@write @string @fast"},
+
+	// keys
+	{"del", -2, "write", 1, -1, 1, "@keyspace @write @slow"},
+	{"unlink", -2, "write fast", 1, -1, 1, "@keyspace @write @fast"},
+	{"exists", -2, "readonly fast", 1, -1, 1, "@keyspace @read @fast"},
+	{"touch", -2, "readonly fast", 1, -1, 1, "@keyspace @read @fast"},
+	{"type", 2, "readonly fast", 1, 1, 1, "@keyspace @read @fast"},
+	{"expire", -3, "write fast", 1, 1, 1, "@keyspace @write @fast"},
+	{"pexpire", -3, "write fast", 1, 1, 1, "@keyspace @write @fast"},
+	{"expireat", -3, "write fast", 1, 1, 1, "@keyspace @write @fast"},
+	{"pexpireat", -3, "write fast", 1, 1, 1, "@keyspace @write @fast"},
+	{"persist", 2, "write fast", 1, 1, 1, "@keyspace @write @fast"},
+	{"ttl", 2, "readonly fast", 1, 1, 1, "@keyspace @read @fast"},
+	{"pttl", 2, "readonly fast", 1, 1, 1, "@keyspace @read @fast"},
+	{"rename", 3, "write", 1, 2, 1, "@keyspace @write @slow"},
+	{"renamenx", 3, "write fast", 1, 2, 1, "@keyspace @write @fast"},
+	{"copy", -3, "write denyoom", 1, 2, 1, "@keyspace @write @slow"},
+	{"dump", 2, "readonly", 1, 1, 1, "@keyspace @read @slow"},
+	{"restore", -4, "write denyoom", 1, 1, 1, "@keyspace @write @slow @dangerous"},
+	{"keys", 2, "readonly", 0, 0, 0, "@keyspace @read @slow @dangerous"},
+	{"scan", -2, "readonly", 0, 0, 0, "@keyspace @read @slow"},
+	{"randomkey", 1, "readonly", 0, 0, 0, "@keyspace @read @slow"},
+	{"sort", -2, "write denyoom movablekeys", 1, 1, 1, "@write @set @sortedset @list @slow @dangerous"},
+	{"sort_ro", -2, "readonly", 1, 1, 1, "@read @set @sortedset @list @slow @dangerous"},
+
+	// hashes
+	{"hset", -4, "write denyoom fast", 1, 1, 1, "@write @hash @fast"},
+	{"hsetnx", 4, "write denyoom fast", 1, 1, 1, "@write @hash @fast"},
+	{"hmset", -4, "write denyoom fast", 1, 1, 1, "@write @hash @fast"},
+	{"hget", 3, "readonly fast", 1, 1, 1, "@read @hash @fast"},
+	{"hmget", -3, "readonly fast", 1, 1, 1, "@read @hash @fast"},
+	{"hgetall", 2, "readonly", 1, 1, 1, "@read @hash @slow"},
+	{"hdel", -3, "write fast", 1, 1, 1, "@write @hash @fast"},
+	{"hexists", 3, "readonly fast", 1, 1, 1, "@read @hash @fast"},
+	{"hlen", 2, "readonly fast", 1, 1, 1, "@read @hash @fast"},
+	{"hkeys", 2, "readonly", 1, 1, 1, "@read @hash @slow"},
+	{"hvals", 2, "readonly", 1, 1, 1, "@read @hash @slow"},
+	{"hstrlen", 3, "readonly fast", 1, 1, 1, "@read @hash @fast"},
+	{"hincrby", 4, "write denyoom fast", 1, 1, 1, "@write @hash @fast"},
+	{"hincrbyfloat", 4, "write denyoom fast", 1, 1, 1, "@write @hash @fast"},
+	{"hrandfield", -2, "readonly", 1, 1, 1, "@read @hash @slow"},
+	{"hscan", -3, "readonly", 1, 1, 1, "@read @hash @slow"},
+
+	// lists
+	{"lpush", -3, "write denyoom fast", 1, 1, 1, "@write @list @fast"},
+	{"rpush", -3, "write denyoom fast", 1, 1, 1, "@write @list @fast"},
+	{"lpushx", -3, "write denyoom fast", 1, 1, 1, "@write @list @fast"},
+	{"rpushx", -3, "write denyoom fast", 1, 1, 1, "@write @list @fast"},
+	{"lpop", -2, "write fast", 1, 1, 1, "@write @list @fast"},
+	{"rpop", -2, "write fast", 1, 1, 1, "@write @list @fast"},
+	{"llen", 2, "readonly fast", 1, 1, 1, "@read @list @fast"},
+	{"lindex", 3, "readonly", 1, 1, 1, "@read @list @slow"},
+	{"lrange", 4, "readonly", 1, 1, 1, "@read @list @slow"},
+	{"lpos", -3, "readonly", 1, 1, 1, "@read @list @slow"},
+	{"lset", 4, "write denyoom", 1, 1, 1, "@write @list @slow"},
+	{"linsert", 5, "write denyoom", 1, 1, 1, "@write @list @slow"},
+	{"lrem", 4, "write", 1, 1, 1, "@write @list @slow"},
+	{"ltrim", 4, "write", 1, 1, 1, "@write @list @slow"},
+	{"lmove", 5, "write denyoom", 1, 2, 1, "@write @list @slow"},
+	{"rpoplpush", 3, "write denyoom", 1, 2, 1, "@write @list @slow"},
+	{"lmpop", -4, "write movablekeys", 0, 0, 0, "@write @list @slow"},
+	{"blpop", -3, "write blocking", 1, -2, 1, "@write @list @slow @blocking"},
+	{"brpop", -3, "write blocking", 1, -2, 1, "@write @list @slow @blocking"},
+	{"blmove", 6, "write denyoom blocking", 1, 2, 1, "@write @list @slow @blocking"},
+	{"brpoplpush", 4, "write denyoom blocking", 1, 2, 1, "@write @list @slow @blocking"},
+	{"blmpop", -5, "write blocking movablekeys", 0, 0, 0, "@write @list @slow @blocking"},
+
+	// sets
+	{"sadd", -3, "write denyoom fast", 1, 1, 1, "@write @set @fast"},
+	{"srem", -3, "write fast", 1, 1, 1, "@write @set @fast"},
+	{"smembers", 2, "readonly", 1, 1, 1, "@read @set @slow"},
+	{"sismember", 3, "readonly fast", 1, 1, 1, "@read @set @fast"},
+	{"smismember", -3, "readonly fast", 1, 1, 1, "@read @set @fast"},
+	{"scard", 2, "readonly fast", 1, 1, 1, "@read @set @fast"},
+	{"spop", -2, "write fast", 1, 1, 1, "@write @set @fast"},
+	{"srandmember", -2, "readonly", 1, 1, 1, "@read @set @slow"},
+	{"smove", 4, "write fast", 1, 2, 1, "@write @set @fast"},
+	{"sinter", -2, "readonly", 1, -1, 1, "@read @set @slow"},
+	{"sunion", -2, "readonly", 1, -1, 1, "@read @set @slow"},
+	{"sdiff", -2, "readonly", 1, -1, 1, "@read @set @slow"},
+	{"sinterstore", -3, "write denyoom", 1, -1, 1, "@write @set @slow"},
+	{"sunionstore", -3, "write denyoom", 1, -1, 1, "@write @set @slow"},
+	{"sdiffstore", -3, "write denyoom", 1, -1, 1, "@write @set @slow"},
+	{"sintercard", -3, "readonly movablekeys", 0, 0, 0, "@read @set @slow"},
+	{"sscan", -3, "readonly", 1, 1, 1, "@read @set @slow"},
+
+	// sorted sets
+	{"zadd", -4, "write denyoom fast", 1, 1, 1, "@write @sortedset @fast"},
+	{"zincrby", 4, "write denyoom fast", 1, 1, 1, "@write @sortedset @fast"},
+	{"zrem", -3, "write fast", 1, 1, 1, "@write @sortedset @fast"},
+	{"zscore", 3, "readonly fast", 1, 1, 1, "@read @sortedset @fast"},
+	{"zmscore", -3, "readonly fast", 1, 1, 1, "@read @sortedset @fast"},
+	{"zcard", 2, "readonly fast", 1, 1, 1, "@read @sortedset @fast"},
+	{"zcount", 4, "readonly fast", 1, 1, 1, "@read @sortedset @fast"},
+	{"zrank", -3, "readonly fast", 1, 1, 1, "@read @sortedset @fast"},
+	{"zrevrank", -3, "readonly fast", 1, 1, 1, "@read @sortedset @fast"},
+	{"zrange", -4, "readonly", 1, 1, 1, "@read @sortedset @slow"},
+	{"zrangebyscore", -4, "readonly", 1, 1, 1, "@read @sortedset @slow"},
+	{"zrevrange", -4, "readonly", 1, 1, 1, "@read @sortedset @slow"},
+	{"zrevrangebyscore", -4, "readonly", 1, 1, 1, "@read @sortedset @slow"},
+	{"zrangestore", -5, "write denyoom", 1, 2, 1, "@write @sortedset @slow"},
+	{"zremrangebyrank", 4, "write", 1, 1, 1, "@write @sortedset @slow"},
+	{"zremrangebyscore", 4, "write", 1, 1, 1, "@write @sortedset @slow"},
+	{"zpopmin", -2, "write fast", 1, 1, 1, "@write @sortedset @fast"},
+	{"zpopmax", -2, "write fast", 1, 1, 1, "@write @sortedset @fast"},
+	{"bzpopmin", -3, "write blocking fast", 1, -2, 1, "@write @sortedset @fast @blocking"},
+	{"bzpopmax", -3, "write blocking fast", 1, -2, 1, "@write @sortedset @fast @blocking"},
+	{"zunionstore", -4, "write denyoom movablekeys", 1, 1, 1, "@write @sortedset @slow"},
+	{"zinterstore", -4, "write denyoom movablekeys", 1, 1, 1, "@write @sortedset @slow"},
+	{"zdiffstore", -4, "write denyoom movablekeys", 1, 1, 1, "@write @sortedset @slow"},
+	{"zunion", -3, "readonly movablekeys", 0, 0, 0, "@read @sortedset @slow"},
+	{"zinter", -3, "readonly movablekeys", 0, 0, 0, "@read @sortedset @slow"},
+	{"zdiff", -3, "readonly movablekeys", 0, 0, 0, "@read @sortedset @slow"},
+	{"zintercard", -3, "readonly movablekeys", 0, 0, 0, "@read @sortedset @slow"},
+	{"zmpop", -4, "write movablekeys", 0, 0, 0, "@write @sortedset @slow"},
+	{"bzmpop", -5, "write blocking movablekeys", 0, 0, 0, "@write @sortedset @slow @blocking"},
+	{"zscan", -3, "readonly", 1, 1, 1, "@read @sortedset @slow"},
+
+	// streams
+	{"xadd", -5, "write denyoom fast", 1, 1, 1, "@write @stream @fast"},
+	{"xlen", 2, "readonly fast", 1, 1, 1, "@read @stream @fast"},
+	{"xrange", -4, "readonly", 1, 1, 1, "@read @stream @slow"},
+	{"xrevrange", -4, "readonly", 1, 1, 1, "@read @stream @slow"},
+	{"xdel", -3, "write fast", 1, 1, 1, "@write @stream @fast"},
+	{"xtrim", -4, "write", 1, 1, 1, "@write @stream @slow"},
+	{"xack", -4, "write fast", 1, 1, 1, "@write @stream @fast"},
+	{"xclaim", -6, "write fast", 1, 1, 1, "@write @stream @fast"},
+	{"xautoclaim", -6, "write fast", 1, 1, 1, "@write @stream @fast"},
+	{"xpending", -3, "readonly", 1, 1, 1, "@read @stream @slow"},
+	{"xread", -4, "readonly blocking movablekeys", 0, 0, 0, "@read @stream @slow @blocking"},
+	{"xreadgroup", -7, "write blocking movablekeys", 0, 0, 0, "@write @stream @slow @blocking"},
+	{"xgroup", -2, "", 0, 0, 0, "@slow"},
+	{"xgroup|create", -5, "write denyoom", 2, 2, 1, "@write @stream @slow"},
+	{"xgroup|createconsumer", 5, "write denyoom", 2, 2, 1, "@write @stream @slow"},
+	{"xgroup|delconsumer", 5, "write", 2, 2, 1, "@write @stream @slow"},
+	{"xgroup|destroy", 4, "write", 2, 2, 1, "@write @stream @slow"},
+	{"xgroup|setid", -5, "write", 2, 2, 1, "@write @stream @slow"},
+	{"xinfo", -2, "", 0, 0, 0, "@slow"},
+	{"xinfo|consumers", 4, "readonly", 2, 2, 1, "@read @stream @slow"},
+	{"xinfo|groups", 3, "readonly", 2, 2, 1, "@read @stream @slow"},
+	{"xinfo|stream", -3, "readonly", 2, 2, 1, "@read @stream @slow"},
+
+	// pub/sub
+	{"publish", 3, "pubsub loading stale fast may_replicate", 0, 0, 0, "@pubsub @fast"},
+	{"subscribe", -2, "pubsub noscript loading stale", 0, 0, 0, "@pubsub @slow"},
+	{"unsubscribe", -1, "pubsub noscript loading stale", 0, 0, 0, "@pubsub @slow"},
+	{"psubscribe", -2, "pubsub noscript loading stale", 0, 0, 0, "@pubsub @slow"},
+	{"punsubscribe", -1, "pubsub noscript loading stale", 0, 0, 0, "@pubsub @slow"},
+	{"spublish", 3, "pubsub loading stale fast may_replicate", 1, 1, 1, "@pubsub @fast"},
+	{"ssubscribe", -2, "pubsub noscript loading stale", 1, -1, 1, "@pubsub @slow"},
+	{"sunsubscribe", -1, "pubsub noscript loading stale", 1, -1, 1, "@pubsub @slow"},
+
+	// scripting and functions
+	{"eval", -3, "noscript stale skip_monitor may_replicate no_mandatory_keys movablekeys", 0, 0, 0, "@slow @scripting"},
+	{"evalsha", -3, "noscript stale skip_monitor may_replicate no_mandatory_keys movablekeys", 0, 0, 0, "@slow @scripting"},
+	{"eval_ro", -3, "readonly noscript stale skip_monitor no_mandatory_keys movablekeys", 0, 0, 0, "@slow @scripting"},
+	{"evalsha_ro", -3, "readonly noscript stale skip_monitor no_mandatory_keys movablekeys", 0, 0, 0, "@slow @scripting"},
+	{"fcall", -3, "noscript stale skip_monitor may_replicate no_mandatory_keys movablekeys", 0, 0, 0, "@slow @scripting"},
+	{"fcall_ro", -3, "readonly noscript stale skip_monitor no_mandatory_keys movablekeys", 0, 0, 0, "@slow @scripting"},
+	{"script", -2, "", 0, 0, 0, "@slow"},
+	{"script|exists", -3, "noscript", 0, 0, 0, "@slow @scripting"},
+	{"script|flush", -2, "noscript", 0, 0, 0, "@slow @scripting"},
+	{"script|load", 3, "noscript stale", 0, 0, 0, "@slow @scripting"},
+	{"function", -2, "", 0, 0, 0, "@slow"},
+	{"function|delete", 3, "write noscript", 0, 0, 0, "@write @slow @scripting"},
+	{"function|list", -2, "noscript", 0, 0, 0, "@slow @scripting"},
+	{"function|load", -3, "write denyoom noscript", 0, 0, 0, "@write @slow @scripting"},
+
+	// transactions
+	{"multi", 1, "noscript loading stale fast allow_busy", 0, 0, 0, "@fast @transaction"},
+	{"exec", 1, "noscript loading stale skip_slowlog", 0, 0, 0, "@slow @transaction"},
+	{"discard", 1, "noscript loading stale fast allow_busy", 0, 0, 0, "@fast @transaction"},
+	{"watch", -2, "noscript loading stale fast allow_busy", 1, -1, 1, "@fast @transaction"},
+	{"unwatch", 1, "noscript loading stale fast allow_busy", 0, 0, 0, "@fast @transaction"},
+
+	// connection
+	{"auth", -2, "noscript loading stale fast no_auth sentinel allow_busy", 0, 0, 0, "@fast @connection"},
+	{"hello", -1, "noscript loading stale fast no_auth sentinel allow_busy", 0, 0, 0, "@fast @connection"},
+	{"ping", -1, "fast sentinel", 0, 0, 0, "@fast @connection"},
+	{"echo", 2, "fast", 0, 0, 0, "@fast @connection"},
+	{"select", 2, "loading stale fast", 0, 0, 0, "@fast @connection"},
+	{"quit", -1, "noscript loading stale fast no_auth allow_busy", 0, 0, 0, "@fast @connection"},
+	{"reset", 1, "noscript loading stale fast no_auth allow_busy", 0, 0, 0, "@fast @connection"},
+	{"client", -2, "", 0, 0, 0, "@slow"},
+	{"client|getname", 2, "noscript loading stale", 0, 0, 0, "@slow @connection"},
+	{"client|id", 2, "noscript loading stale", 0, 0, 0, "@slow @connection"},
+	{"client|kill", -3, "admin noscript loading stale", 0, 0, 0, "@admin @slow @dangerous @connection"},
+	{"client|setname", 3, "noscript loading stale", 0, 0, 0, "@slow @connection"},
+	{"client|tracking", -3, "noscript loading stale", 0, 0, 0, "@slow @connection"},
+
+	// server
+	{"command", -1, "loading stale", 0, 0, 0, "@slow @connection"},
+	{"command|count", 2, "loading stale", 0, 0, 0, "@slow @connection"},
+	{"command|docs", -2, "loading stale", 0, 0, 0, "@slow @connection"},
+	{"command|info", -2, "loading stale", 0, 0, 0, "@slow @connection"},
+	{"config", -2, "", 0, 0, 0, "@slow"},
+	{"config|get", -3, "admin noscript loading stale", 0, 0, 0, "@admin @slow @dangerous"},
+	{"config|set", -4, "admin noscript loading stale", 0, 0, 0, "@admin @slow @dangerous"},
+	{"dbsize", 1, "readonly fast", 0, 0, 0, "@keyspace @read @fast"},
+	{"flushall", -1, "write", 0, 0, 0, "@keyspace @write @slow @dangerous"},
+	{"flushdb", -1, "write", 0, 0, 0, "@keyspace @write @slow @dangerous"},
+	{"info", -1, "loading stale sentinel", 0, 0, 0, "@slow @dangerous"},
+	{"memory", -2, "", 0, 0, 0, "@slow"},
+	{"memory|usage", -3, "readonly", 2, 2, 1, "@read @slow"},
+	{"monitor", 1, "admin noscript loading stale", 0, 0, 0, "@admin @slow @dangerous"},
+	{"object", -2, "", 0, 0, 0, "@slow"},
+	{"object|encoding", 3, "readonly", 2, 2, 1, "@keyspace @read @slow"},
+	{"object|freq", 3, "readonly", 2, 2, 1, "@keyspace @read @slow"},
+	{"object|idletime", 3, "readonly", 2, 2, 1, "@keyspace @read @slow"},
+	{"object|refcount", 3, "readonly", 2, 2, 1, "@keyspace @read @slow"},
+	{"time", 1, "loading stale fast", 0, 0, 0, "@fast"},
+}
+
+// commandKeySpecs are the key specs of the commands with movable keys in the snapshot.
+// The key positions of the others are their legacy positions.
+var commandKeySpecs = map[string][]KeySpec{
+	"sort":        {indexRangeSpec(1, 0, 1, 0), keywordRangeSpec("STORE", 1, 0, 1, 0)},
+	"lmpop":       {keynumSpec(1, 0, 1, 1)},
+	"blmpop":      {keynumSpec(2, 0, 1, 1)},
+	"sintercard":  {keynumSpec(1, 0, 1, 1)},
+	"zunionstore": {indexRangeSpec(1, 0, 1, 0), keynumSpec(2, 0, 1, 1)},
+	"zinterstore": {indexRangeSpec(1, 0, 1, 0), keynumSpec(2, 0, 1, 1)},
+	"zdiffstore":  {indexRangeSpec(1, 0, 1, 0), keynumSpec(2, 0, 1, 1)},
+	"zunion":      {keynumSpec(1, 0, 1, 1)},
+	"zinter":      {keynumSpec(1, 0, 1, 1)},
+	"zdiff":       {keynumSpec(1, 0, 1, 1)},
+	"zintercard":  {keynumSpec(1, 0, 1, 1)},
+	"zmpop":       {keynumSpec(1, 0, 1, 1)},
+	"bzmpop":      {keynumSpec(2, 0, 1, 1)},
+	"xread":       {keywordRangeSpec("STREAMS", 1, -1, 1, 2)},
+	"xreadgroup":  {keywordRangeSpec("STREAMS", 4, -1, 1, 2)},
+	"eval":        {keynumSpec(2, 0, 1, 1)},
+	"evalsha":     {keynumSpec(2, 0, 1, 1)},
+	"eval_ro":     {keynumSpec(2, 0, 1, 1)},
+	"evalsha_ro":  {keynumSpec(2, 0, 1, 1)},
+	"fcall":       {keynumSpec(2, 0, 1, 1)},
+	"fcall_ro":    {keynumSpec(2, 0, 1, 1)},
+}
+
+func indexRangeSpec(index, lastKey, keyStep, limit int) KeySpec {
+	return KeySpec{BeginSearch: "index", Index: index, FindKeys: "range", LastKey: lastKey, KeyStep: keyStep, Limit: limit}
+}
+
+func keywordRangeSpec(keyword string, startFrom, lastKey, keyStep, limit int) KeySpec {
+	return KeySpec{BeginSearch: "keyword", Keyword: keyword, StartFrom: startFrom,
+		FindKeys: "range", LastKey: lastKey, KeyStep: keyStep, Limit: limit}
+}
+
+func keynumSpec(index, keyNumIdx, firstKey, keyStep int) KeySpec {
+	return KeySpec{BeginSearch: "index", Index: index, FindKeys: "keynum", KeyNumIdx: keyNumIdx, FirstKey: firstKey, KeyStep: keyStep}
+}
+
+// staticCommands returns the commands of the snapshot, with their subcommands.
+func staticCommands() []*CommandInfo {
+	var infos []*CommandInfo
+	byName := make(map[string]*CommandInfo)
+	for _, c := range commandTable {
+		info := &CommandInfo{
+			Name:          c.name,
+			Arity:         c.arity,
+			Flags:         strings.Fields(c.flags),
+			FirstKey:      c.first,
+			LastKey:       c.last,
+			Step:          c.step,
+			ACLCategories: strings.Fields(c.acl),
+			KeySpecs:      commandKeySpecs[c.name],
+		}
+		if i := strings.IndexByte(c.name, '|'); i >= 0 {
+			parent := byName[c.name[:i]]
+			parent.Subcommands = append(parent.Subcommands, info)
+			continue
+		}
+		byName[c.name] = info
+		infos = append(infos, info)
+	}
+	return infos
+}
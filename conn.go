@@ -0,0 +1,584 @@
+package resp3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors of connections
+var (
+	ErrConnClosed      = errors.New("resp: connection closed")
+	ErrUnexpectedReply = errors.New("resp: unexpected reply")
+)
+
+// Error is an error reply of the server.
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// Code returns the error code, the first word of the error like ERR or NOSCRIPT.
+func (e Error) Code() string {
+	if i := strings.IndexByte(string(e), ' '); i >= 0 {
+		return string(e[:i])
+	}
+	return string(e)
+}
+
+// Doer sends a command and returns its reply. It's implemented by Conn and Pool.
+type Doer interface {
+	DoContext(ctx context.Context, args ...string) (*Value, error)
+}
+
+// DialOption configures Dial.
+type DialOption func(*dialOptions)
+
+type dialOptions struct {
+	dialTimeout  time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	username     string
+	password     string
+	db           int
+	clientName   string
+	protocol     int
+	tlsConfig    *tls.Config
+	hooks        hooks
+}
+
+// DialTimeout sets the timeout of connecting, 5 seconds by default.
+func DialTimeout(d time.Duration) DialOption {
+	return func(o *dialOptions) {
+		o.dialTimeout = d
+	}
+}
+
+// DialReadTimeout sets the timeout of reading a reply, no timeout by default.
+func DialReadTimeout(d time.Duration) DialOption {
+	return func(o *dialOptions) {
+		o.readTimeout = d
+	}
+}
+
+// DialWriteTimeout sets the timeout of writing commands, no timeout by default.
+func DialWriteTimeout(d time.Duration) DialOption {
+	return func(o *dialOptions) {
+		o.writeTimeout = d
+	}
+}
+
+// DialAuth authenticates the connection with HELLO. The username may be empty for the default user.
+func DialAuth(username, password string) DialOption {
+	return func(o *dialOptions) {
+		o.username = username
+		o.password = password
+	}
+}
+
+// DialDatabase selects the database of the connection.
+func DialDatabase(db int) DialOption {
+	return func(o *dialOptions) {
+		o.db = db
+	}
+}
+
+// DialProtocol sets the protocol version of HELLO, 2 or 3, 3 by default.
+// With RESP2 maps are replied as flat arrays and nulls as null bulk strings or null arrays,
+// and the messages of subscriptions are arrays which are passed to OnPush as push messages like RESP3.
+func DialProtocol(version int) DialOption {
+	return func(o *dialOptions) {
+		o.protocol = version
+	}
+}
+
+// DialTLSConfig connects with TLS. Client certificates, the root CAs and SNI are set by the config,
+// the server name is the host of the address if it's empty.
+func DialTLSConfig(config *tls.Config) DialOption {
+	return func(o *dialOptions) {
+		o.tlsConfig = config
+	}
+}
+
+// DialClientName sets the name of the connection with HELLO.
+func DialClientName(name string) DialOption {
+	return func(o *dialOptions) {
+		o.clientName = name
+	}
+}
+
+// Conn is a RESP3 client connection.
+// It keeps track of the state a command leaves behind: pending replies, subscriptions,
+// client side caching, transactions and the selected database, so a Pool can reset it.
+// A Conn is not safe for concurrent use.
+type Conn struct {
+	conn net.Conn
+	r    *Reader
+	w    *Writer
+	opts dialOptions
+
+	hello     *Value
+	createdAt time.Time
+	usedAt    time.Time
+
+	pending    int  // replies not received yet
+	subscribed bool // SUBSCRIBE, PSUBSCRIBE or SSUBSCRIBE was sent and not all are unsubscribed
+	tracking   bool // CLIENT TRACKING is on
+	multi      bool // in MULTI
+	watching   bool // WATCH was sent
+	selected   bool // SELECT was sent
+	err        error
+
+	subscribing   int // confirmations of subscribe commands not received yet
+	channels      int // channels and patterns subscribed, by the last confirmation
+	shardChannels int // shard channels subscribed, by the last confirmation
+
+	// OnPush is called for push messages received while waiting for a reply.
+	// Push messages are dropped if it's nil.
+	OnPush func(push *Value)
+}
+
+// Dial connects to a RESP3 server and switches the connection to RESP3 with HELLO.
+func Dial(network, address string, opts ...DialOption) (*Conn, error) {
+	return DialContext(context.Background(), network, address, opts...)
+}
+
+// DialContext is like Dial but the context can cancel connecting.
+func DialContext(ctx context.Context, network, address string, opts ...DialOption) (*Conn, error) {
+	o := dialOptions{dialTimeout: 5 * time.Second, protocol: 3}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	start := time.Now()
+	c, err := dial(ctx, network, address, o)
+	if len(o.hooks) > 0 {
+		o.hooks.OnDial(ctx, DialEvent{Network: network, Address: address, Duration: time.Since(start), Err: err})
+	}
+	return c, err
+}
+
+func dial(ctx context.Context, network, address string, o dialOptions) (*Conn, error) {
+	d := net.Dialer{Timeout: o.dialTimeout}
+	netConn, err := d.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	if o.tlsConfig != nil {
+		if netConn, err = handshakeTLS(ctx, netConn, address, o); err != nil {
+			return nil, err
+		}
+	}
+	c := &Conn{
+		conn:      netConn,
+		r:         NewReader(netConn),
+		w:         NewWriter(netConn),
+		opts:      o,
+		createdAt: time.Now(),
+	}
+	if err := c.handshake(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// handshakeTLS runs the TLS handshake on a connection, it's closed if the handshake fails.
+func handshakeTLS(ctx context.Context, netConn net.Conn, address string, o dialOptions) (net.Conn, error) {
+	config := o.tlsConfig
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			host = address
+		}
+		config = config.Clone()
+		config.ServerName = host
+	}
+	if o.dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.dialTimeout)
+		defer cancel()
+	}
+	tlsConn := tls.Client(netConn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// handshake sends HELLO and SELECT.
+func (c *Conn) handshake() error {
+	args := []string{"HELLO", strconv.Itoa(c.opts.protocol)}
+	if c.opts.password != "" {
+		username := c.opts.username
+		if username == "" {
+			username = "default"
+		}
+		args = append(args, "AUTH", username, c.opts.password)
+	}
+	if c.opts.clientName != "" {
+		args = append(args, "SETNAME", c.opts.clientName)
+	}
+	ctx := context.Background()
+	hello, err := c.do(ctx, args)
+	if err != nil {
+		return err
+	}
+	c.hello = hello
+
+	if c.opts.db != 0 {
+		if _, err := c.do(ctx, []string{"SELECT", strconv.Itoa(c.opts.db)}); err != nil {
+			return err
+		}
+		c.selected = false
+	}
+	return nil
+}
+
+// Hello returns the reply of HELLO, which has the server name, the version, the protocol version and the connection id.
+func (c *Conn) Hello() *Value {
+	return c.hello
+}
+
+// NetConn returns the underlying connection.
+func (c *Conn) NetConn() net.Conn {
+	return c.conn
+}
+
+// Stats returns the counters of the replies read and the commands written on the connection.
+func (c *Conn) Stats() (read, written Stats) {
+	return c.r.Stats(), c.w.Stats()
+}
+
+// Err returns the I/O or protocol error which broke the connection, or nil if it can be used.
+func (c *Conn) Err() error {
+	return c.err
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	if c.err == nil {
+		c.err = ErrConnClosed
+	}
+	return c.conn.Close()
+}
+
+// Do sends a command and returns its reply. An error reply is returned as the value and an Error.
+// The replies of SUBSCRIBE commands are push messages, so Do returns a nil value for them
+// and the push messages are passed to OnPush.
+func (c *Conn) Do(args ...string) (*Value, error) {
+	return c.DoContext(context.Background(), args...)
+}
+
+// DoContext is like Do but it returns the error of the context when it's canceled or its deadline passes.
+// The connection is still usable if the reply isn't received yet, it's received by the next call.
+//
+// Blocking commands, like BLPOP, XREAD BLOCK and WAIT, may block on the server for long:
+// the read timeout is extended by the timeout of the command, or disabled if it blocks until it's served.
+// A null reply of a blocking command is returned with ErrNil, and the connection is closed
+// if the context is done before the reply is received.
+func (c *Conn) DoContext(ctx context.Context, args ...string) (*Value, error) {
+	ctx, done := c.hookCommand(ctx, args)
+	v, err := c.do(ctx, args)
+	done(v, err)
+	return v, err
+}
+
+func (c *Conn) do(ctx context.Context, args []string) (*Value, error) {
+	if err := c.Send(args...); err != nil {
+		return nil, err
+	}
+	if err := c.FlushContext(ctx); err != nil {
+		return nil, err
+	}
+	if len(args) > 0 && isSubscribeCommand(strings.ToUpper(args[0])) {
+		return nil, nil
+	}
+	// receive the replies of the commands sent before
+	for c.pending > 1 {
+		if _, err := c.ReceiveContext(ctx); err != nil && !isReplyError(err) {
+			return nil, err
+		}
+	}
+
+	block, blocking := blockingTimeout(args)
+	if !blocking {
+		return c.ReceiveContext(ctx)
+	}
+	timeout := c.opts.readTimeout
+	if block == 0 {
+		timeout = 0
+	} else if timeout > 0 {
+		timeout += block
+	}
+	v, err := c.receive(ctx, timeout)
+	if err != nil && isContextError(ctx, err) {
+		// the reply may not come for long
+		c.fail(err)
+	}
+	if err == nil && isNil(v) {
+		return v, ErrNil
+	}
+	return v, err
+}
+
+// Pipeline sends the commands in one round trip and returns their replies in order.
+// Error replies are returned as values like Do, and the error is the first error which isn't an error reply.
+// SUBSCRIBE commands have no replies in the result.
+func (c *Conn) Pipeline(ctx context.Context, cmds ...[]string) ([]*Value, error) {
+	ctx, done := c.hookPipeline(ctx, cmds)
+	replies, err := c.pipeline(ctx, cmds)
+	done(replies, err)
+	return replies, err
+}
+
+func (c *Conn) pipeline(ctx context.Context, cmds [][]string) ([]*Value, error) {
+	// receive the replies of the commands sent before
+	for c.pending > 0 {
+		if _, err := c.ReceiveContext(ctx); err != nil && !isReplyError(err) {
+			return nil, err
+		}
+	}
+
+	n := 0
+	for _, cmd := range cmds {
+		if err := c.Send(cmd...); err != nil {
+			return nil, err
+		}
+		if len(cmd) > 0 && !isSubscribeCommand(strings.ToUpper(cmd[0])) {
+			n++
+		}
+	}
+	if err := c.FlushContext(ctx); err != nil {
+		return nil, err
+	}
+	replies := make([]*Value, 0, n)
+	for len(replies) < n {
+		v, err := c.ReceiveContext(ctx)
+		if err != nil && !isReplyError(err) {
+			return replies, err
+		}
+		replies = append(replies, v)
+	}
+	return replies, nil
+}
+
+// Send writes a command to the buffer without waiting for the reply.
+func (c *Conn) Send(args ...string) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.w.writeCommand(args)
+	c.track(args)
+	return nil
+}
+
+// Flush sends the buffered commands.
+func (c *Conn) Flush() error {
+	return c.FlushContext(context.Background())
+}
+
+// FlushContext is like Flush but it returns the error of the context when it's canceled or its deadline passes.
+func (c *Conn) FlushContext(ctx context.Context) error {
+	if c.err != nil {
+		return c.err
+	}
+	if c.opts.writeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.writeTimeout)
+		defer cancel()
+	}
+	// an interrupted flush leaves a partial command behind
+	return c.fail(c.w.FlushContext(ctx))
+}
+
+// Receive returns the next reply. An error reply is returned as the value and an Error.
+func (c *Conn) Receive() (*Value, error) {
+	return c.ReceiveContext(context.Background())
+}
+
+// ReceiveContext is like Receive but it returns the error of the context when it's canceled or its deadline passes.
+func (c *Conn) ReceiveContext(ctx context.Context) (*Value, error) {
+	return c.receive(ctx, c.opts.readTimeout)
+}
+
+// receive returns the next reply, waiting for at most timeout if it's positive.
+func (c *Conn) receive(ctx context.Context, timeout time.Duration) (*Value, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	for {
+		v, _, err := c.r.ReadValueContext(ctx)
+		if err != nil {
+			if isContextError(ctx, err) && c.r.Err() == nil {
+				return nil, err
+			}
+			return nil, c.fail(err)
+		}
+		c.usedAt = time.Now()
+		if c.subscribed && c.opts.protocol == 2 && IsPubSubArray(v) {
+			// RESP2 has no push messages, messages and confirmations of subscriptions are arrays
+			v.Type = TypePush
+		}
+		if v.Type == TypePush {
+			c.confirmed(v)
+			if len(c.opts.hooks) > 0 {
+				c.opts.hooks.OnPush(ctx, v)
+			}
+			if c.OnPush != nil {
+				c.OnPush(v)
+			}
+			continue
+		}
+
+		if c.pending > 0 {
+			c.pending--
+		}
+		if v.Type == TypeSimpleError || v.Type == TypeBlobError {
+			return v, Error(v.Err)
+		}
+		return v, nil
+	}
+}
+
+// IsPubSubArray checks if a value is an array of RESP2 which is a message or a confirmation of a subscribe command,
+// which are push messages in RESP3.
+func IsPubSubArray(v *Value) bool {
+	if v.Type != TypeArray || len(v.Elems) < 3 || v.Elems[0].Type != TypeBlobString {
+		return false
+	}
+	switch v.Elems[0].Str {
+	case "message", "smessage":
+		return len(v.Elems) == 3
+	case "pmessage":
+		return len(v.Elems) == 4
+	case "subscribe", "psubscribe", "ssubscribe", "unsubscribe", "punsubscribe", "sunsubscribe":
+		return len(v.Elems) == 3 && v.Elems[2].Type == TypeNumber
+	}
+	return false
+}
+
+// isReplyError checks if err is a reply of the server, an error reply or ErrNil,
+// which doesn't break the connection.
+func isReplyError(err error) bool {
+	_, ok := err.(Error)
+	return ok || err == ErrNil
+}
+
+// fail marks the connection broken by err.
+func (c *Conn) fail(err error) error {
+	if err != nil && c.err == nil {
+		c.err = err
+		c.conn.Close()
+	}
+	return err
+}
+
+// track updates the state of the connection by a command.
+func (c *Conn) track(args []string) {
+	if len(args) == 0 {
+		return
+	}
+	name := strings.ToUpper(args[0])
+	if isSubscribeCommand(name) {
+		if !strings.Contains(name, "UNSUBSCRIBE") {
+			// a confirmation for every channel
+			c.subscribed = true
+			c.subscribing += len(args) - 1
+		}
+		return
+	}
+	c.pending++
+
+	switch name {
+	case "MULTI":
+		c.multi = true
+	case "EXEC", "DISCARD":
+		c.multi, c.watching = false, false
+	case "WATCH":
+		c.watching = true
+	case "UNWATCH":
+		c.watching = false
+	case "SELECT":
+		c.selected = true
+	case "CLIENT":
+		if len(args) > 2 && strings.EqualFold(args[1], "TRACKING") {
+			c.tracking = strings.EqualFold(args[2], "ON")
+		}
+	case "RESET":
+		c.subscribed, c.tracking, c.multi, c.watching, c.selected = false, false, false, false, false
+		c.subscribing, c.channels, c.shardChannels = 0, 0, 0
+	}
+}
+
+// confirmed counts the subscriptions by a confirmation of a subscribe command.
+// subscribed is cleared when all are unsubscribed and no confirmation of a subscribe command is expected,
+// then arrays of RESP2 are replies again.
+func (c *Conn) confirmed(push *Value) {
+	if len(push.Elems) != 3 || push.Elems[2].Type != TypeNumber {
+		return
+	}
+	n := int(push.Elems[2].Integer)
+	switch kind := push.Elems[0].Str; kind {
+	case "subscribe", "psubscribe", "ssubscribe":
+		if c.subscribing > 0 {
+			c.subscribing--
+		}
+		if kind == "ssubscribe" {
+			c.shardChannels = n
+		} else {
+			c.channels = n
+		}
+	case "unsubscribe", "punsubscribe":
+		c.channels = n
+	case "sunsubscribe":
+		c.shardChannels = n
+	default:
+		return
+	}
+	if c.subscribing == 0 && c.channels == 0 && c.shardChannels == 0 {
+		c.subscribed = false
+	}
+}
+
+// dirty returns true if a command changed the state of the connection.
+func (c *Conn) dirty() bool {
+	return c.subscribed || c.tracking || c.multi || c.watching || c.selected
+}
+
+// reset receives the pending replies and resets the state of the connection with RESET.
+// The connection is switched back to its protocol and authenticated again after RESET.
+func (c *Conn) reset(timeout time.Duration) error {
+	if c.err != nil {
+		return c.err
+	}
+	if c.pending == 0 && !c.dirty() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for c.pending > 0 {
+		if _, err := c.ReceiveContext(ctx); err != nil && !isReplyError(err) {
+			return c.fail(err)
+		}
+	}
+	if !c.dirty() {
+		return nil
+	}
+	if _, err := c.do(ctx, []string{"RESET"}); err != nil {
+		return c.fail(err)
+	}
+	return c.fail(c.handshake())
+}
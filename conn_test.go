@@ -0,0 +1,248 @@
+package resp3
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestConn(t *testing.T) {
+	ln := fakeUpstream(t)
+	defer ln.Close()
+
+	c, err := Dial("tcp", ln.Addr().String(), DialClientName("test"), DialDatabase(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if c.Hello().Type != TypeMap || c.dirty() {
+		t.Fatalf("unexpected state after the handshake: %s, %v", c.Hello().ToRESP3String(), c.dirty())
+	}
+
+	var pushes []*Value
+	c.OnPush = func(push *Value) {
+		pushes = append(pushes, push)
+	}
+	if _, err := c.Do("SET", "a", "1"); err != nil {
+		t.Fatal(err)
+	}
+	v, err := c.Do("GET", "a")
+	if err != nil || v.Str != "1" {
+		t.Errorf("expected 1 but got %v, %v", v, err)
+	}
+	if len(pushes) != 1 || pushes[0].Elems[0].Str != "invalidate" {
+		t.Errorf("expected an invalidation message but got %v", pushes)
+	}
+
+	// error reply
+	v, err = c.Do("NOPE")
+	if _, ok := err.(Error); !ok || v.Type != TypeSimpleError || err.Error() != "ERR unknown command" {
+		t.Errorf("expected an error reply but got %v, %v", v, err)
+	}
+
+	// pipeline
+	c.Send("PING")
+	c.Send("GET", "a")
+	if c.pending != 2 {
+		t.Errorf("expected 2 pending replies but got %d", c.pending)
+	}
+	if err := c.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"PONG", "1"} {
+		v, err := c.Receive()
+		if err != nil || v.Str != expected {
+			t.Errorf("expected %s but got %v, %v", expected, v, err)
+		}
+	}
+
+	// state
+	c.Do("CLIENT", "TRACKING", "on")
+	c.Do("SUBSCRIBE", "ch")
+	if !c.tracking || !c.subscribed || c.pending != 0 {
+		t.Errorf("unexpected state %+v", c)
+	}
+	if err := c.reset(resetTimeout); err != nil || c.dirty() {
+		t.Errorf("failed to reset: %v", err)
+	}
+
+	read, written := c.Stats()
+	if read.Frames == 0 || read.Bytes == 0 || written.Frames == 0 || written.Bytes == 0 {
+		t.Errorf("unexpected stats %+v, %+v", read, written)
+	}
+
+	c.Close()
+	if _, err := c.Do("PING"); err != ErrConnClosed {
+		t.Errorf("expected ErrConnClosed but got %v", err)
+	}
+}
+
+func TestConn_DoContext(t *testing.T) {
+	// the server replies to HELLO and PING, to BLPOP after a while, but not to GET
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := NewReader(conn)
+				for {
+					cmd, _, err := r.ReadValue()
+					if err != nil {
+						return
+					}
+					switch cmd.Elems[0].Str {
+					case "HELLO":
+						conn.Write([]byte("%1\r\n+proto\r\n:3\r\n"))
+					case "PING":
+						conn.Write([]byte("+PONG\r\n"))
+					case "BLPOP":
+						time.Sleep(50 * time.Millisecond)
+						conn.Write([]byte("_\r\n"))
+					}
+				}
+			}()
+		}
+	}()
+
+	c, err := Dial("tcp", ln.Addr().String(), DialReadTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.DoContext(ctx, "GET", "a"); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded but got %v", err)
+	}
+	if c.Err() != nil || c.pending != 1 {
+		t.Fatalf("expected a usable connection with a pending reply, got %v, %d", c.Err(), c.pending)
+	}
+
+	// the read timeout is extended by the timeout of BLPOP
+	c, err = Dial("tcp", ln.Addr().String(), DialReadTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	v, err := c.DoContext(context.Background(), "BLPOP", "list", "0.1")
+	if err != ErrNil || v.Type != TypeNull {
+		t.Fatalf("expected ErrNil but got %v, %v", v, err)
+	}
+	if _, err := c.Do("PING"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a blocking command interrupted by the context breaks the connection
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.DoContext(ctx, "BLPOP", "list", "0"); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded but got %v", err)
+	}
+	if c.Err() == nil {
+		t.Fatal("expected a broken connection")
+	}
+}
+
+func TestConn_RESP2(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := NewReader(conn)
+		for {
+			cmd, _, err := r.ReadValue()
+			if err != nil {
+				return
+			}
+			switch cmd.Elems[0].Str {
+			case "HELLO":
+				if cmd.Elems[1].Str != "2" {
+					conn.Write([]byte("-ERR expected HELLO 2\r\n"))
+					continue
+				}
+				conn.Write([]byte("*2\r\n$5\r\nproto\r\n:2\r\n"))
+			case "LRANGE":
+				conn.Write([]byte("*3\r\n$7\r\nmessage\r\n$1\r\na\r\n$1\r\nb\r\n"))
+			case "BLPOP":
+				conn.Write([]byte("*-1\r\n"))
+			case "SUBSCRIBE":
+				for i, elem := range cmd.Elems[1:] {
+					conn.Write([]byte("*3\r\n$9\r\nsubscribe\r\n$1\r\n" + elem.Str + "\r\n:" + strconv.Itoa(i+1) + "\r\n"))
+				}
+				conn.Write([]byte("*3\r\n$7\r\nmessage\r\n$1\r\na\r\n$2\r\nhi\r\n"))
+			case "UNSUBSCRIBE":
+				conn.Write([]byte("*3\r\n$11\r\nunsubscribe\r\n$1\r\na\r\n:1\r\n*3\r\n$11\r\nunsubscribe\r\n$1\r\nb\r\n:0\r\n"))
+			case "PING":
+				conn.Write([]byte("*2\r\n$4\r\npong\r\n$0\r\n\r\n"))
+			case "RESET":
+				conn.Write([]byte("+RESET\r\n"))
+			}
+		}
+	}()
+
+	c, err := Dial("tcp", ln.Addr().String(), DialProtocol(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	var pushes []*Value
+	c.OnPush = func(push *Value) {
+		pushes = append(pushes, push)
+	}
+
+	// an array like a message is a reply before SUBSCRIBE
+	if v, err := c.Do("LRANGE", "l", "0", "-1"); err != nil || v.Type != TypeArray || len(v.Elems) != 3 {
+		t.Errorf("unexpected reply %v, %v", v, err)
+	}
+	if v, err := c.Do("BLPOP", "l", "1"); err != ErrNil || !v.NullArray {
+		t.Errorf("expected ErrNil but got %v, %v", v, err)
+	}
+
+	if _, err := c.Do("SUBSCRIBE", "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := c.Do("PING"); err != nil || v.Type != TypeArray || v.Elems[0].Str != "pong" {
+		t.Fatalf("unexpected reply %v, %v", v, err)
+	}
+	if len(pushes) != 3 {
+		t.Fatalf("expected 3 push messages but got %v", pushes)
+	}
+	for i, kind := range []string{"subscribe", "subscribe", "message"} {
+		if pushes[i].Type != TypePush || pushes[i].Elems[0].Str != kind {
+			t.Errorf("expected %s but got %s", kind, pushes[i].ToRESP3String())
+		}
+	}
+
+	// an array like a message is a reply again after all channels are unsubscribed
+	c.Do("UNSUBSCRIBE")
+	if v, err := c.Do("LRANGE", "l", "0", "-1"); err != nil || v.Type != TypeArray || len(pushes) != 5 || c.subscribed {
+		t.Errorf("unexpected reply %v, %v", v, err)
+	}
+
+	c.Do("SUBSCRIBE", "a")
+	c.Do("PING")
+	if err := c.reset(resetTimeout); err != nil || c.dirty() || c.Err() != nil {
+		t.Fatalf("failed to reset: %v", err)
+	}
+	if v, err := c.Do("LRANGE", "l", "0", "-1"); err != nil || v.Type != TypeArray || len(pushes) != 7 {
+		t.Errorf("unexpected reply %v, %v", v, err)
+	}
+}
@@ -0,0 +1,167 @@
+package resp3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ErrInterrupted is returned by a Reader or Writer after a read or write is interrupted in the middle of a value.
+// The stream is out of sync, the connection must be closed.
+var ErrInterrupted = errors.New("resp: interrupted in the middle of a value")
+
+// aLongTimeAgo is a deadline in the past which makes blocked reads and writes return.
+var aLongTimeAgo = time.Unix(1, 0)
+
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+type writeDeadliner interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// ReadValueContext is like ReadValue but it returns the error of the context when it's canceled or its deadline passes.
+//
+// If the underlying reader has SetReadDeadline, like net.Conn, the deadline of the context is set as the read deadline
+// and a canceled context interrupts a blocked read. Otherwise the value is read in another goroutine.
+// If a value is partially read when the read is interrupted, the Reader is unusable
+// and all later reads return an error which wraps ErrInterrupted.
+func (r *Reader) ReadValueContext(ctx context.Context) (*Value, []byte, error) {
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var v *Value
+	var marker []byte
+	read := func() (err error) {
+		v, marker, err = r.readFrame()
+		return err
+	}
+
+	before := r.consumed()
+	d, ok := r.source().(readDeadliner)
+	if !ok {
+		if err := inBackground(ctx, read); err != nil {
+			if ctx.Err() != nil {
+				// the read is still running
+				r.err = fmt.Errorf("%w: %v", ErrInterrupted, err)
+			}
+			return nil, nil, err
+		}
+		return v, marker, nil
+	}
+
+	if err := withDeadline(ctx, d.SetReadDeadline, read); err != nil {
+		if isContextError(ctx, err) && r.consumed() != before {
+			r.err = fmt.Errorf("%w: %v", ErrInterrupted, err)
+		}
+		return nil, nil, err
+	}
+	return v, marker, nil
+}
+
+// WriteCommandContext is like WriteCommand but it returns the error of the context when it's canceled or its deadline passes.
+// A command is written to the buffer and flushed with FlushContext.
+func (w *Writer) WriteCommandContext(ctx context.Context, args ...string) error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	w.writeCommand(args)
+	return w.FlushContext(ctx)
+}
+
+// FlushContext is like Flush but it returns the error of the context when it's canceled or its deadline passes.
+//
+// If the underlying writer has SetWriteDeadline, like net.Conn, the deadline of the context is set as the write deadline
+// and a canceled context interrupts a blocked write. Otherwise the buffer is flushed in another goroutine.
+// The Writer is unusable after an interrupted flush and all later writes return an error which wraps ErrInterrupted.
+func (w *Writer) FlushContext(ctx context.Context) error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var err error
+	if d, ok := w.dst.(writeDeadliner); ok {
+		err = withDeadline(ctx, d.SetWriteDeadline, w.Flush)
+	} else {
+		err = inBackground(ctx, w.Flush)
+	}
+	if err != nil && isContextError(ctx, err) {
+		w.err = fmt.Errorf("%w: %v", ErrInterrupted, err)
+	}
+	return err
+}
+
+// Err returns the error which made the Writer unusable, or nil.
+func (w *Writer) Err() error {
+	return w.err
+}
+
+// withDeadline runs fn with the deadline of ctx, and interrupts it with a deadline in the past when ctx is canceled.
+// The error of the context is returned if fn is interrupted.
+func withDeadline(ctx context.Context, setDeadline func(time.Time) error, fn func() error) error {
+	deadline, hasDeadline := ctx.Deadline()
+	if hasDeadline {
+		setDeadline(deadline)
+	}
+	defer setDeadline(time.Time{})
+	if ctx.Done() == nil {
+		return fn()
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-ctx.Done():
+			setDeadline(aLongTimeAgo)
+		case <-stop:
+		}
+	}()
+	err := fn()
+	close(stop)
+	<-done
+
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var netErr net.Error
+	if hasDeadline && errors.As(err, &netErr) && netErr.Timeout() && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// inBackground runs fn in another goroutine and returns the error of the context if it's done first.
+func inBackground(ctx context.Context, fn func() error) error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- fn()
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func isContextError(ctx context.Context, err error) bool {
+	return err == ctx.Err() || err == context.DeadlineExceeded
+}
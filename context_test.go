@@ -0,0 +1,91 @@
+package resp3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestReadValueContext(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	r := NewReader(client)
+
+	// nothing is read, the reader is still usable
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := r.ReadValueContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded but got %v", err)
+	}
+	if r.Err() != nil {
+		t.Fatalf("expected a usable reader but got %v", r.Err())
+	}
+	go server.Write([]byte(":1\r\n"))
+	v, _, err := r.ReadValueContext(context.Background())
+	if err != nil || v.Integer != 1 {
+		t.Fatalf("expected 1 but got %v, %v", v, err)
+	}
+
+	// canceled in the middle of a value
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		server.Write([]byte("*2\r\n:1\r\n"))
+		cancel()
+	}()
+	if _, _, err := r.ReadValueContext(ctx); err != context.Canceled {
+		t.Fatalf("expected Canceled but got %v", err)
+	}
+	if !errors.Is(r.Err(), ErrInterrupted) {
+		t.Fatalf("expected ErrInterrupted but got %v", r.Err())
+	}
+	if _, _, err := r.ReadValue(); !errors.Is(err, ErrInterrupted) {
+		t.Errorf("expected ErrInterrupted but got %v", err)
+	}
+}
+
+func TestReadValueContext_NoDeadline(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	r := NewReader(pr)
+
+	go pw.Write([]byte("+OK\r\n"))
+	v, _, err := r.ReadValueContext(context.Background())
+	if err != nil || v.Str != "OK" {
+		t.Fatalf("expected OK but got %v, %v", v, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := r.ReadValueContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded but got %v", err)
+	}
+	if _, _, err := r.ReadValueContext(context.Background()); !errors.Is(err, ErrInterrupted) {
+		t.Errorf("expected ErrInterrupted but got %v", err)
+	}
+}
+
+func TestWriteCommandContext(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	w := NewWriter(client)
+
+	go NewReader(server).ReadValue()
+	if err := w.WriteCommandContext(context.Background(), "PING"); err != nil {
+		t.Fatal(err)
+	}
+
+	// nobody reads
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := w.WriteCommandContext(ctx, "PING"); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded but got %v", err)
+	}
+	if err := w.WriteCommand("PING"); !errors.Is(err, ErrInterrupted) {
+		t.Errorf("expected ErrInterrupted but got %v", err)
+	}
+}
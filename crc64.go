@@ -148,7 +148,11 @@ var crc64_tab = [256]uint64{
 }
 
 func crc64(data []byte) uint64 {
-	var crc uint64
+	return crc64Update(0, data)
+}
+
+// crc64Update continues the checksum crc with data.
+func crc64Update(crc uint64, data []byte) uint64 {
 	var l = uint64(len(data))
 	for j := uint64(0); j < l; j++ {
 		b := data[j]
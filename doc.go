@@ -12,6 +12,9 @@
 //
 // Writer is redis writer. You can use it to send commands to redis servers.
 //
+// Conn is a client connection built on Reader and Writer, and Pool is a pool of Conns.
+// Command builders like Set, HGetAll and ZAdd make commands with checked options and decode their replies to Go types.
+//
 // RESP3 spec can be found at https://github.com/antirez/RESP3.
 //
 // A redis client based on it is just as the below:
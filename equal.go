@@ -0,0 +1,241 @@
+package resp3
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/emirpasic/gods/maps/linkedhashmap"
+)
+
+type equalOptions struct {
+	unorderedSets    bool
+	unorderedMaps    bool
+	ignoreAttributes bool
+}
+
+// EqualOption configures how Equal compares values.
+type EqualOption func(*equalOptions)
+
+// UnorderedSets compares elements of Sets regardless of their order.
+func UnorderedSets() EqualOption {
+	return func(o *equalOptions) {
+		o.unorderedSets = true
+	}
+}
+
+// UnorderedMaps compares entries of Maps and Attributes regardless of their order.
+func UnorderedMaps() EqualOption {
+	return func(o *equalOptions) {
+		o.unorderedMaps = true
+	}
+}
+
+// IgnoreAttributes skips Attributes when comparing values.
+func IgnoreAttributes() EqualOption {
+	return func(o *equalOptions) {
+		o.ignoreAttributes = true
+	}
+}
+
+// Equal reports whether a and b are deeply equal.
+// By default the order of Set elements and Map entries matters and Attributes are compared.
+// Two NaN doubles are equal.
+func Equal(a, b *Value, opts ...EqualOption) bool {
+	var o equalOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return equal(a, b, &o)
+}
+
+// Compare compares the canonical encoded forms of a and b.
+// The result is 0 if a == b, -1 if a < b, and +1 if a > b.
+func Compare(a, b *Value) int {
+	return strings.Compare(a.Canonical().ToRESP3String(), b.Canonical().ToRESP3String())
+}
+
+func equal(a, b *Value, o *equalOptions) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Type != b.Type {
+		return false
+	}
+	if !o.ignoreAttributes && !equalMap(a.Attrs, b.Attrs, o) {
+		return false
+	}
+
+	switch a.Type {
+	case TypeSimpleString:
+		return a.Str == b.Str
+	case TypeBlobString:
+		return a.NullBulkString == b.NullBulkString && a.Str == b.Str
+	case TypeVerbatimString:
+		return a.StrFmt == b.StrFmt && a.Str == b.Str
+	case TypeSimpleError, TypeBlobError:
+		return a.Err == b.Err
+	case TypeNumber:
+		return a.Integer == b.Integer
+	case TypeDouble:
+		return a.Double == b.Double || (math.IsNaN(a.Double) && math.IsNaN(b.Double))
+	case TypeBigNumber:
+		if a.BigInt == nil || b.BigInt == nil {
+			return a.BigInt == b.BigInt
+		}
+		return a.BigInt.Cmp(b.BigInt) == 0
+	case TypeBoolean:
+		return a.Boolean == b.Boolean
+	case TypeArray, TypePush:
+		return a.NullArray == b.NullArray && equalElems(a.Elems, b.Elems, o)
+	case TypeSet:
+		if o.unorderedSets {
+			return equalUnorderedElems(a.Elems, b.Elems, o)
+		}
+		return equalElems(a.Elems, b.Elems, o)
+	case TypeMap:
+		return equalMap(a.KV, b.KV, o)
+	}
+
+	return true
+}
+
+func equalElems(a, b []*Value, o *equalOptions) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !equal(a[i], b[i], o) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalUnorderedElems matches every element of a to a distinct element of b.
+func equalUnorderedElems(a, b []*Value, o *equalOptions) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	used := make([]bool, len(b))
+	for _, x := range a {
+		found := false
+		for j, y := range b {
+			if !used[j] && equal(x, y, o) {
+				used[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func equalMap(a, b *linkedhashmap.Map, o *equalOptions) bool {
+	ka, va := mapEntries(a)
+	kb, vb := mapEntries(b)
+	if len(ka) != len(kb) {
+		return false
+	}
+
+	if !o.unorderedMaps {
+		return equalElems(ka, kb, o) && equalElems(va, vb, o)
+	}
+
+	used := make([]bool, len(kb))
+	for i := range ka {
+		found := false
+		for j := range kb {
+			if !used[j] && equal(ka[i], kb[j], o) && equal(va[i], vb[j], o) {
+				used[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func mapEntries(m *linkedhashmap.Map) (keys, values []*Value) {
+	if m == nil {
+		return nil, nil
+	}
+	m.Each(func(k, v interface{}) {
+		keys = append(keys, k.(*Value))
+		values = append(values, v.(*Value))
+	})
+	return keys, values
+}
+
+// Canonical returns a copy of the value in which the elements of Sets and the entries of Maps and Attributes
+// are sorted by their encoded form, recursively. Equal values have the same canonical encoding,
+// so -0 is written as 0.
+func (r *Value) Canonical() *Value {
+	if r == nil {
+		return nil
+	}
+
+	c := *r
+	if r.Attrs != nil {
+		c.Attrs = canonicalMap(r.Attrs)
+	}
+
+	switch r.Type {
+	case TypeDouble:
+		if r.Double == 0 {
+			c.Double = 0
+		}
+	case TypeArray, TypePush, TypeSet:
+		if r.Elems == nil {
+			break
+		}
+		c.Elems = make([]*Value, len(r.Elems))
+		for i, elem := range r.Elems {
+			c.Elems[i] = elem.Canonical()
+		}
+		if r.Type == TypeSet {
+			sortByEncoding(c.Elems)
+		}
+	case TypeMap:
+		if r.KV != nil {
+			c.KV = canonicalMap(r.KV)
+		}
+	}
+	return &c
+}
+
+func canonicalMap(m *linkedhashmap.Map) *linkedhashmap.Map {
+	type entry struct {
+		k, v *Value
+		enc  string
+	}
+
+	var entries []entry
+	m.Each(func(k, v interface{}) {
+		ck, cv := k.(*Value).Canonical(), v.(*Value).Canonical()
+		entries = append(entries, entry{k: ck, v: cv, enc: ck.ToRESP3String() + cv.ToRESP3String()})
+	})
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].enc < entries[j].enc
+	})
+
+	rt := linkedhashmap.New()
+	for _, e := range entries {
+		rt.Put(e.k, e.v)
+	}
+	return rt
+}
+
+func sortByEncoding(elems []*Value) {
+	encs := make(map[*Value]string, len(elems))
+	for _, elem := range elems {
+		encs[elem] = elem.ToRESP3String()
+	}
+	sort.SliceStable(elems, func(i, j int) bool {
+		return encs[elems[i]] < encs[elems[j]]
+	})
+}
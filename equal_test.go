@@ -0,0 +1,80 @@
+package resp3
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	a, _ := FromString("|1\r\n+ttl\r\n:10\r\n~3\r\n+a\r\n:1\r\n%2\r\n+x\r\n#t\r\n+y\r\n,nan\r\n")
+	b, _ := FromString("|1\r\n+ttl\r\n:10\r\n~3\r\n+a\r\n:1\r\n%2\r\n+x\r\n#t\r\n+y\r\n,nan\r\n")
+	if !Equal(a, b) {
+		t.Errorf("expected %s equals to %s", a.ToRESP3String(), b.ToRESP3String())
+	}
+
+	// different order of set elements and map entries, without attributes
+	c, _ := FromString("~3\r\n%2\r\n+y\r\n,nan\r\n+x\r\n#t\r\n:1\r\n+a\r\n")
+	if Equal(a, c) {
+		t.Errorf("expected %s not equals to %s", a.ToRESP3String(), c.ToRESP3String())
+	}
+	if Equal(a, c, UnorderedSets(), UnorderedMaps()) {
+		t.Errorf("expected attributes are compared")
+	}
+	if Equal(a, c, UnorderedSets(), IgnoreAttributes()) {
+		t.Errorf("expected map entries are ordered")
+	}
+	if !Equal(a, c, UnorderedSets(), UnorderedMaps(), IgnoreAttributes()) {
+		t.Errorf("expected %s equals to %s", a.ToRESP3String(), c.ToRESP3String())
+	}
+
+	// arrays are always ordered
+	d, _ := FromString("*2\r\n:1\r\n:2\r\n")
+	e, _ := FromString("*2\r\n:2\r\n:1\r\n")
+	if Equal(d, e, UnorderedSets(), UnorderedMaps()) {
+		t.Errorf("expected %s not equals to %s", d.ToRESP3String(), e.ToRESP3String())
+	}
+
+	// duplicated elements must be matched one by one
+	f, _ := FromString("~2\r\n:1\r\n:1\r\n")
+	g, _ := FromString("~2\r\n:1\r\n:2\r\n")
+	if Equal(f, g, UnorderedSets()) || Equal(g, f, UnorderedSets()) {
+		t.Errorf("expected %s not equals to %s", f.ToRESP3String(), g.ToRESP3String())
+	}
+
+	if !Equal(nil, nil) || Equal(nil, d) {
+		t.Errorf("unexpected result for nil values")
+	}
+	if Equal(NewBlobStringValue(""), &Value{Type: TypeBlobString, NullBulkString: true}) {
+		t.Errorf("expected null bulk string not equals to an empty string")
+	}
+	if !Equal(NewDoubleValue(math.NaN()), NewDoubleValue(math.NaN())) {
+		t.Errorf("expected NaN equals to NaN")
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	v, _ := FromString("~3\r\n+b\r\n%2\r\n+y\r\n:2\r\n+x\r\n:1\r\n+a\r\n")
+	expected := "~3\r\n%2\r\n+x\r\n:1\r\n+y\r\n:2\r\n+a\r\n+b\r\n"
+	if s := v.Canonical().ToRESP3String(); s != expected {
+		t.Errorf("expected %q but got %q", expected, s)
+	}
+
+	// the original value is not changed
+	if v.Elems[0].Str != "b" {
+		t.Errorf("expected the value is not changed but got %q", v.ToRESP3String())
+	}
+
+	w, _ := FromString("~3\r\n+a\r\n+b\r\n%2\r\n+x\r\n:1\r\n+y\r\n:2\r\n")
+	if Compare(v, w) != 0 {
+		t.Errorf("expected %s equals to %s", v.ToRESP3String(), w.ToRESP3String())
+	}
+	if Compare(NewNumberValue(1), NewNumberValue(2)) != -1 {
+		t.Errorf("expected :1 < :2")
+	}
+
+	// 0 and -0 are equal
+	negZero := NewDoubleValue(math.Copysign(0, -1))
+	if s := negZero.Canonical().ToRESP3String(); s != ",0\r\n" || Compare(negZero, NewDoubleValue(0)) != 0 {
+		t.Errorf("expected -0 is canonicalized to 0 but got %q", s)
+	}
+}
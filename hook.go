@@ -0,0 +1,164 @@
+package resp3
+
+import (
+	"context"
+	"time"
+)
+
+// Hook instruments connections, for metrics, tracing or logging. It's set by DialHook.
+//
+// Before hooks can return a new context, like a context with a span, which is passed to the after hooks.
+// Commands sent by Send are not reported, and neither are the commands of the handshake.
+// Embed NoopHook to implement a part of the hooks.
+type Hook interface {
+	// OnDial is called after a connection is dialed and the handshake is done or failed.
+	OnDial(ctx context.Context, e DialEvent)
+	// BeforeCommand is called before DoContext sends a command.
+	BeforeCommand(ctx context.Context, args []string) context.Context
+	// AfterCommand is called after DoContext returns.
+	AfterCommand(ctx context.Context, e CommandEvent)
+	// BeforePipeline is called before Pipeline or Exec of a transaction sends the commands.
+	BeforePipeline(ctx context.Context, cmds [][]string) context.Context
+	// AfterPipeline is called after Pipeline or Exec of a transaction returns.
+	AfterPipeline(ctx context.Context, e PipelineEvent)
+	// OnPush is called for the push messages, before OnPush of the connection.
+	OnPush(ctx context.Context, push *Value)
+}
+
+// DialEvent is the result of dialing.
+type DialEvent struct {
+	Network  string
+	Address  string
+	Duration time.Duration
+	Err      error
+}
+
+// CommandEvent is the result of a command.
+type CommandEvent struct {
+	Args     []string
+	Reply    *Value
+	Err      error
+	Duration time.Duration
+	// BytesWritten and BytesRead are counted by the Writer and Reader of the connection,
+	// the replies of commands sent before and the push messages are included.
+	BytesWritten int64
+	BytesRead    int64
+}
+
+// PipelineEvent is the result of a pipeline. A transaction is reported without MULTI and EXEC,
+// and its replies are the results of the commands.
+type PipelineEvent struct {
+	Cmds         [][]string
+	Replies      []*Value
+	Err          error
+	Duration     time.Duration
+	BytesWritten int64
+	BytesRead    int64
+}
+
+// NoopHook is a Hook which does nothing.
+type NoopHook struct{}
+
+// OnDial implements Hook.
+func (NoopHook) OnDial(ctx context.Context, e DialEvent) {}
+
+// BeforeCommand implements Hook.
+func (NoopHook) BeforeCommand(ctx context.Context, args []string) context.Context { return ctx }
+
+// AfterCommand implements Hook.
+func (NoopHook) AfterCommand(ctx context.Context, e CommandEvent) {}
+
+// BeforePipeline implements Hook.
+func (NoopHook) BeforePipeline(ctx context.Context, cmds [][]string) context.Context { return ctx }
+
+// AfterPipeline implements Hook.
+func (NoopHook) AfterPipeline(ctx context.Context, e PipelineEvent) {}
+
+// OnPush implements Hook.
+func (NoopHook) OnPush(ctx context.Context, push *Value) {}
+
+// DialHook adds a hook to the connections. Hooks are called in the order they are added.
+func DialHook(h Hook) DialOption {
+	return func(o *dialOptions) {
+		o.hooks = append(o.hooks, h)
+	}
+}
+
+// hooks calls the hooks in order.
+type hooks []Hook
+
+func (hs hooks) OnDial(ctx context.Context, e DialEvent) {
+	for _, h := range hs {
+		h.OnDial(ctx, e)
+	}
+}
+
+func (hs hooks) BeforeCommand(ctx context.Context, args []string) context.Context {
+	for _, h := range hs {
+		ctx = h.BeforeCommand(ctx, args)
+	}
+	return ctx
+}
+
+func (hs hooks) AfterCommand(ctx context.Context, e CommandEvent) {
+	for _, h := range hs {
+		h.AfterCommand(ctx, e)
+	}
+}
+
+func (hs hooks) BeforePipeline(ctx context.Context, cmds [][]string) context.Context {
+	for _, h := range hs {
+		ctx = h.BeforePipeline(ctx, cmds)
+	}
+	return ctx
+}
+
+func (hs hooks) AfterPipeline(ctx context.Context, e PipelineEvent) {
+	for _, h := range hs {
+		h.AfterPipeline(ctx, e)
+	}
+}
+
+func (hs hooks) OnPush(ctx context.Context, push *Value) {
+	for _, h := range hs {
+		h.OnPush(ctx, push)
+	}
+}
+
+// hookCommand calls BeforeCommand, and returns a function which calls AfterCommand.
+func (c *Conn) hookCommand(ctx context.Context, args []string) (context.Context, func(v *Value, err error)) {
+	if len(c.opts.hooks) == 0 {
+		return ctx, func(*Value, error) {}
+	}
+	ctx = c.opts.hooks.BeforeCommand(ctx, args)
+	start, written, read := time.Now(), c.w.written(), c.r.consumed()
+	return ctx, func(v *Value, err error) {
+		c.opts.hooks.AfterCommand(ctx, CommandEvent{
+			Args:         args,
+			Reply:        v,
+			Err:          err,
+			Duration:     time.Since(start),
+			BytesWritten: c.w.written() - written,
+			BytesRead:    c.r.consumed() - read,
+		})
+	}
+}
+
+// hookPipeline calls BeforePipeline, and returns a function which calls AfterPipeline.
+func (c *Conn) hookPipeline(ctx context.Context, cmds [][]string) (context.Context, func(replies []*Value, err error)) {
+	if len(c.opts.hooks) == 0 {
+		return ctx, func([]*Value, error) {}
+	}
+	ctx = c.opts.hooks.BeforePipeline(ctx, cmds)
+	start, written, read := time.Now(), c.w.written(), c.r.consumed()
+	return ctx, func(replies []*Value, err error) {
+		c.opts.hooks.AfterPipeline(ctx, PipelineEvent{
+			Cmds:         cmds,
+			Replies:      replies,
+			Err:          err,
+			Duration:     time.Since(start),
+			BytesWritten: c.w.written() - written,
+			BytesRead:    c.r.consumed() - read,
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package resp3
+
+import (
+	"context"
+	"expvar"
+	"strings"
+)
+
+// ExpvarHook is a Hook which counts in an expvar.Map:
+//
+//	dials, dial_errors          connections dialed and the failures
+//	commands, command_errors    commands, including the commands of pipelines, and the errors except ErrNil
+//	pipelines, pipeline_errors  pipelines and transactions, and the failures
+//	pushes                      push messages
+//	bytes_written, bytes_read   bytes of the commands and the replies
+//	duration_us                 the total time of the commands and the pipelines in microseconds
+//	calls                       a map of the lowercase command names to the number of calls
+//
+//	hook := resp3.NewExpvarHook(expvar.NewMap("redis"))
+//	pool := resp3.NewPool(addr, resp3.DialHook(hook))
+type ExpvarHook struct {
+	NoopHook
+	m     *expvar.Map
+	calls *expvar.Map
+}
+
+// NewExpvarHook returns a hook which counts in m.
+func NewExpvarHook(m *expvar.Map) *ExpvarHook {
+	calls := new(expvar.Map).Init()
+	m.Set("calls", calls)
+	return &ExpvarHook{m: m, calls: calls}
+}
+
+// OnDial implements Hook.
+func (h *ExpvarHook) OnDial(ctx context.Context, e DialEvent) {
+	h.m.Add("dials", 1)
+	if e.Err != nil {
+		h.m.Add("dial_errors", 1)
+	}
+}
+
+// AfterCommand implements Hook.
+func (h *ExpvarHook) AfterCommand(ctx context.Context, e CommandEvent) {
+	h.count(e.Args)
+	if e.Err != nil && e.Err != ErrNil {
+		h.m.Add("command_errors", 1)
+	}
+	h.m.Add("bytes_written", e.BytesWritten)
+	h.m.Add("bytes_read", e.BytesRead)
+	h.m.Add("duration_us", e.Duration.Microseconds())
+}
+
+// AfterPipeline implements Hook.
+func (h *ExpvarHook) AfterPipeline(ctx context.Context, e PipelineEvent) {
+	h.m.Add("pipelines", 1)
+	for _, cmd := range e.Cmds {
+		h.count(cmd)
+	}
+	for _, v := range e.Replies {
+		if v != nil && (v.Type == TypeSimpleError || v.Type == TypeBlobError) {
+			h.m.Add("command_errors", 1)
+		}
+	}
+	if e.Err != nil {
+		h.m.Add("pipeline_errors", 1)
+	}
+	h.m.Add("bytes_written", e.BytesWritten)
+	h.m.Add("bytes_read", e.BytesRead)
+	h.m.Add("duration_us", e.Duration.Microseconds())
+}
+
+// OnPush implements Hook.
+func (h *ExpvarHook) OnPush(ctx context.Context, push *Value) {
+	h.m.Add("pushes", 1)
+}
+
+func (h *ExpvarHook) count(args []string) {
+	h.m.Add("commands", 1)
+	if len(args) > 0 {
+		h.calls.Add(strings.ToLower(args[0]), 1)
+	}
+}
@@ -0,0 +1,78 @@
+//go:build go1.21
+
+package resp3
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// SlogHook is a Hook which logs the dials, commands, pipelines and push messages with a slog.Logger.
+// Only the names of the commands are logged, the arguments may have secrets like the password of AUTH.
+//
+//	pool := resp3.NewPool(addr, resp3.DialHook(resp3.NewSlogHook(slog.Default(), slog.LevelDebug)))
+type SlogHook struct {
+	NoopHook
+	Logger *slog.Logger
+	// Level is the level of the logs. Errors which aren't error replies are logged at slog.LevelError.
+	Level slog.Level
+}
+
+// NewSlogHook returns a hook which logs at the level.
+func NewSlogHook(logger *slog.Logger, level slog.Level) *SlogHook {
+	return &SlogHook{Logger: logger, Level: level}
+}
+
+// OnDial implements Hook.
+func (h *SlogHook) OnDial(ctx context.Context, e DialEvent) {
+	h.log(ctx, "resp dial", e.Err,
+		slog.String("network", e.Network),
+		slog.String("address", e.Address),
+		slog.Duration("duration", e.Duration))
+}
+
+// AfterCommand implements Hook.
+func (h *SlogHook) AfterCommand(ctx context.Context, e CommandEvent) {
+	var name string
+	if len(e.Args) > 0 {
+		name = strings.ToUpper(e.Args[0])
+	}
+	h.log(ctx, "resp command", e.Err,
+		slog.String("cmd", name),
+		slog.Duration("duration", e.Duration),
+		slog.Int64("bytes_written", e.BytesWritten),
+		slog.Int64("bytes_read", e.BytesRead))
+}
+
+// AfterPipeline implements Hook.
+func (h *SlogHook) AfterPipeline(ctx context.Context, e PipelineEvent) {
+	h.log(ctx, "resp pipeline", e.Err,
+		slog.Int("cmds", len(e.Cmds)),
+		slog.Duration("duration", e.Duration),
+		slog.Int64("bytes_written", e.BytesWritten),
+		slog.Int64("bytes_read", e.BytesRead))
+}
+
+// OnPush implements Hook.
+func (h *SlogHook) OnPush(ctx context.Context, push *Value) {
+	var kind string
+	if len(push.Elems) > 0 {
+		kind = push.Elems[0].Str
+	}
+	h.log(ctx, "resp push", nil, slog.String("kind", kind))
+}
+
+func (h *SlogHook) log(ctx context.Context, msg string, err error, attrs ...slog.Attr) {
+	level := h.Level
+	if err != nil && !isReplyError(err) {
+		level = slog.LevelError
+	}
+	if !h.Logger.Enabled(ctx, level) {
+		return
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("err", err.Error()))
+	}
+	h.Logger.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,42 @@
+//go:build go1.21
+
+package resp3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlogHook(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	h := NewSlogHook(logger, slog.LevelDebug)
+	ctx := context.Background()
+
+	h.AfterCommand(ctx, CommandEvent{Args: []string{"auth", "secret"}, Err: Error("WRONGPASS invalid"), Duration: time.Millisecond})
+	h.OnDial(ctx, DialEvent{Network: "tcp", Address: "127.0.0.1:6379", Err: errors.New("refused")})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines but got %q", lines)
+	}
+	if !strings.Contains(lines[0], "level=DEBUG") || !strings.Contains(lines[0], "cmd=AUTH") ||
+		!strings.Contains(lines[0], `err="WRONGPASS invalid"`) || strings.Contains(lines[0], "secret") {
+		t.Errorf("unexpected log %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "level=ERROR") || !strings.Contains(lines[1], "address=127.0.0.1:6379") {
+		t.Errorf("unexpected log %s", lines[1])
+	}
+
+	// not enabled
+	buf.Reset()
+	NewSlogHook(logger, slog.LevelDebug-1).OnPush(ctx, NewPushValue([]*Value{NewBlobStringValue("message")}))
+	if buf.Len() != 0 {
+		t.Errorf("unexpected log %s", buf.String())
+	}
+}
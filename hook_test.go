@@ -0,0 +1,128 @@
+package resp3
+
+import (
+	"context"
+	"expvar"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type ctxKey struct{}
+
+// recordHook records the events, and checks the context returned by the before hooks is passed to the after hooks.
+type recordHook struct {
+	t         *testing.T
+	dials     []DialEvent
+	commands  []CommandEvent
+	pipelines []PipelineEvent
+	pushes    []*Value
+}
+
+func (h *recordHook) OnDial(ctx context.Context, e DialEvent) {
+	h.dials = append(h.dials, e)
+}
+
+func (h *recordHook) BeforeCommand(ctx context.Context, args []string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, args[0])
+}
+
+func (h *recordHook) AfterCommand(ctx context.Context, e CommandEvent) {
+	if ctx.Value(ctxKey{}) != e.Args[0] {
+		h.t.Errorf("unexpected context of %v", e.Args)
+	}
+	h.commands = append(h.commands, e)
+}
+
+func (h *recordHook) BeforePipeline(ctx context.Context, cmds [][]string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, len(cmds))
+}
+
+func (h *recordHook) AfterPipeline(ctx context.Context, e PipelineEvent) {
+	if ctx.Value(ctxKey{}) != len(e.Cmds) {
+		h.t.Errorf("unexpected context of %v", e.Cmds)
+	}
+	h.pipelines = append(h.pipelines, e)
+}
+
+func (h *recordHook) OnPush(ctx context.Context, push *Value) {
+	h.pushes = append(h.pushes, push)
+}
+
+func TestHook(t *testing.T) {
+	ln := serveFake(t, func(args []string, write func(v *Value)) *Value {
+		switch args[0] {
+		case "GET":
+			write(NewPushValue([]*Value{NewBlobStringValue("invalidate"), NewNullValue()}))
+			return NewBlobStringValue("v")
+		case "MULTI", "SET":
+			return NewSimpleStringValue("OK")
+		case "EXEC":
+			return NewArrayValue([]*Value{NewSimpleStringValue("OK")})
+		case "NOPE":
+			return &Value{Type: TypeSimpleError, Err: "ERR unknown command"}
+		}
+		return NewSimpleStringValue("QUEUED")
+	})
+	defer ln.Close()
+
+	h := &recordHook{t: t}
+	m := new(expvar.Map).Init()
+	c, err := Dial("tcp", ln.Addr().String(), DialDatabase(1), DialHook(h), DialHook(NewExpvarHook(m)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	if len(h.dials) != 1 || h.dials[0].Err != nil || h.dials[0].Address != ln.Addr().String() {
+		t.Errorf("unexpected dials %+v", h.dials)
+	}
+	if len(h.commands) != 0 {
+		t.Errorf("expected no commands of the handshake but got %+v", h.commands)
+	}
+
+	c.DoContext(ctx, "GET", "k")
+	c.DoContext(ctx, "NOPE")
+	if len(h.commands) != 2 || len(h.pushes) != 1 {
+		t.Fatalf("unexpected commands %+v, pushes %v", h.commands, h.pushes)
+	}
+	e := h.commands[0]
+	if e.Reply.Str != "v" || e.Err != nil || e.Duration <= 0 ||
+		e.BytesWritten != int64(len("*2\r\n$3\r\nGET\r\n$1\r\nk\r\n")) ||
+		e.BytesRead != int64(len(">2\r\n$10\r\ninvalidate\r\n_\r\n$1\r\nv\r\n")) {
+		t.Errorf("unexpected event %+v", e)
+	}
+	if h.commands[1].Err == nil {
+		t.Errorf("expected an error reply")
+	}
+
+	replies, err := c.Pipeline(ctx, []string{"SET", "k", "v"}, []string{"GET", "k"})
+	if err != nil || len(replies) != 2 || replies[1].Str != "v" {
+		t.Fatalf("unexpected replies %v, %v", replies, err)
+	}
+	tx := NewTx(c)
+	tx.Queue("SET", "k", "v")
+	if _, err := tx.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.pipelines) != 2 || len(h.commands) != 2 {
+		t.Fatalf("unexpected pipelines %+v", h.pipelines)
+	}
+	if !reflect.DeepEqual(h.pipelines[1].Cmds, [][]string{{"SET", "k", "v"}}) || h.pipelines[1].Replies[0].Str != "OK" {
+		t.Errorf("unexpected transaction %+v", h.pipelines[1])
+	}
+
+	for name, expected := range map[string]string{
+		"dials":          "1",
+		"commands":       "5",
+		"command_errors": "1",
+		"pipelines":      "2",
+		"pushes":         "2",
+		"calls":          `{"get": 2, "nope": 1, "set": 2}`,
+	} {
+		if v := m.Get(name); v == nil || strings.ReplaceAll(v.String(), "\n", "") != expected {
+			t.Errorf("expected %s of %s but got %v", expected, name, v)
+		}
+	}
+}
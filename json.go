@@ -0,0 +1,371 @@
+package resp3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/emirpasic/gods/maps/linkedhashmap"
+)
+
+// ErrInvalidJSON is returned when a lossless JSON document can't be converted to a Value.
+var ErrInvalidJSON = errors.New("resp: invalid lossless json")
+
+var typeNames = map[byte]string{
+	TypeBlobString:     "blob_string",
+	TypeSimpleString:   "simple_string",
+	TypeSimpleError:    "simple_error",
+	TypeNumber:         "number",
+	TypeNull:           "null",
+	TypeDouble:         "double",
+	TypeBoolean:        "boolean",
+	TypeBlobError:      "blob_error",
+	TypeVerbatimString: "verbatim_string",
+	TypeBigNumber:      "big_number",
+	TypeArray:          "array",
+	TypeMap:            "map",
+	TypeSet:            "set",
+	TypePush:           "push",
+}
+
+var typesByName = func() map[string]byte {
+	rt := make(map[string]byte, len(typeNames))
+	for t, name := range typeNames {
+		rt[name] = t
+	}
+	return rt
+}()
+
+// MarshalJSON converts the value to natural JSON. Attributes are dropped.
+// String -> string
+// Null, null bulk string and null array -> null
+// Number and Double -> number, inf, -inf and nan are strings
+// BigNumber -> string
+// Boolean -> bool
+// Error -> {"error": "message"}
+// Array, Set and Push -> array
+// Map -> object, keys are converted to strings
+//
+// Use MarshalLosslessJSON to keep the RESP3 types.
+func (r *Value) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	err := r.writeJSON(buf)
+	return buf.Bytes(), err
+}
+
+func (r *Value) writeJSON(buf *bytes.Buffer) error {
+	switch r.Type {
+	case TypeSimpleString, TypeVerbatimString:
+		return writeJSONString(buf, r.Str)
+	case TypeBlobString:
+		if r.NullBulkString {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeJSONString(buf, r.Str)
+	case TypeSimpleError, TypeBlobError:
+		buf.WriteString(`{"error":`)
+		if err := writeJSONString(buf, r.Err); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+	case TypeNumber:
+		buf.WriteString(strconv.FormatInt(r.Integer, 10))
+	case TypeDouble:
+		if math.IsNaN(r.Double) || math.IsInf(r.Double, 0) {
+			return writeJSONString(buf, formatDouble(r.Double))
+		}
+		buf.WriteString(formatDouble(r.Double))
+	case TypeBigNumber:
+		return writeJSONString(buf, r.BigInt.String())
+	case TypeBoolean:
+		buf.WriteString(strconv.FormatBool(r.Boolean))
+	case TypeArray, TypeSet, TypePush:
+		if r.NullArray {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i, elem := range r.Elems {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := elem.writeJSON(buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case TypeMap:
+		buf.WriteByte('{')
+		keys, values := mapEntries(r.KV)
+		for i := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := keys[i].jsonKey()
+			if err != nil {
+				return err
+			}
+			if err = writeJSONString(buf, key); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err = values[i].writeJSON(buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		buf.WriteString("null")
+	}
+	return nil
+}
+
+// jsonKey converts a map key to a JSON object key.
+func (r *Value) jsonKey() (string, error) {
+	switch r.Type {
+	case TypeSimpleString, TypeBlobString, TypeVerbatimString:
+		return r.Str, nil
+	case TypeSimpleError, TypeBlobError:
+		return r.Err, nil
+	case TypeNumber:
+		return strconv.FormatInt(r.Integer, 10), nil
+	case TypeDouble:
+		return formatDouble(r.Double), nil
+	case TypeBigNumber:
+		return r.BigInt.String(), nil
+	case TypeBoolean:
+		return strconv.FormatBool(r.Boolean), nil
+	case TypeNull:
+		return "null", nil
+	}
+
+	// aggregate keys use their JSON text
+	data, err := r.MarshalJSON()
+	return string(data), err
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
+
+// jsonValue is the lossless JSON form of a Value.
+// Maps and Attributes are arrays of [key, value] pairs to keep their order and non string keys.
+type jsonValue struct {
+	Type       string            `json:"type"`
+	Format     string            `json:"format,omitempty"`
+	Null       bool              `json:"null,omitempty"`
+	Value      json.RawMessage   `json:"value,omitempty"`
+	Attributes []json.RawMessage `json:"attributes,omitempty"`
+}
+
+// MarshalLosslessJSON converts the value to a tagged JSON which keeps the RESP3 types and attributes,
+// for example {"type":"double","value":"1.5"}. FromLosslessJSON converts it back to an identical Value.
+func (r *Value) MarshalLosslessJSON() ([]byte, error) {
+	name, ok := typeNames[r.Type]
+	if !ok {
+		return nil, ErrUnknown
+	}
+	jv := jsonValue{Type: name}
+
+	var value interface{}
+	switch r.Type {
+	case TypeSimpleString:
+		value = r.Str
+	case TypeBlobString:
+		if r.NullBulkString {
+			jv.Null = true
+		} else {
+			value = r.Str
+		}
+	case TypeVerbatimString:
+		jv.Format = r.StrFmt
+		value = r.Str
+	case TypeSimpleError, TypeBlobError:
+		value = r.Err
+	case TypeNumber:
+		value = r.Integer
+	case TypeDouble:
+		value = formatDouble(r.Double)
+	case TypeBigNumber:
+		value = r.BigInt.String()
+	case TypeBoolean:
+		value = r.Boolean
+	case TypeArray, TypeSet, TypePush:
+		if r.NullArray {
+			jv.Null = true
+			break
+		}
+		elems := make([]json.RawMessage, 0, len(r.Elems))
+		for _, elem := range r.Elems {
+			data, err := elem.MarshalLosslessJSON()
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, data)
+		}
+		value = elems
+	case TypeMap:
+		pairs, err := losslessPairs(r.KV)
+		if err != nil {
+			return nil, err
+		}
+		value = pairs
+	}
+
+	if value != nil {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		jv.Value = data
+	}
+
+	if r.Attrs != nil && r.Attrs.Size() > 0 {
+		pairs, err := losslessPairs(r.Attrs)
+		if err != nil {
+			return nil, err
+		}
+		jv.Attributes = pairs
+	}
+
+	return json.Marshal(jv)
+}
+
+func losslessPairs(m *linkedhashmap.Map) ([]json.RawMessage, error) {
+	keys, values := mapEntries(m)
+	pairs := make([]json.RawMessage, 0, len(keys))
+	for i := range keys {
+		k, err := keys[i].MarshalLosslessJSON()
+		if err != nil {
+			return nil, err
+		}
+		v, err := values[i].MarshalLosslessJSON()
+		if err != nil {
+			return nil, err
+		}
+		pair, err := json.Marshal([]json.RawMessage{k, v})
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
+
+// FromLosslessJSON converts JSON created by MarshalLosslessJSON into a Value.
+func FromLosslessJSON(data []byte) (*Value, error) {
+	var jv jsonValue
+	if err := json.Unmarshal(data, &jv); err != nil {
+		return nil, err
+	}
+	t, ok := typesByName[jv.Type]
+	if !ok {
+		return nil, ErrInvalidJSON
+	}
+
+	v := &Value{Type: t}
+	var err error
+	switch t {
+	case TypeSimpleString:
+		err = unmarshalJSONValue(jv.Value, &v.Str)
+	case TypeBlobString:
+		if jv.Null {
+			v.NullBulkString = true
+		} else {
+			err = unmarshalJSONValue(jv.Value, &v.Str)
+		}
+	case TypeVerbatimString:
+		v.StrFmt = jv.Format
+		err = unmarshalJSONValue(jv.Value, &v.Str)
+	case TypeSimpleError, TypeBlobError:
+		err = unmarshalJSONValue(jv.Value, &v.Err)
+	case TypeNumber:
+		err = unmarshalJSONValue(jv.Value, &v.Integer)
+	case TypeDouble:
+		var s string
+		if err = unmarshalJSONValue(jv.Value, &s); err == nil {
+			v.Double, err = (&Reader{}).readDouble([]byte("," + s + CRLF))
+		}
+	case TypeBigNumber:
+		var s string
+		if err = unmarshalJSONValue(jv.Value, &s); err == nil {
+			var ok bool
+			if v.BigInt, ok = new(big.Int).SetString(s, 10); !ok {
+				err = ErrInvalidJSON
+			}
+		}
+	case TypeBoolean:
+		err = unmarshalJSONValue(jv.Value, &v.Boolean)
+	case TypeArray, TypeSet, TypePush:
+		if jv.Null {
+			v.NullArray = true
+			break
+		}
+		var elems []json.RawMessage
+		if err = unmarshalJSONValue(jv.Value, &elems); err == nil {
+			v.Elems, err = fromLosslessElems(elems)
+		}
+	case TypeMap:
+		var pairs []json.RawMessage
+		if err = unmarshalJSONValue(jv.Value, &pairs); err == nil {
+			v.KV, err = fromLosslessPairs(pairs)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(jv.Attributes) > 0 {
+		if v.Attrs, err = fromLosslessPairs(jv.Attributes); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func unmarshalJSONValue(data json.RawMessage, v interface{}) error {
+	if len(data) == 0 {
+		return ErrInvalidJSON
+	}
+	return json.Unmarshal(data, v)
+}
+
+func fromLosslessElems(elems []json.RawMessage) ([]*Value, error) {
+	var rt []*Value
+	for _, elem := range elems {
+		v, err := FromLosslessJSON(elem)
+		if err != nil {
+			return nil, err
+		}
+		rt = append(rt, v)
+	}
+	return rt, nil
+}
+
+func fromLosslessPairs(pairs []json.RawMessage) (*linkedhashmap.Map, error) {
+	rt := linkedhashmap.New()
+	for _, pair := range pairs {
+		var kv []json.RawMessage
+		if err := json.Unmarshal(pair, &kv); err != nil {
+			return nil, err
+		}
+		if len(kv) != 2 {
+			return nil, ErrInvalidJSON
+		}
+		elems, err := fromLosslessElems(kv)
+		if err != nil {
+			return nil, err
+		}
+		rt.Put(elems[0], elems[1])
+	}
+	return rt, nil
+}
@@ -0,0 +1,93 @@
+package resp3
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValue_MarshalJSON(t *testing.T) {
+	var cases = map[string]string{
+		"$11\r\nhello world\r\n": `"hello world"`,
+		"$-1\r\n":                `null`,
+		"_\r\n":                  `null`,
+		"*-1\r\n":                `null`,
+		":-12\r\n":               `-12`,
+		",1.5\r\n":               `1.5`,
+		",-inf\r\n":              `"-inf"`,
+		"#t\r\n":                 `true`,
+		"(3492890328409238509324850943850943825024385\r\n": `"3492890328409238509324850943850943825024385"`,
+		"-ERR unknown\r\n":                                `{"error":"ERR unknown"}`,
+		"=15\r\ntxt:Some string\r\n":                      `"Some string"`,
+		"~2\r\n+a\r\n:1\r\n":                              `["a",1]`,
+		"%3\r\n+b\r\n:1\r\n:2\r\n_\r\n#f\r\n*1\r\n+x\r\n": `{"b":1,"2":null,"false":["x"]}`,
+		"|1\r\n+ttl\r\n:3\r\n*1\r\n:1\r\n":                `[1]`,
+	}
+
+	for resp, expected := range cases {
+		v, err := FromString(resp)
+		if err != nil {
+			t.Errorf("failed to parse %q: %v", resp, err)
+			continue
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Errorf("failed to marshal %q: %v", resp, err)
+			continue
+		}
+		if string(data) != expected {
+			t.Errorf("expected %s but got %s", expected, data)
+		}
+	}
+}
+
+func TestValue_MarshalLosslessJSON(t *testing.T) {
+	var cases = []string{
+		"$11\r\nhello world\r\n",
+		"$0\r\n\r\n",
+		"$-1\r\n",
+		"+OK\r\n",
+		"-ERR unknown\r\n",
+		"!21\r\nSYNTAX invalid syntax\r\n",
+		":-12\r\n",
+		"_\r\n",
+		",nan\r\n",
+		",1.7976931348623157e+308\r\n",
+		"#f\r\n",
+		"=15\r\nmkd:Some string\r\n",
+		"(-3492890328409238509324850943850943825024385\r\n",
+		"*0\r\n",
+		"*-1\r\n",
+		"~2\r\n+a\r\n:1\r\n",
+		">2\r\n+invalidate\r\n*1\r\n$1\r\na\r\n",
+		"%2\r\n:1\r\n+one\r\n*1\r\n:2\r\n%0\r\n",
+		"|1\r\n+key-popularity\r\n%1\r\n$1\r\na\r\n,0.1923\r\n*2\r\n:2039123\r\n:9543892\r\n",
+	}
+
+	for _, resp := range cases {
+		v, err := FromString(resp)
+		if err != nil {
+			t.Errorf("failed to parse %q: %v", resp, err)
+			continue
+		}
+		data, err := v.MarshalLosslessJSON()
+		if err != nil {
+			t.Errorf("failed to marshal %q: %v", resp, err)
+			continue
+		}
+		v2, err := FromLosslessJSON(data)
+		if err != nil {
+			t.Errorf("failed to unmarshal %s: %v", data, err)
+			continue
+		}
+		if !Equal(v, v2) {
+			t.Errorf("expected %q but got %q from %s", resp, v2.ToRESP3String(), data)
+		}
+	}
+
+	if _, err := FromLosslessJSON([]byte(`{"type":"unknown"}`)); err != ErrInvalidJSON {
+		t.Errorf("expected ErrInvalidJSON but got %v", err)
+	}
+	if _, err := FromLosslessJSON([]byte(`{"type":"number"}`)); err != ErrInvalidJSON {
+		t.Errorf("expected ErrInvalidJSON but got %v", err)
+	}
+}
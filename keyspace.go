@@ -0,0 +1,151 @@
+package resp3
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Event is a keyspace notification.
+type Event struct {
+	DB  int
+	Key string
+	// Op is the event, like set, del, expired, evicted or hset.
+	Op string
+}
+
+// KeyspaceOption sets an option of ListenKeyspace.
+type KeyspaceOption func(*keyspaceOptions)
+
+type keyspaceOptions struct {
+	config string
+	db     int
+	keys   string
+	ops    []string
+}
+
+// KeyspaceConfig sets notify-keyspace-events of the server by CONFIG SET before subscribing, like "KEA".
+// The notifications are sent only if they are enabled in the configuration.
+func KeyspaceConfig(flags string) KeyspaceOption {
+	return func(o *keyspaceOptions) {
+		o.config = flags
+	}
+}
+
+// KeyspaceDB only listens to the events of a database, all databases by default.
+func KeyspaceDB(db int) KeyspaceOption {
+	return func(o *keyspaceOptions) {
+		o.db = db
+	}
+}
+
+// KeyspaceKeys only listens to the events of the keys which match the glob pattern.
+// It needs keyspace notifications, K in notify-keyspace-events.
+func KeyspaceKeys(pattern string) KeyspaceOption {
+	return func(o *keyspaceOptions) {
+		o.keys = pattern
+	}
+}
+
+// KeyspaceOps only listens to the events, like "expired" and "evicted".
+// Without KeyspaceKeys it needs keyevent notifications, E in notify-keyspace-events,
+// and the events are filtered by the server.
+func KeyspaceOps(ops ...string) KeyspaceOption {
+	return func(o *keyspaceOptions) {
+		o.ops = ops
+	}
+}
+
+// ListenKeyspace subscribes to keyspace notifications on the connection,
+// and calls fn with the events until the context is done or the connection is broken.
+// The connection is still subscribed when it returns, it can be closed or put back to a Pool, which resets it.
+func ListenKeyspace(ctx context.Context, c *Conn, fn func(Event), opts ...KeyspaceOption) error {
+	o := keyspaceOptions{db: -1, keys: "*"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.config != "" {
+		if _, err := c.DoContext(ctx, "CONFIG", "SET", "notify-keyspace-events", o.config); err != nil {
+			return err
+		}
+	}
+
+	db := "*"
+	if o.db >= 0 {
+		db = strconv.Itoa(o.db)
+	}
+	args := []string{"PSUBSCRIBE"}
+	ops := make(map[string]bool)
+	if len(o.ops) > 0 && o.keys == "*" {
+		for _, op := range o.ops {
+			args = append(args, "__keyevent@"+db+"__:"+op)
+		}
+	} else {
+		args = append(args, "__keyspace@"+db+"__:"+o.keys)
+		for _, op := range o.ops {
+			ops[op] = true
+		}
+	}
+
+	onPush := c.OnPush
+	defer func() {
+		c.OnPush = onPush
+	}()
+	c.OnPush = func(v *Value) {
+		if e, ok := ParseEvent(v); ok && (len(ops) == 0 || ops[e.Op]) {
+			fn(e)
+		} else if onPush != nil {
+			onPush(v)
+		}
+	}
+	if _, err := c.DoContext(ctx, args...); err != nil {
+		return err
+	}
+	for {
+		if _, err := c.ReceiveContext(ctx); err != nil && !isReplyError(err) {
+			return err
+		}
+	}
+}
+
+// ParseEvent parses a push message of a keyspace or keyevent notification.
+// It returns false if the message is not a notification.
+//
+// A notification is published on __keyspace@<db>__:<key> with the event,
+// and on __keyevent@<db>__:<event> with the key.
+func ParseEvent(push *Value) (Event, bool) {
+	var channel, payload *Value
+	switch {
+	case len(push.Elems) == 3 && push.Elems[0].Str == "message":
+		channel, payload = push.Elems[1], push.Elems[2]
+	case len(push.Elems) == 4 && push.Elems[0].Str == "pmessage":
+		channel, payload = push.Elems[2], push.Elems[3]
+	default:
+		return Event{}, false
+	}
+
+	var keyspace bool
+	var rest string
+	switch {
+	case strings.HasPrefix(channel.Str, "__keyspace@"):
+		keyspace, rest = true, channel.Str[len("__keyspace@"):]
+	case strings.HasPrefix(channel.Str, "__keyevent@"):
+		rest = channel.Str[len("__keyevent@"):]
+	default:
+		return Event{}, false
+	}
+	i := strings.Index(rest, "__:")
+	if i < 0 {
+		return Event{}, false
+	}
+	db, err := strconv.Atoi(rest[:i])
+	if err != nil {
+		return Event{}, false
+	}
+
+	if keyspace {
+		return Event{DB: db, Key: rest[i+3:], Op: payload.Str}, true
+	}
+	return Event{DB: db, Key: payload.Str, Op: rest[i+3:]}, true
+}
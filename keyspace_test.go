@@ -0,0 +1,87 @@
+package resp3
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseEvent(t *testing.T) {
+	str := NewBlobStringValue
+	tests := []struct {
+		push *Value
+		want Event
+		ok   bool
+	}{
+		{NewPushValue([]*Value{str("pmessage"), str("__keyspace@*__:*"), str("__keyspace@0__:user:1"), str("hset")}), Event{0, "user:1", "hset"}, true},
+		{NewPushValue([]*Value{str("message"), str("__keyevent@12__:expired"), str("k:__:x")}), Event{12, "k:__:x", "expired"}, true},
+		{NewPushValue([]*Value{str("message"), str("news"), str("hello")}), Event{}, false},
+		{NewPushValue([]*Value{str("message"), str("__keyevent@x__:del"), str("k")}), Event{}, false},
+		{NewPushValue([]*Value{str("invalidate"), NewArrayValue(nil)}), Event{}, false},
+	}
+	for _, tt := range tests {
+		if e, ok := ParseEvent(tt.push); e != tt.want || ok != tt.ok {
+			t.Errorf("%s: expected %+v, %v but got %+v, %v", tt.push.ToRESP3String(), tt.want, tt.ok, e, ok)
+		}
+	}
+}
+
+func TestListenKeyspace(t *testing.T) {
+	testListenKeyspace(t, 3)
+	// messages are arrays
+	testListenKeyspace(t, 2)
+}
+
+func testListenKeyspace(t *testing.T, proto int) {
+	push := NewPushValue
+	if proto == 2 {
+		push = NewArrayValue
+	}
+	var mu sync.Mutex
+	var cmds []string
+	ln := serveFake(t, func(args []string, write func(v *Value)) *Value {
+		mu.Lock()
+		cmds = append(cmds, strings.Join(args, " "))
+		mu.Unlock()
+		if args[0] != "PSUBSCRIBE" {
+			return NewSimpleStringValue("OK")
+		}
+		str := NewBlobStringValue
+		for i, pattern := range args[1:] {
+			write(push([]*Value{str("psubscribe"), str(pattern), NewNumberValue(int64(i + 1))}))
+		}
+		for _, ev := range [][2]string{{"user:1", "set"}, {"user:2", "del"}, {"user:1", "expired"}} {
+			write(push([]*Value{str("pmessage"), str(args[1]), str("__keyspace@3__:" + ev[0]), str(ev[1])}))
+		}
+		return nil
+	})
+	defer ln.Close()
+	c, err := Dial("tcp", ln.Addr().String(), DialProtocol(proto))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var events []Event
+	err = ListenKeyspace(ctx, c, func(e Event) {
+		events = append(events, e)
+		if len(events) == 2 {
+			cancel()
+		}
+	}, KeyspaceConfig("KEA"), KeyspaceDB(3), KeyspaceKeys("user:*"), KeyspaceOps("del", "expired"))
+	if err != context.Canceled {
+		t.Errorf("RESP%d: expected context.Canceled but got %v", proto, err)
+	}
+	if want := []Event{{3, "user:2", "del"}, {3, "user:1", "expired"}}; !reflect.DeepEqual(events, want) {
+		t.Errorf("RESP%d: expected %v but got %v", proto, want, events)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"CONFIG SET notify-keyspace-events KEA", "PSUBSCRIBE __keyspace@3__:user:*"}; !reflect.DeepEqual(cmds, want) {
+		t.Errorf("RESP%d: expected %v but got %v", proto, want, cmds)
+	}
+}
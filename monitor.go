@@ -0,0 +1,199 @@
+package resp3
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidMonitorLine is returned when a line of MONITOR can't be parsed.
+var ErrInvalidMonitorLine = errors.New("resp: invalid MONITOR line")
+
+// MonitorLine is a command executed by the server, as reported by MONITOR.
+type MonitorLine struct {
+	Time time.Time
+	DB   int
+	// Addr is the address of the client, like "127.0.0.1:60866" or "unix:/tmp/redis.sock",
+	// or "lua" for the commands of scripts.
+	Addr string
+	Args []string
+}
+
+// ParseMonitorLine parses a line of MONITOR, like:
+//
+//	1339518083.107412 [0 127.0.0.1:60866] "keys" "*"
+//
+// The arguments are quoted and escaped like sdscatrepr of Redis.
+func ParseMonitorLine(line string) (*MonitorLine, error) {
+	i := strings.Index(line, " [")
+	j := strings.Index(line, "] ")
+	if i < 0 || j < i {
+		return nil, ErrInvalidMonitorLine
+	}
+	ts, err := parseMonitorTime(line[:i])
+	if err != nil {
+		return nil, err
+	}
+	client := line[i+2 : j]
+	k := strings.IndexByte(client, ' ')
+	if k < 0 {
+		return nil, ErrInvalidMonitorLine
+	}
+	db, err := strconv.Atoi(client[:k])
+	if err != nil {
+		return nil, ErrInvalidMonitorLine
+	}
+	args, err := unquoteArgs(line[j+2:])
+	if err != nil {
+		return nil, err
+	}
+	return &MonitorLine{Time: ts, DB: db, Addr: client[k+1:], Args: args}, nil
+}
+
+// parseMonitorTime parses the seconds with the fraction, which has 6 digits.
+func parseMonitorTime(s string) (time.Time, error) {
+	sec, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		sec, frac = s[:i], s[i+1:]
+	}
+	if len(frac) > 9 {
+		frac = frac[:9]
+	}
+	frac += strings.Repeat("0", 9-len(frac))
+	n, err1 := strconv.ParseInt(sec, 10, 64)
+	nsec, err2 := strconv.ParseInt(frac, 10, 64)
+	if err1 != nil || err2 != nil {
+		return time.Time{}, ErrInvalidMonitorLine
+	}
+	return time.Unix(n, nsec), nil
+}
+
+// unquoteArgs parses the arguments separated by spaces, each one is in double quotes.
+// The escapes are \\, \", \n, \r, \t, \a, \b and \xHH.
+func unquoteArgs(s string) ([]string, error) {
+	var args []string
+	for i := 0; i < len(s); {
+		if s[i] == ' ' {
+			i++
+			continue
+		}
+		if s[i] != '"' {
+			return nil, ErrInvalidMonitorLine
+		}
+		i++
+
+		var arg []byte
+		for {
+			if i >= len(s) {
+				return nil, ErrInvalidMonitorLine
+			}
+			c := s[i]
+			i++
+			if c == '"' {
+				break
+			}
+			if c != '\\' {
+				arg = append(arg, c)
+				continue
+			}
+			if i >= len(s) {
+				return nil, ErrInvalidMonitorLine
+			}
+			c = s[i]
+			i++
+			switch c {
+			case 'n':
+				arg = append(arg, '\n')
+			case 'r':
+				arg = append(arg, '\r')
+			case 't':
+				arg = append(arg, '\t')
+			case 'a':
+				arg = append(arg, '\a')
+			case 'b':
+				arg = append(arg, '\b')
+			case 'x':
+				if i+2 > len(s) {
+					return nil, ErrInvalidMonitorLine
+				}
+				b, err := strconv.ParseUint(s[i:i+2], 16, 8)
+				if err != nil {
+					return nil, ErrInvalidMonitorLine
+				}
+				arg = append(arg, byte(b))
+				i += 2
+			default:
+				arg = append(arg, c)
+			}
+		}
+		args = append(args, string(arg))
+	}
+	return args, nil
+}
+
+// Monitor streams the commands executed by the server, by MONITOR on a connection.
+//
+//	m, err := resp3.NewMonitor(ctx, conn)
+//	if err != nil {
+//		...
+//	}
+//	for line := range m.Lines() {
+//		fmt.Println(line.Addr, line.Args)
+//	}
+//	err = m.Err()
+type Monitor struct {
+	c     *Conn
+	lines chan *MonitorLine
+	err   error
+}
+
+// NewMonitor sends MONITOR on the connection and streams the lines until the context is done,
+// the connection is broken or a line can't be parsed. The connection is closed then,
+// it can't be used for other commands after MONITOR.
+func NewMonitor(ctx context.Context, c *Conn) (*Monitor, error) {
+	if _, err := c.DoContext(ctx, "MONITOR"); err != nil {
+		return nil, err
+	}
+	m := &Monitor{c: c, lines: make(chan *MonitorLine, 64)}
+	go m.run(ctx)
+	return m, nil
+}
+
+func (m *Monitor) run(ctx context.Context) {
+	defer close(m.lines)
+	defer m.c.Close()
+	for {
+		v, err := m.c.ReceiveContext(ctx)
+		if err != nil {
+			m.err = err
+			return
+		}
+		if v.Type != TypeSimpleString {
+			continue
+		}
+		line, err := ParseMonitorLine(v.Str)
+		if err != nil {
+			m.err = err
+			return
+		}
+		select {
+		case m.lines <- line:
+		case <-ctx.Done():
+			m.err = ctx.Err()
+			return
+		}
+	}
+}
+
+// Lines returns the channel of the lines, it's closed when the monitor stops.
+func (m *Monitor) Lines() <-chan *MonitorLine {
+	return m.lines
+}
+
+// Err returns the error which stopped the monitor, like the error of the context.
+// It's only valid after the channel of Lines is closed.
+func (m *Monitor) Err() error {
+	return m.err
+}
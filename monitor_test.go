@@ -0,0 +1,77 @@
+package resp3
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseMonitorLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want *MonitorLine
+	}{
+		{`1339518083.107412 [0 127.0.0.1:60866] "keys" "*"`,
+			&MonitorLine{time.Unix(1339518083, 107412000), 0, "127.0.0.1:60866", []string{"keys", "*"}}},
+		{`1339518083.000001 [12 unix:/tmp/redis.sock] "set" "a b" "x\"y\\z\r\n\x00\xff"`,
+			&MonitorLine{time.Unix(1339518083, 1000), 12, "unix:/tmp/redis.sock", []string{"set", "a b", "x\"y\\z\r\n\x00\xff"}}},
+		{`1339518083.5 [0 lua] "get" ""`,
+			&MonitorLine{time.Unix(1339518083, 500000000), 0, "lua", []string{"get", ""}}},
+		{`1339518083.107412 [0 [::1]:6379] "ping"`,
+			&MonitorLine{time.Unix(1339518083, 107412000), 0, "[::1]:6379", []string{"ping"}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseMonitorLine(tt.line)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %+v but got %+v, %v", tt.line, tt.want, got, err)
+		}
+	}
+
+	for _, line := range []string{
+		`OK`,
+		`x.1 [0 lua] "get"`,
+		`1.1 [a lua] "get"`,
+		`1.1 [0 lua] get`,
+		`1.1 [0 lua] "get`,
+		`1.1 [0 lua] "get\x4"`,
+	} {
+		if _, err := ParseMonitorLine(line); err != ErrInvalidMonitorLine {
+			t.Errorf("%s: expected ErrInvalidMonitorLine but got %v", line, err)
+		}
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	ln := serveFake(t, func(args []string, write func(v *Value)) *Value {
+		write(NewSimpleStringValue("OK"))
+		write(NewSimpleStringValue(`1.000001 [0 127.0.0.1:1] "set" "a" "1"`))
+		write(NewSimpleStringValue(`2.000001 [0 lua] "get" "a"`))
+		return nil
+	})
+	defer ln.Close()
+	c, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m, err := NewMonitor(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var addrs []string
+	for line := range m.Lines() {
+		addrs = append(addrs, line.Addr)
+		if len(addrs) == 2 {
+			cancel()
+		}
+	}
+	if m.Err() != context.Canceled || !reflect.DeepEqual(addrs, []string{"127.0.0.1:1", "lua"}) {
+		t.Errorf("unexpected %v, %v", addrs, m.Err())
+	}
+	if c.Err() == nil {
+		t.Errorf("expected the connection to be closed")
+	}
+}
@@ -0,0 +1,282 @@
+package resp3
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPoolClosed is returned by Get after the pool is closed.
+var ErrPoolClosed = errors.New("resp: pool closed")
+
+// resetTimeout is how long Put waits for the pending replies of a connection.
+const resetTimeout = time.Second
+
+// Pool is a pool of connections.
+//
+// Connections are reset when they are put back: pending replies are received,
+// and connections which subscribed, turned on client side caching, started a transaction or selected another database
+// are reset with RESET. Broken connections are closed.
+type Pool struct {
+	// Dial creates a connection.
+	Dial func(ctx context.Context) (*Conn, error)
+
+	// MinIdle is the number of idle connections kept open in the background.
+	MinIdle int
+	// MaxIdle is the maximum number of idle connections, 2 if it's 0.
+	MaxIdle int
+	// MaxActive is the maximum number of connections returned by Get and not put back yet, no limit if it's 0.
+	// Get waits for a connection to be put back when the limit is reached.
+	MaxActive int
+	// IdleTimeout closes connections which are idle for longer, 0 keeps them.
+	IdleTimeout time.Duration
+	// MaxLifetime closes connections which are older, 0 keeps them.
+	MaxLifetime time.Duration
+	// PingAfterIdle makes Get check a connection with PING if it's idle for longer, 0 checks every connection.
+	// It's disabled if it's negative.
+	PingAfterIdle time.Duration
+
+	once   sync.Once
+	sem    chan struct{}
+	stop   chan struct{}
+	mu     sync.Mutex
+	idle   []*Conn // the most recently used at the end
+	active int
+	closed bool
+}
+
+// PoolStats are the statistics of a pool.
+type PoolStats struct {
+	Active int // connections returned by Get and not put back yet
+	Idle   int
+}
+
+// NewPool returns a pool of connections to the address.
+func NewPool(address string, opts ...DialOption) *Pool {
+	return &Pool{
+		Dial: func(ctx context.Context) (*Conn, error) {
+			return DialContext(ctx, "tcp", address, opts...)
+		},
+	}
+}
+
+func (p *Pool) init() {
+	if p.MaxActive > 0 {
+		p.sem = make(chan struct{}, p.MaxActive)
+	}
+	p.stop = make(chan struct{})
+	if p.MinIdle > 0 || p.IdleTimeout > 0 || p.MaxLifetime > 0 {
+		go p.maintain()
+	}
+}
+
+// Get returns an idle connection or a new one. It waits for the context if MaxActive connections are in use.
+func (p *Pool) Get(ctx context.Context) (*Conn, error) {
+	p.once.Do(p.init)
+
+	if p.sem != nil {
+		select {
+		case p.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	c, err := p.get(ctx)
+	if err != nil {
+		p.release()
+		return nil, err
+	}
+	p.mu.Lock()
+	p.active++
+	p.mu.Unlock()
+	return c, nil
+}
+
+func (p *Pool) get(ctx context.Context) (*Conn, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrPoolClosed
+		}
+		var c *Conn
+		if n := len(p.idle); n > 0 {
+			c = p.idle[n-1]
+			p.idle = p.idle[:n-1]
+		}
+		p.mu.Unlock()
+
+		if c == nil {
+			return p.Dial(ctx)
+		}
+		if p.expired(c, time.Now()) {
+			c.Close()
+			continue
+		}
+		if p.PingAfterIdle >= 0 && time.Since(c.usedAt) >= p.PingAfterIdle {
+			if _, err := c.DoContext(ctx, "PING"); err != nil {
+				if !isContextError(ctx, err) {
+					c.Close()
+					continue
+				}
+				if c.Err() == nil {
+					// the connection isn't known to be broken, the reply is received when it's reset
+					go p.putIdle(c)
+				} else {
+					c.Close()
+				}
+				return nil, err
+			}
+		}
+		return c, nil
+	}
+}
+
+// Put puts a connection returned by Get back to the pool.
+func (p *Pool) Put(c *Conn) {
+	p.mu.Lock()
+	p.active--
+	p.mu.Unlock()
+	p.release()
+	p.putIdle(c)
+}
+
+// putIdle resets a connection and adds it to the idle connections.
+func (p *Pool) putIdle(c *Conn) {
+	if c.reset(resetTimeout) != nil {
+		c.Close()
+		return
+	}
+	c.OnPush = nil
+	c.usedAt = time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed || len(p.idle) >= p.maxIdle() || p.expired(c, c.usedAt) {
+		c.Close()
+		return
+	}
+	p.idle = append(p.idle, c)
+}
+
+// DoContext sends a command on a connection of the pool, which is put back after the reply is received.
+func (p *Pool) DoContext(ctx context.Context, args ...string) (*Value, error) {
+	c, err := p.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Put(c)
+	return c.DoContext(ctx, args...)
+}
+
+func (p *Pool) release() {
+	if p.sem != nil {
+		<-p.sem
+	}
+}
+
+// Stats returns the statistics of the pool.
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PoolStats{Active: p.active, Idle: len(p.idle)}
+}
+
+// Close closes the idle connections. Connections in use are closed when they are put back.
+func (p *Pool) Close() error {
+	p.once.Do(p.init)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	close(p.stop)
+	for _, c := range p.idle {
+		c.Close()
+	}
+	p.idle = nil
+	return nil
+}
+
+func (p *Pool) maxIdle() int {
+	if p.MaxIdle == 0 {
+		return 2
+	}
+	return p.MaxIdle
+}
+
+func (p *Pool) expired(c *Conn, now time.Time) bool {
+	return c.Err() != nil ||
+		(p.IdleTimeout > 0 && now.Sub(c.usedAt) >= p.IdleTimeout) ||
+		(p.MaxLifetime > 0 && now.Sub(c.createdAt) >= p.MaxLifetime)
+}
+
+// maintain closes expired idle connections and opens connections up to MinIdle.
+func (p *Pool) maintain() {
+	interval := time.Minute
+	for _, d := range []time.Duration{p.IdleTimeout / 2, p.MaxLifetime / 2} {
+		if d > 0 && d < interval {
+			interval = d
+		}
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.evict()
+		p.fill()
+		select {
+		case <-ticker.C:
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+func (p *Pool) evict() {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	idle := p.idle[:0]
+	for _, c := range p.idle {
+		if p.expired(c, now) {
+			c.Close()
+		} else {
+			idle = append(idle, c)
+		}
+	}
+	p.idle = idle
+}
+
+func (p *Pool) fill() {
+	for {
+		p.mu.Lock()
+		n := len(p.idle)
+		p.mu.Unlock()
+		if n >= p.MinIdle || n >= p.maxIdle() {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		c, err := p.Dial(ctx)
+		cancel()
+		if err != nil {
+			return
+		}
+		c.usedAt = time.Now()
+
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			c.Close()
+			return
+		}
+		// the new connection is the least recently used one
+		p.idle = append([]*Conn{c}, p.idle...)
+		p.mu.Unlock()
+	}
+}
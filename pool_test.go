@@ -0,0 +1,193 @@
+package resp3
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestPool(t *testing.T) (*Pool, *int32, func()) {
+	ln := fakeUpstream(t)
+	p := NewPool(ln.Addr().String())
+	dial := p.Dial
+	var dials int32
+	p.Dial = func(ctx context.Context) (*Conn, error) {
+		atomic.AddInt32(&dials, 1)
+		return dial(ctx)
+	}
+	return p, &dials, func() {
+		p.Close()
+		ln.Close()
+	}
+}
+
+func TestPool(t *testing.T) {
+	p, dials, done := newTestPool(t)
+	defer done()
+	ctx := context.Background()
+
+	c1, err := p.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := p.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := p.Stats(); s.Active != 2 || s.Idle != 0 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	p.Put(c1)
+	p.Put(c2)
+	if s := p.Stats(); s.Active != 0 || s.Idle != 2 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+
+	// the most recently used connection is reused
+	c, err := p.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != c2 || atomic.LoadInt32(dials) != 2 {
+		t.Errorf("expected the idle connection")
+	}
+
+	// leave state behind
+	c.Do("CLIENT", "TRACKING", "on")
+	c.Do("SELECT", "2")
+	c.Send("GET", "a")
+	c.Flush()
+	p.Put(c)
+	c, _ = p.Get(ctx)
+	if c != c2 || c.pending != 0 || c.dirty() {
+		t.Errorf("expected a reset connection but got %+v", c)
+	}
+
+	// a broken connection is replaced
+	c.NetConn().Close()
+	p.Put(c)
+	c, err = p.Get(ctx)
+	if err != nil || c == c2 {
+		t.Errorf("expected another connection but got %v", err)
+	}
+	p.Put(c)
+
+	p.Close()
+	if _, err := p.Get(ctx); err != ErrPoolClosed {
+		t.Errorf("expected ErrPoolClosed but got %v", err)
+	}
+}
+
+func TestPool_MaxActive(t *testing.T) {
+	p, _, done := newTestPool(t)
+	defer done()
+	p.MaxActive = 1
+
+	c, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded but got %v", err)
+	}
+
+	time.AfterFunc(20*time.Millisecond, func() { p.Put(c) })
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	c2, err := p.Get(ctx)
+	if err != nil || c2 != c {
+		t.Errorf("expected the connection put back but got %v", err)
+	}
+}
+
+func TestPool_Health(t *testing.T) {
+	p, dials, done := newTestPool(t)
+	defer done()
+	p.IdleTimeout = 50 * time.Millisecond
+	p.MinIdle = 2
+	ctx := context.Background()
+
+	// idle connections are opened in the background
+	c, err := p.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Put(c)
+	deadline := time.Now().Add(time.Second)
+	for p.Stats().Idle < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if p.Stats().Idle != 2 {
+		t.Errorf("expected 2 idle connections but got %+v", p.Stats())
+	}
+
+	// PING finds a dead connection
+	c, _ = p.Get(ctx)
+	p.Put(c)
+	c.NetConn().Close()
+	c2, err := p.Get(ctx)
+	if err != nil || c2 == c {
+		t.Errorf("expected a healthy connection but got %v", err)
+	}
+	p.Put(c2)
+
+	// expired connections are replaced
+	n := atomic.LoadInt32(dials)
+	time.Sleep(80 * time.Millisecond)
+	c, _ = p.Get(ctx)
+	p.Put(c)
+	if atomic.LoadInt32(dials) == n {
+		t.Errorf("expected new connections")
+	}
+}
+
+func TestPool_DoContext(t *testing.T) {
+	p, _, done := newTestPool(t)
+	defer done()
+
+	var d Doer = p
+	v, err := d.DoContext(context.Background(), "PING")
+	if err != nil || v.Str != "PONG" {
+		t.Errorf("expected PONG but got %v, %v", v, err)
+	}
+	if s := p.Stats(); s.Active != 0 || s.Idle != 1 {
+		t.Errorf("expected the connection to be put back but got %+v", s)
+	}
+}
+
+func TestPool_HealthCanceled(t *testing.T) {
+	ln := serveFake(t, func(args []string, write func(v *Value)) *Value {
+		time.Sleep(50 * time.Millisecond)
+		return NewSimpleStringValue("PONG")
+	})
+	defer ln.Close()
+	p := NewPool(ln.Addr().String())
+	p.PingAfterIdle = 0
+	defer p.Close()
+
+	c, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Put(c)
+
+	// the connection is put back if PING is interrupted by the context
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded but got %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for p.Stats().Idle != 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	c2, err := p.Get(context.Background())
+	if err != nil || c2 != c {
+		t.Errorf("expected the same connection but got %v", err)
+	}
+	p.Put(c2)
+}
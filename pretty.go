@@ -0,0 +1,160 @@
+package resp3
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Pretty returns the human-readable form of the value as redis-cli prints RESP3 replies:
+//
+//	1# "key-popularity" => 1) (integer) 1
+//	                       2) (double) 0.5
+//
+// Strings are quoted and escaped, verbatim strings are printed as they are,
+// aggregate entries are numbered with ')' for arrays and pushes, '~' for sets and '#' for maps,
+// and attributes are printed before the value with a '|' prefix.
+func (r *Value) Pretty() string {
+	buf := new(strings.Builder)
+	r.pretty(buf, "")
+	return buf.String()
+}
+
+func (r *Value) pretty(buf *strings.Builder, prefix string) {
+	if r.Attrs != nil && r.Attrs.Size() > 0 {
+		buf.WriteString("| ")
+		keys, values := mapEntries(r.Attrs)
+		prettyAggregate(buf, prefix+"  ", TypeMap, append(keys, values...), len(keys))
+		buf.WriteString(prefix)
+	}
+
+	switch r.Type {
+	case TypeSimpleString:
+		buf.WriteString(r.Str)
+	case TypeBlobString:
+		if r.NullBulkString {
+			buf.WriteString("(nil)")
+		} else {
+			buf.WriteString(quote(r.Str))
+		}
+	case TypeVerbatimString:
+		buf.WriteString(r.Str)
+	case TypeSimpleError, TypeBlobError:
+		buf.WriteString("(error) ")
+		buf.WriteString(r.Err)
+	case TypeNumber:
+		buf.WriteString("(integer) ")
+		buf.WriteString(strconv.FormatInt(r.Integer, 10))
+	case TypeDouble:
+		buf.WriteString("(double) ")
+		buf.WriteString(formatDouble(r.Double))
+	case TypeBigNumber:
+		buf.WriteString("(big number) ")
+		buf.WriteString(r.BigInt.String())
+	case TypeNull:
+		buf.WriteString("(nil)")
+	case TypeBoolean:
+		if r.Boolean {
+			buf.WriteString("(true)")
+		} else {
+			buf.WriteString("(false)")
+		}
+	case TypeArray, TypeSet, TypePush:
+		if r.NullArray {
+			buf.WriteString("(nil)")
+			break
+		}
+		prettyAggregate(buf, prefix, r.Type, r.Elems, len(r.Elems))
+		return
+	case TypeMap:
+		keys, values := mapEntries(r.KV)
+		prettyAggregate(buf, prefix, TypeMap, append(keys, values...), len(keys))
+		return
+	}
+
+	buf.WriteByte('\n')
+}
+
+// prettyAggregate prints n entries of an aggregate type.
+// For maps elems contains the n keys followed by the n values.
+func prettyAggregate(buf *strings.Builder, prefix string, t byte, elems []*Value, n int) {
+	if n == 0 {
+		switch t {
+		case TypeArray:
+			buf.WriteString("(empty array)\n")
+		case TypeMap:
+			buf.WriteString("(empty hash)\n")
+		case TypeSet:
+			buf.WriteString("(empty set)\n")
+		case TypePush:
+			buf.WriteString("(empty push)\n")
+		}
+		return
+	}
+
+	mark := ")"
+	switch t {
+	case TypeSet:
+		mark = "~"
+	case TypeMap:
+		mark = "#"
+	}
+
+	idxlen := len(strconv.Itoa(n))
+	childPrefix := prefix + strings.Repeat(" ", idxlen+2)
+	for i := 0; i < n; i++ {
+		// the first entry follows the index printed by the parent
+		if i > 0 {
+			buf.WriteString(prefix)
+		}
+		idx := strconv.Itoa(i + 1)
+		buf.WriteString(strings.Repeat(" ", idxlen-len(idx)))
+		buf.WriteString(idx)
+		buf.WriteString(mark)
+		buf.WriteByte(' ')
+
+		if t != TypeMap {
+			elems[i].pretty(buf, childPrefix)
+			continue
+		}
+
+		key := new(strings.Builder)
+		elems[i].pretty(key, childPrefix)
+		buf.WriteString(strings.TrimSuffix(key.String(), "\n"))
+		buf.WriteString(" => ")
+		elems[n+i].pretty(buf, childPrefix)
+	}
+}
+
+// quote quotes a string as redis-cli does, non printable bytes are escaped as \xHH.
+func quote(s string) string {
+	buf := new(strings.Builder)
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\', '"':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\a':
+			buf.WriteString(`\a`)
+		case '\b':
+			buf.WriteString(`\b`)
+		default:
+			if c >= 0x20 && c < 0x7f {
+				buf.WriteByte(c)
+			} else {
+				buf.WriteString(`\x`)
+				buf.WriteString(strconv.FormatUint(uint64(c)>>4, 16))
+				buf.WriteString(strconv.FormatUint(uint64(c)&0xf, 16))
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
@@ -0,0 +1,45 @@
+package resp3
+
+import (
+	"testing"
+)
+
+func TestValue_Pretty(t *testing.T) {
+	var cases = map[string]string{
+		"+OK\r\n":                     "OK\n",
+		"$12\r\nhello\x00\"world\r\n": "\"hello\\x00\\\"world\"\n",
+		"$-1\r\n":                     "(nil)\n",
+		"_\r\n":                       "(nil)\n",
+		":1234\r\n":                   "(integer) 1234\n",
+		",1.23\r\n":                   "(double) 1.23\n",
+		"#f\r\n":                      "(false)\n",
+		"(3492890328409238509324850943850943825024385\r\n": "(big number) 3492890328409238509324850943850943825024385\n",
+		"-ERR this is the error description\r\n":           "(error) ERR this is the error description\n",
+		"=15\r\ntxt:Some string\r\n":                       "Some string\n",
+		"*0\r\n":                                           "(empty array)\n",
+		"~2\r\n+orange\r\n+apple\r\n":                      "1~ orange\n2~ apple\n",
+		"%2\r\n+first\r\n:1\r\n+second\r\n*2\r\n:2\r\n:3\r\n": "1# first => (integer) 1\n" +
+			"2# second => 1) (integer) 2\n" +
+			"   2) (integer) 3\n",
+		"*10\r\n:1\r\n:2\r\n:3\r\n:4\r\n:5\r\n:6\r\n:7\r\n:8\r\n:9\r\n*2\r\n:1\r\n:2\r\n": " 1) (integer) 1\n" +
+			" 2) (integer) 2\n 3) (integer) 3\n 4) (integer) 4\n 5) (integer) 5\n" +
+			" 6) (integer) 6\n 7) (integer) 7\n 8) (integer) 8\n 9) (integer) 9\n" +
+			"10) 1) (integer) 1\n" +
+			"    2) (integer) 2\n",
+		"|1\r\n+key-popularity\r\n%2\r\n$1\r\na\r\n,0.1923\r\n$1\r\nb\r\n,0.0012\r\n*2\r\n:2039123\r\n:9543892\r\n": "| 1# key-popularity => 1# \"a\" => (double) 0.1923\n" +
+			"     2# \"b\" => (double) 0.0012\n" +
+			"1) (integer) 2039123\n" +
+			"2) (integer) 9543892\n",
+	}
+
+	for resp, expected := range cases {
+		v, err := FromString(resp)
+		if err != nil {
+			t.Errorf("failed to parse %q: %v", resp, err)
+			continue
+		}
+		if s := v.Pretty(); s != expected {
+			t.Errorf("expected %q but got %q", expected, s)
+		}
+	}
+}
@@ -0,0 +1,602 @@
+package resp3
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emirpasic/gods/maps/linkedhashmap"
+)
+
+// ErrProxyClosed is returned by Serve and ServeConn after the proxy is closed.
+var ErrProxyClosed = errors.New("resp: proxy closed")
+
+// Middleware inspects and rewrites the traffic of a proxy session. Nil hooks are skipped.
+type Middleware struct {
+	// Command is called for every command of the client.
+	// It returns the command to forward, which may be rewritten,
+	// or a reply which is sent to the client instead of forwarding the command, for example an error to reject it.
+	Command func(s *ProxySession, cmd *Value) (forward *Value, reply *Value)
+	// Reply is called for the reply of every forwarded command and returns the reply sent to the client.
+	Reply func(s *ProxySession, cmd, reply *Value) *Value
+	// Push is called for every push message and returns the message sent to the client, nil drops it.
+	Push func(s *ProxySession, push *Value) *Value
+}
+
+// Proxy is a transparent RESP3 proxy. Every client gets its own upstream connection.
+//
+// The proxy talks RESP3 with the upstream if it can, and replies to every client in the protocol version
+// the client negotiated with HELLO, so RESP2 clients get downgraded replies.
+// Frames are forwarded as they are read, without parsing, if no middleware needs them.
+type Proxy struct {
+	// Dial connects to the upstream server.
+	Dial func() (net.Conn, error)
+	// Middlewares are called in order for commands and in reverse order for replies and push messages.
+	Middlewares []Middleware
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+}
+
+// NewProxy returns a proxy to the upstream address.
+func NewProxy(upstream string, middlewares ...Middleware) *Proxy {
+	return &Proxy{
+		Dial: func() (net.Conn, error) {
+			return net.DialTimeout("tcp", upstream, 5*time.Second)
+		},
+		Middlewares: middlewares,
+	}
+}
+
+// Serve accepts clients on ln and serves each of them in a new goroutine.
+func (p *Proxy) Serve(ln net.Listener) error {
+	if !p.track(ln, nil) {
+		ln.Close()
+		return ErrProxyClosed
+	}
+	defer p.untrack(ln, nil)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if p.isClosed() {
+				return ErrProxyClosed
+			}
+			return err
+		}
+		go p.ServeConn(conn)
+	}
+}
+
+// ServeConn serves a client connection until the client or the upstream closes it.
+func (p *Proxy) ServeConn(client net.Conn) error {
+	defer client.Close()
+
+	upstream, err := p.Dial()
+	if err != nil {
+		return err
+	}
+	defer upstream.Close()
+
+	if !p.track(nil, client) {
+		return ErrProxyClosed
+	}
+	defer p.untrack(nil, client)
+
+	s := &ProxySession{
+		proxy:    p,
+		client:   client,
+		upstream: upstream,
+		cr:       NewReader(client),
+		cw:       NewWriter(client),
+		ur:       NewReader(upstream),
+		uw:       NewWriter(upstream),
+		proto:    2,
+	}
+	if err := s.handshake(); err != nil {
+		return err
+	}
+
+	// the first error ends the session, closing the connections stops the other direction
+	errc := make(chan error, 2)
+	go func() {
+		errc <- s.relayReplies()
+	}()
+	go func() {
+		errc <- s.relayCommands()
+	}()
+	err = <-errc
+	client.Close()
+	upstream.Close()
+	<-errc
+
+	if p.isClosed() {
+		return ErrProxyClosed
+	}
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// Close closes the listeners and all client connections.
+func (p *Proxy) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for ln := range p.listeners {
+		ln.Close()
+	}
+	for conn := range p.conns {
+		conn.Close()
+	}
+	return nil
+}
+
+func (p *Proxy) track(ln net.Listener, conn net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	if p.listeners == nil {
+		p.listeners = make(map[net.Listener]struct{})
+		p.conns = make(map[net.Conn]struct{})
+	}
+	if ln != nil {
+		p.listeners[ln] = struct{}{}
+	}
+	if conn != nil {
+		p.conns[conn] = struct{}{}
+	}
+	return true
+}
+
+func (p *Proxy) untrack(ln net.Listener, conn net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.listeners, ln)
+	delete(p.conns, conn)
+}
+
+func (p *Proxy) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// ProxySession is a client connection of a proxy and its upstream connection.
+type ProxySession struct {
+	proxy            *Proxy
+	client, upstream net.Conn
+	cr, ur           *Reader
+	cw, uw           *Writer
+
+	// upgrade is false if the upstream doesn't support RESP3.
+	upgrade bool
+
+	mu            sync.Mutex
+	proto         int // protocol version of the client
+	upstreamProto int
+	pending       []*proxyCall
+	subscriptions map[string]map[string]bool // channels, patterns and shard channels by SUBSCRIBE, PSUBSCRIBE and SSUBSCRIBE
+
+	// subscribed is set by a subscribe command to a RESP2 upstream, whose messages are arrays.
+	subscribed bool
+}
+
+// proxyCall is a command waiting for its reply, or a reply of the proxy waiting for its turn.
+type proxyCall struct {
+	name  string
+	cmd   *Value
+	local *Value
+	hello int // the protocol version requested by HELLO
+	sent  int // the protocol version sent to the upstream by HELLO
+}
+
+// ClientAddr returns the address of the client.
+func (s *ProxySession) ClientAddr() net.Addr {
+	return s.client.RemoteAddr()
+}
+
+// Proto returns the protocol version of the client, 2 or 3.
+func (s *ProxySession) Proto() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.proto
+}
+
+// handshake switches the upstream to RESP3.
+// The upstream may refuse because it requires authentication, then the client's HELLO is upgraded later.
+func (s *ProxySession) handshake() error {
+	s.upstreamProto = 2
+	if err := s.uw.WriteCommand("HELLO", "3"); err != nil {
+		return err
+	}
+	v, _, err := s.ur.ReadValue()
+	if err != nil {
+		return err
+	}
+	switch {
+	case v.Type != TypeSimpleError && v.Type != TypeBlobError:
+		s.upstreamProto = 3
+		s.upgrade = true
+	case strings.HasPrefix(v.Err, "NOAUTH"):
+		s.upgrade = true
+	}
+	return nil
+}
+
+// relayCommands forwards the commands of the client.
+func (s *ProxySession) relayCommands() error {
+	hasHooks := false
+	for _, m := range s.proxy.Middlewares {
+		if m.Command != nil || m.Reply != nil {
+			hasHooks = true
+		}
+	}
+
+	for {
+		raw, err := s.cr.ReadRaw()
+		if err != nil {
+			return err
+		}
+		if len(raw) > 0 && raw[0] != TypeArray {
+			raw = inlineCommand(raw)
+		}
+		name := rawCommandName(raw)
+
+		call := &proxyCall{}
+		if hasHooks || name == "HELLO" {
+			if call.cmd, err = FromString(string(raw)); err != nil {
+				return err
+			}
+			if name == "HELLO" {
+				s.hello(call)
+			}
+			for _, m := range s.proxy.Middlewares {
+				if m.Command == nil {
+					continue
+				}
+				call.cmd, call.local = m.Command(s, call.cmd)
+				if call.local != nil {
+					break
+				}
+				if call.cmd == nil {
+					call.local = &Value{Type: TypeSimpleError, Err: "ERR command rejected by proxy"}
+					break
+				}
+			}
+			if call.local == nil {
+				raw = []byte(call.cmd.ToRESP3String())
+				name = commandNameOf(call.cmd)
+			}
+		}
+
+		if call.local != nil {
+			if err := s.replyLocal(call); err != nil {
+				return err
+			}
+		} else if err := s.forward(call, name, raw); err != nil {
+			return err
+		}
+
+		// flush when the client waits for the replies, not in the middle of a pipeline
+		if s.cr.Buffered() == 0 {
+			if err := s.uw.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// forward sends a command to the upstream.
+func (s *ProxySession) forward(call *proxyCall, name string, raw []byte) error {
+	call.name = name
+	s.mu.Lock()
+	if !isSubscribeCommand(name) {
+		if name == "RESET" {
+			s.subscriptions = nil
+			s.subscribed = false
+		}
+		s.pending = append(s.pending, call)
+	} else if n := s.subscribe(name, raw); s.upstreamProto == 2 {
+		if !strings.Contains(name, "UNSUBSCRIBE") {
+			s.subscribed = true
+		}
+		// RESP2 replies to every channel with an array, RESP3 with push messages
+		for i := 0; i < n; i++ {
+			s.pending = append(s.pending, call)
+		}
+	}
+	s.mu.Unlock()
+
+	_, err := s.uw.Write(raw)
+	return err
+}
+
+// subscribe tracks the subscriptions of a subscribe command and returns the number of its replies:
+// one for every channel, or for every subscribed channel if all of them are unsubscribed, at least one.
+func (s *ProxySession) subscribe(name string, raw []byte) int {
+	kind := strings.Replace(name, "UNSUBSCRIBE", "SUBSCRIBE", 1)
+	cmd, err := FromString(string(raw))
+	if err != nil || len(cmd.Elems) < 2 {
+		// UNSUBSCRIBE without channels is confirmed for every subscribed channel,
+		// SUBSCRIBE without channels is an error
+		if kind == name || len(s.subscriptions[kind]) == 0 {
+			return 1
+		}
+		n := len(s.subscriptions[kind])
+		delete(s.subscriptions, kind)
+		return n
+	}
+
+	channels := s.subscriptions[kind]
+	if channels == nil {
+		if s.subscriptions == nil {
+			s.subscriptions = make(map[string]map[string]bool)
+		}
+		channels = make(map[string]bool)
+		s.subscriptions[kind] = channels
+	}
+	for _, ch := range cmd.Elems[1:] {
+		if kind == name {
+			channels[ch.Str] = true
+		} else {
+			delete(channels, ch.Str)
+		}
+	}
+	return len(cmd.Elems) - 1
+}
+
+// hello upgrades the protocol version requested by HELLO to RESP3.
+func (s *ProxySession) hello(call *proxyCall) {
+	if len(call.cmd.Elems) < 2 {
+		return
+	}
+	ver, err := strconv.Atoi(call.cmd.Elems[1].Str)
+	if err != nil {
+		return
+	}
+	call.hello, call.sent = ver, ver
+	if s.upgrade && ver == 2 {
+		call.sent = 3
+		elems := append([]*Value(nil), call.cmd.Elems...)
+		elems[1] = NewBlobStringValue("3")
+		call.cmd = NewArrayValue(elems)
+	}
+}
+
+// replyLocal sends a reply of the proxy, after the replies of the commands before it.
+func (s *ProxySession) replyLocal(call *proxyCall) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) > 0 {
+		s.pending = append(s.pending, call)
+		return nil
+	}
+	s.writeValue(call.local)
+	return s.cw.Flush()
+}
+
+// relayReplies forwards the replies and push messages of the upstream.
+func (s *ProxySession) relayReplies() error {
+	hasHooks := false
+	for _, m := range s.proxy.Middlewares {
+		if m.Reply != nil || m.Push != nil {
+			hasHooks = true
+		}
+	}
+
+	for {
+		raw, err := s.ur.ReadRaw()
+		if err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		var call *proxyCall
+		if len(s.pending) > 0 {
+			call = s.pending[0]
+		}
+		subscribed := s.upstreamProto == 2 && s.subscribed
+		parse := hasHooks || subscribed || (s.proto == 2 && s.upstreamProto == 3) || (call != nil && call.hello != 0) || raw[0] == TypeAttribute
+		s.mu.Unlock()
+
+		if !parse {
+			s.mu.Lock()
+			s.cw.Write(raw)
+			if raw[0] != TypePush {
+				s.pop()
+			}
+			err = s.flush()
+			s.mu.Unlock()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		v, err := FromString(string(raw))
+		if err != nil {
+			return err
+		}
+		// messages of a RESP2 upstream are arrays, they don't reply to any command
+		if v.Type == TypePush || (subscribed && isMessageArray(v)) {
+			for i := len(s.proxy.Middlewares) - 1; i >= 0 && v != nil; i-- {
+				if m := s.proxy.Middlewares[i]; m.Push != nil {
+					v = m.Push(s, v)
+				}
+			}
+			s.mu.Lock()
+			if v != nil {
+				s.writeValue(v)
+			}
+			err = s.flush()
+			s.mu.Unlock()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if call != nil && call.cmd != nil {
+			for i := len(s.proxy.Middlewares) - 1; i >= 0; i-- {
+				if m := s.proxy.Middlewares[i]; m.Reply != nil {
+					v = m.Reply(s, call.cmd, v)
+				}
+			}
+		}
+
+		s.mu.Lock()
+		if call != nil && call.hello != 0 && v.Type != TypeSimpleError && v.Type != TypeBlobError {
+			s.proto, s.upstreamProto = call.hello, call.sent
+			if v.Type == TypeMap {
+				v = helloReply(v, call.hello)
+			}
+		}
+		if subscribed && IsPubSubArray(v) && v.Elems[2].Integer == 0 && s.subscriptionCount() == 0 {
+			s.subscribed = false
+		}
+		if call != nil && s.proto == 2 && s.upstreamProto == 3 {
+			v = nullArrayReply(call.name, v)
+		}
+		s.writeValue(v)
+		s.pop()
+		err = s.flush()
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// pop removes the answered command and sends the replies of the proxy which are next.
+func (s *ProxySession) pop() {
+	if len(s.pending) == 0 {
+		return
+	}
+	s.pending = s.pending[1:]
+	for len(s.pending) > 0 && s.pending[0].local != nil {
+		s.writeValue(s.pending[0].local)
+		s.pending = s.pending[1:]
+	}
+}
+
+func (s *ProxySession) flush() error {
+	if s.ur.Buffered() > 0 {
+		return nil
+	}
+	return s.cw.Flush()
+}
+
+func (s *ProxySession) writeValue(v *Value) {
+	if s.proto == 2 {
+		s.cw.WriteString(v.ToRESP2String())
+	} else {
+		s.cw.WriteString(v.ToRESP3String())
+	}
+}
+
+// helloReply sets the protocol version in the reply of HELLO to the version of the client.
+func helloReply(v *Value, proto int) *Value {
+	keys, values := mapEntries(v.KV)
+	rt := &Value{Type: TypeMap, KV: linkedhashmap.New(), Attrs: v.Attrs}
+	for i := range keys {
+		if keys[i].Str == "proto" && values[i].Type == TypeNumber {
+			rt.KV.Put(keys[i], NewNumberValue(int64(proto)))
+		} else {
+			rt.KV.Put(keys[i], values[i])
+		}
+	}
+	return rt
+}
+
+// rawCommandName returns the upper case name of an encoded command without parsing the arguments.
+func rawCommandName(raw []byte) string {
+	i := bytes.Index(raw, CRLFByte)
+	if i < 0 || i+2 >= len(raw) || raw[i+2] != TypeBlobString {
+		return ""
+	}
+	rest := raw[i+2:]
+	j := bytes.Index(rest, CRLFByte)
+	if j < 0 {
+		return ""
+	}
+	n, err := strconv.Atoi(string(rest[1:j]))
+	if err != nil || n < 0 || j+2+n > len(rest) {
+		return ""
+	}
+	return strings.ToUpper(string(rest[j+2 : j+2+n]))
+}
+
+func commandNameOf(cmd *Value) string {
+	if len(cmd.Elems) == 0 {
+		return ""
+	}
+	return strings.ToUpper(cmd.Elems[0].Str)
+}
+
+// inlineCommand converts an inline command, like "PING\r\n" sent by telnet, to an array.
+func inlineCommand(line []byte) []byte {
+	var elems []*Value
+	for _, arg := range strings.Fields(string(line)) {
+		elems = append(elems, NewBlobStringValue(arg))
+	}
+	return []byte(NewArrayValue(elems).ToRESP3String())
+}
+
+// isMessageArray checks if a value is a message of a RESP2 upstream, not a confirmation of a subscribe command.
+func isMessageArray(v *Value) bool {
+	return IsPubSubArray(v) && strings.HasSuffix(v.Elems[0].Str, "message")
+}
+
+// subscriptionCount returns the number of channels, patterns and shard channels subscribed by the client.
+func (s *ProxySession) subscriptionCount() int {
+	n := 0
+	for _, channels := range s.subscriptions {
+		n += len(channels)
+	}
+	return n
+}
+
+// nullArrayCommands reply null arrays to RESP2 clients where RESP3 clients get nulls.
+var nullArrayCommands = map[string]bool{
+	"EXEC": true, "BLPOP": true, "BRPOP": true, "BRPOPLPUSH": true, "BLMOVE": true, "BLMPOP": true, "LMPOP": true,
+	"BZPOPMIN": true, "BZPOPMAX": true, "BZMPOP": true, "ZMPOP": true, "XREAD": true, "XREADGROUP": true,
+}
+
+// nullArrayReply converts the nulls of a reply to null arrays where redis replies null arrays to RESP2 clients,
+// like an aborted EXEC, a timeout of BLPOP or XREAD and the missing members of GEOPOS.
+func nullArrayReply(name string, v *Value) *Value {
+	switch {
+	case v.Type == TypeNull && nullArrayCommands[name]:
+		return &Value{Type: TypeArray, NullArray: true, Attrs: v.Attrs}
+	case v.Type == TypeArray && name == "GEOPOS":
+		rt := *v
+		rt.Elems = make([]*Value, len(v.Elems))
+		for i, e := range v.Elems {
+			if e.Type == TypeNull {
+				e = &Value{Type: TypeArray, NullArray: true, Attrs: e.Attrs}
+			}
+			rt.Elems[i] = e
+		}
+		return &rt
+	}
+	return v
+}
+
+func isSubscribeCommand(name string) bool {
+	switch name {
+	case "SUBSCRIBE", "PSUBSCRIBE", "SSUBSCRIBE", "UNSUBSCRIBE", "PUNSUBSCRIBE", "SUNSUBSCRIBE":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,273 @@
+package resp3
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeUpstream is a tiny RESP3 server: PING, SET, GET, HGETALL, HGET, BLPOP, GEOPOS, SUBSCRIBE, SELECT, CLIENT and RESET.
+// GET sends an invalidation push message before the reply.
+func fakeUpstream(t *testing.T) net.Listener {
+	var mu sync.Mutex
+	data := make(map[string]string)
+	return serveFake(t, func(args []string, write func(v *Value)) *Value {
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			return NewSimpleStringValue("PONG")
+		case "SELECT", "CLIENT":
+			return NewSimpleStringValue("OK")
+		case "RESET":
+			return NewSimpleStringValue("RESET")
+		case "SET":
+			mu.Lock()
+			data[args[1]] = args[2]
+			mu.Unlock()
+			return NewSimpleStringValue("OK")
+		case "GET":
+			write(NewPushValue([]*Value{NewSimpleStringValue("invalidate"), NewArrayValue([]*Value{NewBlobStringValue(args[1])})}))
+			mu.Lock()
+			defer mu.Unlock()
+			return NewBlobStringValue(data[args[1]])
+		case "HGETALL":
+			return mustValue(t, "%1\r\n+f\r\n,1.5\r\n")
+		case "HGET", "BLPOP":
+			return NewNullValue()
+		case "GEOPOS":
+			return mustValue(t, "*2\r\n*2\r\n,1.5\r\n,2.5\r\n_\r\n")
+		case "SUBSCRIBE":
+			write(mustValue(t, ">3\r\n+subscribe\r\n+ch\r\n:1\r\n"))
+			return mustValue(t, ">3\r\n+message\r\n+ch\r\n+hi\r\n")
+		}
+		return &Value{Type: TypeSimpleError, Err: "ERR unknown command"}
+	})
+}
+
+// startProxy serves a proxy to a fake upstream and returns a connected client.
+func startProxy(t *testing.T, middlewares ...Middleware) (net.Conn, func()) {
+	upstream := fakeUpstream(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewProxy(upstream.Addr().String(), middlewares...)
+	go p.Serve(ln)
+
+	conn, err := net.DialTimeout("tcp", ln.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, func() {
+		conn.Close()
+		p.Close()
+		upstream.Close()
+	}
+}
+
+func expectReplies(t *testing.T, r *Reader, expected ...string) {
+	t.Helper()
+	for _, e := range expected {
+		raw, err := r.ReadRaw()
+		if err != nil {
+			t.Fatalf("failed to read: %v", err)
+		}
+		if string(raw) != e {
+			t.Errorf("expected %q but got %q", e, raw)
+		}
+	}
+}
+
+func TestProxy_RESP3(t *testing.T) {
+	conn, done := startProxy(t)
+	defer done()
+	w, r := NewWriter(conn), NewReader(conn)
+
+	w.WriteCommand("HELLO", "3")
+	expectReplies(t, r, "%1\r\n+proto\r\n:3\r\n")
+
+	w.WriteCommand("SET", "a", "1")
+	w.WriteCommand("GET", "a")
+	w.WriteCommand("HGETALL", "h")
+	w.WriteCommand("SUBSCRIBE", "ch")
+	expectReplies(t, r,
+		"+OK\r\n",
+		">2\r\n+invalidate\r\n*1\r\n$1\r\na\r\n",
+		"$1\r\n1\r\n",
+		"%1\r\n+f\r\n,1.5\r\n",
+		">3\r\n+subscribe\r\n+ch\r\n:1\r\n",
+		">3\r\n+message\r\n+ch\r\n+hi\r\n")
+}
+
+func TestProxy_RESP2(t *testing.T) {
+	conn, done := startProxy(t)
+	defer done()
+	w, r := NewWriter(conn), NewReader(conn)
+
+	// inline command and RESP2 by default
+	conn.Write([]byte("HGETALL h\r\n"))
+	expectReplies(t, r, "*2\r\n+f\r\n$3\r\n1.5\r\n")
+
+	w.WriteCommand("HELLO", "2")
+	expectReplies(t, r, "*2\r\n+proto\r\n:2\r\n")
+
+	w.WriteCommand("GET", "a")
+	w.WriteCommand("HGET", "h", "f")
+	w.WriteCommand("BLPOP", "l", "1")
+	w.WriteCommand("GEOPOS", "g", "a", "b")
+	w.WriteCommand("SUBSCRIBE", "ch")
+	expectReplies(t, r,
+		"*2\r\n+invalidate\r\n*1\r\n$1\r\na\r\n",
+		"$0\r\n\r\n",
+		"$-1\r\n",
+		"*-1\r\n",
+		"*2\r\n*2\r\n$3\r\n1.5\r\n$3\r\n2.5\r\n*-1\r\n",
+		"*3\r\n+subscribe\r\n+ch\r\n:1\r\n",
+		"*3\r\n+message\r\n+ch\r\n+hi\r\n")
+}
+
+func TestProxy_Middleware(t *testing.T) {
+	var mu sync.Mutex
+	var audit []string
+	prefix := Middleware{
+		Command: func(s *ProxySession, cmd *Value) (*Value, *Value) {
+			mu.Lock()
+			audit = append(audit, cmd.Elems[0].Str)
+			mu.Unlock()
+			if len(cmd.Elems) > 1 && !strings.EqualFold(cmd.Elems[0].Str, "HELLO") {
+				elems := append([]*Value(nil), cmd.Elems...)
+				elems[1] = NewBlobStringValue("app:" + elems[1].Str)
+				cmd = NewArrayValue(elems)
+			}
+			return cmd, nil
+		},
+	}
+	deny := Middleware{
+		Command: func(s *ProxySession, cmd *Value) (*Value, *Value) {
+			if strings.EqualFold(cmd.Elems[0].Str, "FLUSHALL") {
+				return nil, &Value{Type: TypeSimpleError, Err: "NOPERM flushall is not allowed"}
+			}
+			return cmd, nil
+		},
+		Reply: func(s *ProxySession, cmd, reply *Value) *Value {
+			if strings.EqualFold(cmd.Elems[0].Str, "GET") {
+				reply = NewBlobStringValue(cmd.Elems[1].Str + "=" + reply.Str)
+			}
+			return reply
+		},
+		Push: func(s *ProxySession, push *Value) *Value {
+			return nil
+		},
+	}
+	conn, done := startProxy(t, prefix, deny)
+	defer done()
+	w, r := NewWriter(conn), NewReader(conn)
+
+	w.WriteCommand("HELLO", "3")
+	w.WriteCommand("SET", "a", "1")
+	w.WriteCommand("FLUSHALL")
+	w.WriteCommand("GET", "a")
+	w.WriteCommand("FLUSHALL")
+	r.ReadValue()
+	expectReplies(t, r,
+		"+OK\r\n",
+		"-NOPERM flushall is not allowed\r\n",
+		"$7\r\napp:a=1\r\n",
+		"-NOPERM flushall is not allowed\r\n")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(audit) != 5 {
+		t.Errorf("expected 5 audited commands but got %q", audit)
+	}
+}
+
+func TestProxy_RESP2Upstream(t *testing.T) {
+	// the upstream doesn't support HELLO, and confirms every channel of SUBSCRIBE and UNSUBSCRIBE,
+	// a message of the first channel follows the confirmations of SUBSCRIBE
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstream.Close()
+	go func() {
+		conn, err := upstream.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := NewReader(conn)
+		var channels []string
+		confirm := func(kind, ch string) string {
+			return NewArrayValue([]*Value{NewBlobStringValue(kind), NewBlobStringValue(ch), NewNumberValue(int64(len(channels)))}).ToRESP3String()
+		}
+		for {
+			cmd, _, err := r.ReadValue()
+			if err != nil {
+				return
+			}
+			var reply string
+			switch strings.ToUpper(cmd.Elems[0].Str) {
+			case "SUBSCRIBE":
+				for _, ch := range cmd.Elems[1:] {
+					channels = append(channels, ch.Str)
+					reply += confirm("subscribe", ch.Str)
+				}
+				reply += NewArrayValue([]*Value{NewBlobStringValue("message"), cmd.Elems[1], NewBlobStringValue("hi")}).ToRESP3String()
+			case "UNSUBSCRIBE":
+				for len(channels) > 0 {
+					ch := channels[0]
+					channels = channels[1:]
+					reply += confirm("unsubscribe", ch)
+				}
+			case "PING":
+				reply = "+PONG\r\n"
+			case "LRANGE":
+				reply = "*3\r\n$7\r\nmessage\r\n$1\r\na\r\n$1\r\nb\r\n"
+			default:
+				reply = "-ERR unknown command\r\n"
+			}
+			conn.Write([]byte(reply))
+		}
+	}()
+
+	// a local reply waits for all confirmations of UNSUBSCRIBE
+	deny := Middleware{
+		Command: func(s *ProxySession, cmd *Value) (*Value, *Value) {
+			if strings.EqualFold(cmd.Elems[0].Str, "FLUSHALL") {
+				return nil, &Value{Type: TypeSimpleError, Err: "NOPERM flushall is not allowed"}
+			}
+			return cmd, nil
+		},
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewProxy(upstream.Addr().String(), deny)
+	go p.Serve(ln)
+	defer p.Close()
+	conn, err := net.DialTimeout("tcp", ln.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	w, r := NewWriter(conn), NewReader(conn)
+
+	w.WriteCommand("SUBSCRIBE", "a", "b")
+	w.WriteCommand("UNSUBSCRIBE")
+	w.WriteCommand("FLUSHALL")
+	w.WriteCommand("PING")
+	// not a message after all channels are unsubscribed
+	w.WriteCommand("LRANGE", "l", "0", "-1")
+	expectReplies(t, r,
+		"*3\r\n$9\r\nsubscribe\r\n$1\r\na\r\n:1\r\n",
+		"*3\r\n$9\r\nsubscribe\r\n$1\r\nb\r\n:2\r\n",
+		"*3\r\n$7\r\nmessage\r\n$1\r\na\r\n$2\r\nhi\r\n",
+		"*3\r\n$11\r\nunsubscribe\r\n$1\r\na\r\n:1\r\n",
+		"*3\r\n$11\r\nunsubscribe\r\n$1\r\nb\r\n:0\r\n",
+		"-NOPERM flushall is not allowed\r\n",
+		"+PONG\r\n",
+		"*3\r\n$7\r\nmessage\r\n$1\r\na\r\n$1\r\nb\r\n")
+}
@@ -7,8 +7,14 @@ import (
 
 // ReadRaw parses a RESP3 raw byte slice.
 func (r *Reader) ReadRaw() ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
 	var buf bytes.Buffer
 	err := r.readRaw(&buf)
+	if err == nil {
+		r.stats.frame(int64(buf.Len()))
+	}
 	return buf.Bytes(), err
 }
 
@@ -85,6 +91,10 @@ func (r *Reader) readRawBlobString(w io.Writer, line []byte) error {
 	if err != nil {
 		return err
 	}
+	if count == -1 && line[0] == TypeBlobString {
+		// null bulk string of RESP2
+		return nil
+	}
 	if count < 0 {
 		return ErrInvalidSyntax
 	}
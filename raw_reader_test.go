@@ -15,6 +15,8 @@ func TestReader_ReadRaw(t *testing.T) {
 		"double":          ",1.23\r\n",
 		"inf":             ",inf\r\n",
 		"null":            "_\r\n",
+		"null bulk":       "$-1\r\n",
+		"null array":      "*-1\r\n",
 		"bool:true":       "#t\r\n",
 		"bool:false":      "#f\r\n",
 		"bignumber":       "(3492890328409238509324850943850943825024385\r\n",
@@ -33,6 +35,8 @@ func TestReader_ReadRaw(t *testing.T) {
 		"double":          ",1.23\r\nabcd",
 		"inf":             ",inf\r\nabcd",
 		"null":            "_\r\nabcd",
+		"null bulk":       "$-1\r\nabcd",
+		"null array":      "*-1\r\nabcd",
 		"bool:true":       "#t\r\nabcd",
 		"bool:false":      "#f\r\nabcd",
 		"bignumber":       "(3492890328409238509324850943850943825024385\r\nabcd",
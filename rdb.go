@@ -0,0 +1,1000 @@
+package resp3
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/emirpasic/gods/maps/linkedhashmap"
+)
+
+// RDB errors
+var (
+	ErrRDBInvalidHeader = errors.New("resp: invalid rdb header")
+	ErrRDBCorrupt       = errors.New("resp: corrupt rdb data")
+	ErrRDBChecksum      = errors.New("resp: rdb checksum mismatch")
+	ErrRDBUnsupported   = errors.New("resp: unsupported rdb encoding")
+)
+
+// rdbMaxVersion is the newest RDB format version the parser knows.
+const rdbMaxVersion = 12
+
+// rdb object types
+const (
+	RDBTypeString           = 0
+	RDBTypeList             = 1
+	RDBTypeSet              = 2
+	RDBTypeZSet             = 3
+	RDBTypeHash             = 4
+	RDBTypeZSet2            = 5
+	RDBTypeModule           = 6
+	RDBTypeModule2          = 7
+	RDBTypeHashZipmap       = 9
+	RDBTypeListZiplist      = 10
+	RDBTypeSetIntset        = 11
+	RDBTypeZSetZiplist      = 12
+	RDBTypeHashZiplist      = 13
+	RDBTypeListQuicklist    = 14
+	RDBTypeStreamListpacks  = 15
+	RDBTypeHashListpack     = 16
+	RDBTypeZSetListpack     = 17
+	RDBTypeListQuicklist2   = 18
+	RDBTypeStreamListpacks2 = 19
+	RDBTypeSetListpack      = 20
+	RDBTypeStreamListpacks3 = 21
+	RDBTypeHashMetadata     = 24
+	RDBTypeHashListpackEx   = 25
+)
+
+// rdb opcodes
+const (
+	rdbOpSlotInfo     = 0xf4
+	rdbOpFunction     = 0xf5
+	rdbOpFunction2    = 0xf6
+	rdbOpModuleAux    = 0xf7
+	rdbOpIdle         = 0xf8
+	rdbOpFreq         = 0xf9
+	rdbOpAux          = 0xfa
+	rdbOpResizeDB     = 0xfb
+	rdbOpExpireTimeMS = 0xfc
+	rdbOpExpireTime   = 0xfd
+	rdbOpSelectDB     = 0xfe
+	rdbOpEOF          = 0xff
+)
+
+// special string encodings
+const (
+	rdbEncInt8  = 0
+	rdbEncInt16 = 1
+	rdbEncInt32 = 2
+	rdbEncLZF   = 3
+)
+
+// module opcodes
+const (
+	rdbModuleOpEOF    = 0
+	rdbModuleOpSInt   = 1
+	rdbModuleOpUInt   = 2
+	rdbModuleOpFloat  = 3
+	rdbModuleOpDouble = 4
+	rdbModuleOpString = 5
+)
+
+// quicklist 2 containers
+const (
+	quicklistNodePlain  = 1
+	quicklistNodePacked = 2
+)
+
+const moduleTypeNameCharSet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+// RDBEntry is a key read from a RDB file or a DUMP payload.
+//
+// Value is converted to RESP3 types:
+// String -> BlobString
+// List -> Array of BlobString
+// Set -> Set of BlobString
+// Sorted Set -> Map of member -> Double
+// Hash -> Map of field -> value
+// Stream -> Array of [id, [field, value ...]] as XRANGE returns
+// Module -> Null, the module type name is kept in Module
+type RDBEntry struct {
+	DB       int
+	Key      string
+	Type     byte
+	Value    *Value
+	ExpireAt int64 // unix time in milliseconds, 0 if the key has no expiry
+	Idle     int64 // LRU idle time in seconds, -1 if absent
+	Freq     int   // LFU frequency, -1 if absent
+
+	// FieldExpireAt contains expiries of hash fields in unix milliseconds (Redis 7.4+).
+	FieldExpireAt map[string]int64
+	// Module is the module type name of a module value.
+	Module string
+	// Stream contains the metadata of a stream value.
+	Stream *RDBStream
+}
+
+// RDBStream is the metadata of a stream stored in a RDB file.
+type RDBStream struct {
+	Length       uint64
+	LastID       string
+	FirstID      string
+	MaxDeletedID string
+	EntriesAdded uint64
+	Groups       []*RDBStreamGroup
+}
+
+// RDBStreamGroup is a consumer group of a stream.
+type RDBStreamGroup struct {
+	Name        string
+	LastID      string
+	EntriesRead uint64
+	Pending     []string // ids of the pending entries list
+	Consumers   []string
+}
+
+// RDBReader reads keys from a RDB file.
+type RDBReader struct {
+	r       *bufio.Reader
+	crc     uint64
+	version int
+	db      int
+	eof     bool
+
+	// Aux contains the auxiliary fields, like redis-ver and ctime.
+	Aux map[string]string
+	// Functions contains the code of the function libraries (Redis 7+).
+	Functions []string
+}
+
+// NewRDBReader returns a RDB reader, the RDB header is read and checked.
+func NewRDBReader(reader io.Reader) (*RDBReader, error) {
+	p := &RDBReader{
+		r:   bufio.NewReader(reader),
+		Aux: make(map[string]string),
+	}
+
+	header, err := p.readFull(9)
+	if err != nil {
+		return nil, err
+	}
+	if string(header[:5]) != "REDIS" {
+		return nil, ErrRDBInvalidHeader
+	}
+	p.version, err = strconv.Atoi(string(header[5:]))
+	if err != nil || p.version < 1 || p.version > rdbMaxVersion {
+		return nil, ErrRDBInvalidHeader
+	}
+	return p, nil
+}
+
+// Version returns the RDB format version.
+func (p *RDBReader) Version() int {
+	return p.version
+}
+
+// Next returns the next key. It returns io.EOF after the checksum of the file has been verified.
+func (p *RDBReader) Next() (*RDBEntry, error) {
+	if p.eof {
+		return nil, io.EOF
+	}
+
+	entry := &RDBEntry{Idle: -1, Freq: -1}
+	for {
+		op, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case rdbOpEOF:
+			if p.version >= 5 {
+				sum := p.crc
+				var trailer [8]byte
+				if _, err := io.ReadFull(p.r, trailer[:]); err != nil {
+					return nil, unexpectedEOF(err)
+				}
+				// a zero checksum means the checksum is disabled
+				if expected := binary.LittleEndian.Uint64(trailer[:]); expected != 0 && expected != sum {
+					return nil, ErrRDBChecksum
+				}
+			}
+			p.eof = true
+			return nil, io.EOF
+		case rdbOpSelectDB:
+			db, _, err := p.readLength()
+			if err != nil {
+				return nil, err
+			}
+			p.db = int(db)
+		case rdbOpResizeDB, rdbOpSlotInfo:
+			n := 2
+			if op == rdbOpSlotInfo {
+				n = 3
+			}
+			for i := 0; i < n; i++ {
+				if _, _, err := p.readLength(); err != nil {
+					return nil, err
+				}
+			}
+		case rdbOpAux:
+			k, err := p.readString()
+			if err != nil {
+				return nil, err
+			}
+			v, err := p.readString()
+			if err != nil {
+				return nil, err
+			}
+			p.Aux[string(k)] = string(v)
+		case rdbOpExpireTime:
+			b, err := p.readFull(4)
+			if err != nil {
+				return nil, err
+			}
+			entry.ExpireAt = int64(binary.LittleEndian.Uint32(b)) * 1000
+		case rdbOpExpireTimeMS:
+			entry.ExpireAt, err = p.readMillis()
+			if err != nil {
+				return nil, err
+			}
+		case rdbOpFreq:
+			freq, err := p.readByte()
+			if err != nil {
+				return nil, err
+			}
+			entry.Freq = int(freq)
+		case rdbOpIdle:
+			idle, _, err := p.readLength()
+			if err != nil {
+				return nil, err
+			}
+			entry.Idle = int64(idle)
+		case rdbOpModuleAux:
+			if _, err := p.readModule(); err != nil {
+				return nil, err
+			}
+		case rdbOpFunction2:
+			code, err := p.readString()
+			if err != nil {
+				return nil, err
+			}
+			p.Functions = append(p.Functions, string(code))
+		case rdbOpFunction:
+			return nil, ErrRDBUnsupported
+		default:
+			key, err := p.readString()
+			if err != nil {
+				return nil, err
+			}
+			entry.DB = p.db
+			entry.Key = string(key)
+			if err := p.readObject(op, entry); err != nil {
+				return nil, err
+			}
+			return entry, nil
+		}
+	}
+}
+
+// ParseDump parses a payload returned by the DUMP command.
+// The returned entry has no key.
+func ParseDump(payload []byte) (*RDBEntry, error) {
+	if len(payload) < 11 {
+		return nil, ErrRDBCorrupt
+	}
+	footer := payload[len(payload)-10:]
+	version := int(binary.LittleEndian.Uint16(footer))
+	if version > rdbMaxVersion {
+		return nil, ErrRDBUnsupported
+	}
+	if expected := binary.LittleEndian.Uint64(footer[2:]); expected != crc64(payload[:len(payload)-8]) {
+		return nil, ErrRDBChecksum
+	}
+
+	p := &RDBReader{
+		r:       bufio.NewReader(bytes.NewReader(payload[:len(payload)-10])),
+		version: version,
+	}
+	t, err := p.readByte()
+	if err != nil {
+		return nil, err
+	}
+	entry := &RDBEntry{Idle: -1, Freq: -1}
+	if err := p.readObject(t, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (p *RDBReader) readObject(t byte, entry *RDBEntry) (err error) {
+	entry.Type = t
+
+	var items []string
+	switch t {
+	case RDBTypeString:
+		var s []byte
+		s, err = p.readString()
+		entry.Value = NewBlobStringValue(string(s))
+	case RDBTypeList, RDBTypeSet:
+		items, err = p.readStrings(1)
+		if t == RDBTypeList {
+			entry.Value = NewArrayValue(blobStringValues(items))
+		} else {
+			entry.Value = NewSetValue(blobStringValues(items))
+		}
+	case RDBTypeHash:
+		items, err = p.readStrings(2)
+		entry.Value = NewMapValue(blobStringMap(items))
+	case RDBTypeZSet, RDBTypeZSet2:
+		entry.Value, err = p.readZSet(t)
+	case RDBTypeHashZipmap, RDBTypeListZiplist, RDBTypeSetIntset, RDBTypeZSetZiplist,
+		RDBTypeHashZiplist, RDBTypeHashListpack, RDBTypeZSetListpack, RDBTypeSetListpack:
+		entry.Value, err = p.readEncoded(t)
+	case RDBTypeListQuicklist, RDBTypeListQuicklist2:
+		items, err = p.readQuicklist(t)
+		entry.Value = NewArrayValue(blobStringValues(items))
+	case RDBTypeHashMetadata, RDBTypeHashListpackEx:
+		err = p.readHashWithTTL(t, entry)
+	case RDBTypeStreamListpacks, RDBTypeStreamListpacks2, RDBTypeStreamListpacks3:
+		entry.Value, entry.Stream, err = p.readStream(t)
+	case RDBTypeModule2:
+		entry.Module, err = p.readModule()
+		entry.Value = NewNullValue()
+	default:
+		return ErrRDBUnsupported
+	}
+
+	return err
+}
+
+// readStrings reads a length and then length*n strings.
+func (p *RDBReader) readStrings(n int) ([]string, error) {
+	length, _, err := p.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	var rt []string
+	for i := uint64(0); i < length*uint64(n); i++ {
+		s, err := p.readString()
+		if err != nil {
+			return nil, err
+		}
+		rt = append(rt, string(s))
+	}
+	return rt, nil
+}
+
+func (p *RDBReader) readZSet(t byte) (*Value, error) {
+	length, _, err := p.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	kv := linkedhashmap.New()
+	for i := uint64(0); i < length; i++ {
+		member, err := p.readString()
+		if err != nil {
+			return nil, err
+		}
+		var score float64
+		if t == RDBTypeZSet2 {
+			score, err = p.readBinaryDouble()
+		} else {
+			score, err = p.readStringDouble()
+		}
+		if err != nil {
+			return nil, err
+		}
+		kv.Put(NewBlobStringValue(string(member)), NewDoubleValue(score))
+	}
+	return NewMapValue(kv), nil
+}
+
+// readEncoded reads the types stored as one ziplist, listpack, intset or zipmap string.
+func (p *RDBReader) readEncoded(t byte) (*Value, error) {
+	s, err := p.readString()
+	if err != nil {
+		return nil, err
+	}
+
+	var items []string
+	switch t {
+	case RDBTypeHashZipmap:
+		items, err = parseZipmap(s)
+	case RDBTypeSetIntset:
+		items, err = parseIntset(s)
+	case RDBTypeListZiplist, RDBTypeZSetZiplist, RDBTypeHashZiplist:
+		items, err = parseZiplist(s)
+	default:
+		items, err = parseListpack(s)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch t {
+	case RDBTypeListZiplist:
+		return NewArrayValue(blobStringValues(items)), nil
+	case RDBTypeSetIntset, RDBTypeSetListpack:
+		return NewSetValue(blobStringValues(items)), nil
+	case RDBTypeZSetZiplist, RDBTypeZSetListpack:
+		if len(items)%2 != 0 {
+			return nil, ErrRDBCorrupt
+		}
+		kv := linkedhashmap.New()
+		for i := 0; i < len(items); i += 2 {
+			score, err := strconv.ParseFloat(items[i+1], 64)
+			if err != nil {
+				return nil, ErrRDBCorrupt
+			}
+			kv.Put(NewBlobStringValue(items[i]), NewDoubleValue(score))
+		}
+		return NewMapValue(kv), nil
+	default:
+		if len(items)%2 != 0 {
+			return nil, ErrRDBCorrupt
+		}
+		return NewMapValue(blobStringMap(items)), nil
+	}
+}
+
+func (p *RDBReader) readQuicklist(t byte) ([]string, error) {
+	nodes, _, err := p.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	var rt []string
+	for i := uint64(0); i < nodes; i++ {
+		container := uint64(quicklistNodePacked)
+		if t == RDBTypeListQuicklist2 {
+			container, _, err = p.readLength()
+			if err != nil {
+				return nil, err
+			}
+		}
+		s, err := p.readString()
+		if err != nil {
+			return nil, err
+		}
+
+		var items []string
+		switch {
+		case container == quicklistNodePlain:
+			items = []string{string(s)}
+		case container != quicklistNodePacked:
+			return nil, ErrRDBCorrupt
+		case t == RDBTypeListQuicklist:
+			items, err = parseZiplist(s)
+		default:
+			items, err = parseListpack(s)
+		}
+		if err != nil {
+			return nil, err
+		}
+		rt = append(rt, items...)
+	}
+	return rt, nil
+}
+
+func (p *RDBReader) readHashWithTTL(t byte, entry *RDBEntry) error {
+	minExpire, err := p.readMillis()
+	if err != nil {
+		return err
+	}
+
+	kv := linkedhashmap.New()
+	entry.FieldExpireAt = make(map[string]int64)
+	entry.Value = NewMapValue(kv)
+
+	if t == RDBTypeHashListpackEx {
+		s, err := p.readString()
+		if err != nil {
+			return err
+		}
+		items, err := parseListpack(s)
+		if err != nil {
+			return err
+		}
+		if len(items)%3 != 0 {
+			return ErrRDBCorrupt
+		}
+		for i := 0; i < len(items); i += 3 {
+			kv.Put(NewBlobStringValue(items[i]), NewBlobStringValue(items[i+1]))
+			expireAt, err := strconv.ParseInt(items[i+2], 10, 64)
+			if err != nil {
+				return ErrRDBCorrupt
+			}
+			if expireAt != 0 {
+				entry.FieldExpireAt[items[i]] = expireAt
+			}
+		}
+		return nil
+	}
+
+	length, _, err := p.readLength()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < length; i++ {
+		ttl, _, err := p.readLength()
+		if err != nil {
+			return err
+		}
+		field, err := p.readString()
+		if err != nil {
+			return err
+		}
+		value, err := p.readString()
+		if err != nil {
+			return err
+		}
+		kv.Put(NewBlobStringValue(string(field)), NewBlobStringValue(string(value)))
+		// ttls are saved relative to the minimal expiry plus one, zero means no expiry
+		if ttl != 0 {
+			entry.FieldExpireAt[string(field)] = minExpire + int64(ttl) - 1
+		}
+	}
+	return nil
+}
+
+func (p *RDBReader) readStream(t byte) (*Value, *RDBStream, error) {
+	nodes, _, err := p.readLength()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entries []*Value
+	for i := uint64(0); i < nodes; i++ {
+		nodeKey, err := p.readString()
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(nodeKey) != 16 {
+			return nil, nil, ErrRDBCorrupt
+		}
+		lp, err := p.readString()
+		if err != nil {
+			return nil, nil, err
+		}
+		items, err := parseListpack(lp)
+		if err != nil {
+			return nil, nil, err
+		}
+		es, err := streamEntries(nodeKey, items)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, es...)
+	}
+
+	s := &RDBStream{}
+	if s.Length, _, err = p.readLength(); err != nil {
+		return nil, nil, err
+	}
+	if s.LastID, err = p.readStreamID(); err != nil {
+		return nil, nil, err
+	}
+	if t >= RDBTypeStreamListpacks2 {
+		if s.FirstID, err = p.readStreamID(); err != nil {
+			return nil, nil, err
+		}
+		if s.MaxDeletedID, err = p.readStreamID(); err != nil {
+			return nil, nil, err
+		}
+		if s.EntriesAdded, _, err = p.readLength(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	groups, _, err := p.readLength()
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := uint64(0); i < groups; i++ {
+		g, err := p.readStreamGroup(t)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.Groups = append(s.Groups, g)
+	}
+
+	return NewArrayValue(entries), s, nil
+}
+
+func (p *RDBReader) readStreamGroup(t byte) (*RDBStreamGroup, error) {
+	name, err := p.readString()
+	if err != nil {
+		return nil, err
+	}
+	g := &RDBStreamGroup{Name: string(name)}
+	if g.LastID, err = p.readStreamID(); err != nil {
+		return nil, err
+	}
+	if t >= RDBTypeStreamListpacks2 {
+		if g.EntriesRead, _, err = p.readLength(); err != nil {
+			return nil, err
+		}
+	}
+
+	// the global pending entries list: raw id, delivery time and delivery count
+	pending, _, err := p.readLength()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < pending; i++ {
+		rawID, err := p.readFull(16)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = p.readMillis(); err != nil {
+			return nil, err
+		}
+		if _, _, err = p.readLength(); err != nil {
+			return nil, err
+		}
+		g.Pending = append(g.Pending, formatStreamID(rawID))
+	}
+
+	consumers, _, err := p.readLength()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < consumers; i++ {
+		name, err := p.readString()
+		if err != nil {
+			return nil, err
+		}
+		g.Consumers = append(g.Consumers, string(name))
+		// seen time and active time
+		if _, err = p.readMillis(); err != nil {
+			return nil, err
+		}
+		if t >= RDBTypeStreamListpacks3 {
+			if _, err = p.readMillis(); err != nil {
+				return nil, err
+			}
+		}
+		// the consumer pending entries only refer to the global one
+		n, _, err := p.readLength()
+		if err != nil {
+			return nil, err
+		}
+		for j := uint64(0); j < n; j++ {
+			if _, err = p.readFull(16); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return g, nil
+}
+
+// streamEntries decodes the entries of a stream listpack node.
+// The first entry is the master entry which holds the master fields:
+// count, deleted, field number, fields..., 0.
+// Every entry is then flags, ms-diff, seq-diff, (field number, field-value pairs | values), lp-count.
+func streamEntries(nodeKey []byte, items []string) ([]*Value, error) {
+	const (
+		flagDeleted    = 1
+		flagSameFields = 2
+	)
+
+	masterMs := binary.BigEndian.Uint64(nodeKey)
+	masterSeq := binary.BigEndian.Uint64(nodeKey[8:])
+
+	atoi := func(i int) (int64, error) {
+		if i >= len(items) {
+			return 0, ErrRDBCorrupt
+		}
+		n, err := strconv.ParseInt(items[i], 10, 64)
+		if err != nil {
+			return 0, ErrRDBCorrupt
+		}
+		return n, nil
+	}
+
+	nfields, err := atoi(2)
+	if err != nil {
+		return nil, err
+	}
+	idx := 3 + int(nfields)
+	if idx >= len(items) {
+		return nil, ErrRDBCorrupt
+	}
+	fields := items[3:idx]
+	idx++ // master entry terminator
+
+	var rt []*Value
+	for idx < len(items) {
+		flags, err := atoi(idx)
+		if err != nil {
+			return nil, err
+		}
+		msDiff, err := atoi(idx + 1)
+		if err != nil {
+			return nil, err
+		}
+		seqDiff, err := atoi(idx + 2)
+		if err != nil {
+			return nil, err
+		}
+		idx += 3
+
+		var kv []string
+		if flags&flagSameFields != 0 {
+			if idx+len(fields) > len(items) {
+				return nil, ErrRDBCorrupt
+			}
+			for i, f := range fields {
+				kv = append(kv, f, items[idx+i])
+			}
+			idx += len(fields)
+		} else {
+			n, err := atoi(idx)
+			if err != nil {
+				return nil, err
+			}
+			idx++
+			if idx+int(n)*2 > len(items) {
+				return nil, ErrRDBCorrupt
+			}
+			kv = append(kv, items[idx:idx+int(n)*2]...)
+			idx += int(n) * 2
+		}
+		idx++ // lp-count
+
+		if flags&flagDeleted != 0 {
+			continue
+		}
+		id := strconv.FormatUint(masterMs+uint64(msDiff), 10) + "-" + strconv.FormatUint(masterSeq+uint64(seqDiff), 10)
+		rt = append(rt, NewArrayValue([]*Value{
+			NewBlobStringValue(id),
+			NewArrayValue(blobStringValues(kv)),
+		}))
+	}
+	return rt, nil
+}
+
+func (p *RDBReader) readStreamID() (string, error) {
+	ms, _, err := p.readLength()
+	if err != nil {
+		return "", err
+	}
+	seq, _, err := p.readLength()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(ms, 10) + "-" + strconv.FormatUint(seq, 10), nil
+}
+
+func formatStreamID(raw []byte) string {
+	return strconv.FormatUint(binary.BigEndian.Uint64(raw), 10) + "-" + strconv.FormatUint(binary.BigEndian.Uint64(raw[8:]), 10)
+}
+
+// readModule skips a module value or a module aux field and returns the module type name.
+func (p *RDBReader) readModule() (string, error) {
+	id, _, err := p.readLength()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		op, _, err := p.readLength()
+		if err != nil {
+			return "", err
+		}
+		switch op {
+		case rdbModuleOpEOF:
+			return moduleTypeName(id), nil
+		case rdbModuleOpSInt, rdbModuleOpUInt:
+			_, _, err = p.readLength()
+		case rdbModuleOpFloat:
+			_, err = p.readFull(4)
+		case rdbModuleOpDouble:
+			_, err = p.readFull(8)
+		case rdbModuleOpString:
+			_, err = p.readString()
+		default:
+			return "", ErrRDBCorrupt
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// moduleTypeName decodes the 9 characters name from the 64 bit module id.
+// The lower 10 bits are the encoding version.
+func moduleTypeName(id uint64) string {
+	var name [9]byte
+	id >>= 10
+	for j := 0; j < 9; j++ {
+		name[8-j] = moduleTypeNameCharSet[id&63]
+		id >>= 6
+	}
+	return string(name[:])
+}
+
+// readLength reads a length. If encoded is true, the length is a special string encoding.
+func (p *RDBReader) readLength() (length uint64, encoded bool, err error) {
+	b, err := p.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch b >> 6 {
+	case 0:
+		return uint64(b & 0x3f), false, nil
+	case 1:
+		next, err := p.readByte()
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(b&0x3f)<<8 | uint64(next), false, nil
+	case 2:
+		switch b {
+		case 0x80:
+			data, err := p.readFull(4)
+			if err != nil {
+				return 0, false, err
+			}
+			return uint64(binary.BigEndian.Uint32(data)), false, nil
+		case 0x81:
+			data, err := p.readFull(8)
+			if err != nil {
+				return 0, false, err
+			}
+			return binary.BigEndian.Uint64(data), false, nil
+		}
+		return 0, false, ErrRDBCorrupt
+	default:
+		return uint64(b & 0x3f), true, nil
+	}
+}
+
+func (p *RDBReader) readString() ([]byte, error) {
+	length, encoded, err := p.readLength()
+	if err != nil {
+		return nil, err
+	}
+	if !encoded {
+		return p.readFull(length)
+	}
+
+	switch length {
+	case rdbEncInt8:
+		b, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(nil, int64(int8(b)), 10), nil
+	case rdbEncInt16:
+		b, err := p.readFull(2)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(nil, int64(int16(binary.LittleEndian.Uint16(b))), 10), nil
+	case rdbEncInt32:
+		b, err := p.readFull(4)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(nil, int64(int32(binary.LittleEndian.Uint32(b))), 10), nil
+	case rdbEncLZF:
+		clen, _, err := p.readLength()
+		if err != nil {
+			return nil, err
+		}
+		ulen, _, err := p.readLength()
+		if err != nil {
+			return nil, err
+		}
+		b, err := p.readFull(clen)
+		if err != nil {
+			return nil, err
+		}
+		return lzfDecompress(b, ulen)
+	}
+	return nil, ErrRDBUnsupported
+}
+
+// readStringDouble reads a double of the old zset encoding.
+func (p *RDBReader) readStringDouble() (float64, error) {
+	n, err := p.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 253:
+		return math.NaN(), nil
+	case 254:
+		return math.Inf(1), nil
+	case 255:
+		return math.Inf(-1), nil
+	}
+	b, err := p.readFull(uint64(n))
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(string(b)), 64)
+	if err != nil {
+		return 0, ErrRDBCorrupt
+	}
+	return f, nil
+}
+
+func (p *RDBReader) readBinaryDouble() (float64, error) {
+	b, err := p.readFull(8)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+}
+
+func (p *RDBReader) readMillis() (int64, error) {
+	b, err := p.readFull(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint64(b)), nil
+}
+
+func (p *RDBReader) readByte() (byte, error) {
+	b, err := p.r.ReadByte()
+	if err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	p.crc = crc64_tab[byte(p.crc)^b] ^ (p.crc >> 8)
+	return b, nil
+}
+
+// readFull reads n bytes and updates the checksum.
+// Large lengths are not trusted to preallocate memory since the data may be corrupt.
+func (p *RDBReader) readFull(n uint64) ([]byte, error) {
+	var b []byte
+	if n <= 64*1024 {
+		b = make([]byte, n)
+		if _, err := io.ReadFull(p.r, b); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	} else {
+		var buf bytes.Buffer
+		if _, err := io.CopyN(&buf, p.r, int64(n)); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		b = buf.Bytes()
+	}
+	p.crc = crc64Update(p.crc, b)
+	return b, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func blobStringValues(items []string) []*Value {
+	rt := make([]*Value, 0, len(items))
+	for _, item := range items {
+		rt = append(rt, NewBlobStringValue(item))
+	}
+	return rt
+}
+
+func blobStringMap(items []string) *linkedhashmap.Map {
+	kv := linkedhashmap.New()
+	for i := 0; i+1 < len(items); i += 2 {
+		kv.Put(NewBlobStringValue(items[i]), NewBlobStringValue(items[i+1]))
+	}
+	return kv
+}
@@ -0,0 +1,328 @@
+package resp3
+
+import (
+	"encoding/binary"
+	"strconv"
+)
+
+// lzfMaxRatio is the largest expansion of LZF: a back reference of 3 bytes copies 264 bytes.
+const lzfMaxRatio = 88
+
+// lzfDecompress decompresses a LZF compressed string of a RDB file.
+// The uncompressed length read from the file is checked against the input before it is allocated.
+func lzfDecompress(in []byte, outLen uint64) ([]byte, error) {
+	if outLen > uint64(len(in))*lzfMaxRatio {
+		return nil, ErrRDBCorrupt
+	}
+	out := make([]byte, 0, outLen)
+	i := 0
+	for i < len(in) {
+		ctrl := int(in[i])
+		i++
+
+		if ctrl < 1<<5 { // literal run
+			n := ctrl + 1
+			if i+n > len(in) || uint64(len(out)+n) > outLen {
+				return nil, ErrRDBCorrupt
+			}
+			out = append(out, in[i:i+n]...)
+			i += n
+			continue
+		}
+
+		// back reference
+		length := ctrl >> 5
+		ref := len(out) - ((ctrl & 0x1f) << 8) - 1
+		if length == 7 {
+			if i >= len(in) {
+				return nil, ErrRDBCorrupt
+			}
+			length += int(in[i])
+			i++
+		}
+		if i >= len(in) {
+			return nil, ErrRDBCorrupt
+		}
+		ref -= int(in[i])
+		i++
+		length += 2
+		if ref < 0 || uint64(len(out)+length) > outLen {
+			return nil, ErrRDBCorrupt
+		}
+		for k := 0; k < length; k++ {
+			out = append(out, out[ref+k])
+		}
+	}
+
+	if uint64(len(out)) != outLen {
+		return nil, ErrRDBCorrupt
+	}
+	return out, nil
+}
+
+// parseZiplist returns all entries of a ziplist, integers are formatted in base 10.
+func parseZiplist(b []byte) ([]string, error) {
+	if len(b) < 11 {
+		return nil, ErrRDBCorrupt
+	}
+
+	var rt []string
+	pos := 10
+	for {
+		if pos >= len(b) {
+			return nil, ErrRDBCorrupt
+		}
+		if b[pos] == 0xff {
+			return rt, nil
+		}
+
+		// skip prevlen
+		if b[pos] < 254 {
+			pos++
+		} else {
+			pos += 5
+		}
+		if pos >= len(b) {
+			return nil, ErrRDBCorrupt
+		}
+
+		c := b[pos]
+		var n, size int
+		var integer int64
+		isInt := true
+		switch c >> 6 {
+		case 0:
+			n, size, isInt = int(c&0x3f), 1, false
+		case 1:
+			if pos+2 > len(b) {
+				return nil, ErrRDBCorrupt
+			}
+			n, size, isInt = int(c&0x3f)<<8|int(b[pos+1]), 2, false
+		case 2:
+			if pos+5 > len(b) {
+				return nil, ErrRDBCorrupt
+			}
+			n, size, isInt = int(binary.BigEndian.Uint32(b[pos+1:])), 5, false
+		default:
+			switch {
+			case c == 0xc0:
+				size = 3
+			case c == 0xd0:
+				size = 5
+			case c == 0xe0:
+				size = 9
+			case c == 0xf0:
+				size = 4
+			case c == 0xfe:
+				size = 2
+			case c >= 0xf1 && c <= 0xfd:
+				size = 1
+				integer = int64(c&0x0f) - 1
+			default:
+				return nil, ErrRDBCorrupt
+			}
+			if pos+size > len(b) {
+				return nil, ErrRDBCorrupt
+			}
+			data := b[pos+1 : pos+size]
+			switch c {
+			case 0xc0:
+				integer = int64(int16(binary.LittleEndian.Uint16(data)))
+			case 0xd0:
+				integer = int64(int32(binary.LittleEndian.Uint32(data)))
+			case 0xe0:
+				integer = int64(binary.LittleEndian.Uint64(data))
+			case 0xf0:
+				integer = int64(int32(uint32(data[0])<<8|uint32(data[1])<<16|uint32(data[2])<<24) >> 8)
+			case 0xfe:
+				integer = int64(int8(data[0]))
+			}
+		}
+
+		if isInt {
+			rt = append(rt, strconv.FormatInt(integer, 10))
+			pos += size
+			continue
+		}
+		if pos+size+n > len(b) {
+			return nil, ErrRDBCorrupt
+		}
+		rt = append(rt, string(b[pos+size:pos+size+n]))
+		pos += size + n
+	}
+}
+
+// parseListpack returns all entries of a listpack, integers are formatted in base 10.
+func parseListpack(b []byte) ([]string, error) {
+	if len(b) < 7 {
+		return nil, ErrRDBCorrupt
+	}
+
+	var rt []string
+	pos := 6
+	for {
+		if pos >= len(b) {
+			return nil, ErrRDBCorrupt
+		}
+		c := b[pos]
+		if c == 0xff {
+			return rt, nil
+		}
+
+		var size, n int // size of the encoding header and the length of the string
+		var integer int64
+		isInt := true
+		switch {
+		case c&0x80 == 0:
+			size, integer = 1, int64(c&0x7f)
+		case c&0xc0 == 0x80:
+			size, n, isInt = 1, int(c&0x3f), false
+		case c&0xe0 == 0xc0:
+			size = 2
+		case c&0xf0 == 0xe0:
+			size, isInt = 2, false
+		case c == 0xf0:
+			size, isInt = 5, false
+		case c == 0xf1:
+			size = 3
+		case c == 0xf2:
+			size = 4
+		case c == 0xf3:
+			size = 5
+		case c == 0xf4:
+			size = 9
+		default:
+			return nil, ErrRDBCorrupt
+		}
+		if pos+size > len(b) {
+			return nil, ErrRDBCorrupt
+		}
+		data := b[pos+1 : pos+size]
+
+		switch {
+		case c&0xe0 == 0xc0:
+			integer = int64(c&0x1f)<<8 | int64(data[0])
+			if integer >= 1<<12 {
+				integer -= 1 << 13
+			}
+		case c&0xf0 == 0xe0:
+			n = int(c&0x0f)<<8 | int(data[0])
+		case c == 0xf0:
+			n = int(binary.LittleEndian.Uint32(data))
+		case c == 0xf1:
+			integer = int64(int16(binary.LittleEndian.Uint16(data)))
+		case c == 0xf2:
+			integer = int64(int32(uint32(data[0])<<8|uint32(data[1])<<16|uint32(data[2])<<24) >> 8)
+		case c == 0xf3:
+			integer = int64(int32(binary.LittleEndian.Uint32(data)))
+		case c == 0xf4:
+			integer = int64(binary.LittleEndian.Uint64(data))
+		}
+
+		if isInt {
+			rt = append(rt, strconv.FormatInt(integer, 10))
+		} else {
+			if pos+size+n > len(b) {
+				return nil, ErrRDBCorrupt
+			}
+			rt = append(rt, string(b[pos+size:pos+size+n]))
+		}
+
+		entryLen := size + n
+		pos += entryLen + listpackBacklenSize(entryLen)
+	}
+}
+
+// listpackBacklenSize returns how many bytes the backlen of an entry uses,
+// with the same boundaries as lpEncodeBacklen of redis.
+func listpackBacklenSize(l int) int {
+	switch {
+	case l <= 127:
+		return 1
+	case l < 16383:
+		return 2
+	case l < 2097151:
+		return 3
+	case l < 268435455:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// parseIntset returns all members of an intset.
+func parseIntset(b []byte) ([]string, error) {
+	if len(b) < 8 {
+		return nil, ErrRDBCorrupt
+	}
+	enc := int(binary.LittleEndian.Uint32(b))
+	n := int(binary.LittleEndian.Uint32(b[4:]))
+	if (enc != 2 && enc != 4 && enc != 8) || len(b) < 8+n*enc {
+		return nil, ErrRDBCorrupt
+	}
+
+	rt := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		data := b[8+i*enc:]
+		var v int64
+		switch enc {
+		case 2:
+			v = int64(int16(binary.LittleEndian.Uint16(data)))
+		case 4:
+			v = int64(int32(binary.LittleEndian.Uint32(data)))
+		case 8:
+			v = int64(binary.LittleEndian.Uint64(data))
+		}
+		rt = append(rt, strconv.FormatInt(v, 10))
+	}
+	return rt, nil
+}
+
+// parseZipmap returns the key and value pairs of a zipmap flattened in one slice.
+func parseZipmap(b []byte) ([]string, error) {
+	var rt []string
+	pos := 1
+	for {
+		if pos >= len(b) {
+			return nil, ErrRDBCorrupt
+		}
+		if b[pos] == 0xff {
+			return rt, nil
+		}
+
+		var n int
+		var err error
+		n, pos, err = zipmapLen(b, pos)
+		if err != nil || pos+n > len(b) {
+			return nil, ErrRDBCorrupt
+		}
+		rt = append(rt, string(b[pos:pos+n]))
+		pos += n
+
+		n, pos, err = zipmapLen(b, pos)
+		if err != nil || pos+1+n > len(b) {
+			return nil, ErrRDBCorrupt
+		}
+		free := int(b[pos])
+		pos++
+		rt = append(rt, string(b[pos:pos+n]))
+		pos += n + free
+	}
+}
+
+func zipmapLen(b []byte, pos int) (int, int, error) {
+	if pos >= len(b) {
+		return 0, pos, ErrRDBCorrupt
+	}
+	switch c := b[pos]; {
+	case c < 254:
+		return int(c), pos + 1, nil
+	case c == 254:
+		if pos+5 > len(b) {
+			return 0, pos, ErrRDBCorrupt
+		}
+		return int(binary.LittleEndian.Uint32(b[pos+1:])), pos + 5, nil
+	default:
+		return 0, pos, ErrRDBCorrupt
+	}
+}
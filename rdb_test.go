@@ -0,0 +1,340 @@
+package resp3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+// rdbBuilder writes RDB data for tests.
+type rdbBuilder struct {
+	bytes.Buffer
+}
+
+func (b *rdbBuilder) length(n int) *rdbBuilder {
+	switch {
+	case n < 1<<6:
+		b.WriteByte(byte(n))
+	case n < 1<<14:
+		b.WriteByte(byte(n>>8) | 0x40)
+		b.WriteByte(byte(n))
+	default:
+		b.WriteByte(0x80)
+		binary.Write(b, binary.BigEndian, uint32(n))
+	}
+	return b
+}
+
+func (b *rdbBuilder) str(s string) *rdbBuilder {
+	b.length(len(s))
+	b.WriteString(s)
+	return b
+}
+
+func (b *rdbBuilder) op(op byte) *rdbBuilder {
+	b.WriteByte(op)
+	return b
+}
+
+func (b *rdbBuilder) file() []byte {
+	data := append([]byte(nil), b.Bytes()...)
+	data = append(data, rdbOpEOF)
+	var sum [8]byte
+	binary.LittleEndian.PutUint64(sum[:], crc64(data))
+	return append(data, sum[:]...)
+}
+
+func newRDBBuilder() *rdbBuilder {
+	b := &rdbBuilder{}
+	b.WriteString("REDIS0011")
+	return b
+}
+
+func listpack(entries ...[]byte) string {
+	var body []byte
+	for _, e := range entries {
+		body = append(body, e...)
+		body = append(body, byte(len(e)))
+	}
+	var hdr [6]byte
+	binary.LittleEndian.PutUint32(hdr[:], uint32(len(body)+7))
+	binary.LittleEndian.PutUint16(hdr[4:], uint16(len(entries)))
+	return string(append(append(hdr[:], body...), 0xff))
+}
+
+func lpStr(s string) []byte {
+	return append([]byte{0x80 | byte(len(s))}, s...)
+}
+
+func lpInt(i int) []byte {
+	return []byte{byte(i)}
+}
+
+func TestCRC64(t *testing.T) {
+	if crc64([]byte("123456789")) != 0xe9c6d914c4b8d9ca {
+		t.Errorf("unexpected crc64: %x", crc64([]byte("123456789")))
+	}
+}
+
+func TestRDBReader(t *testing.T) {
+	b := newRDBBuilder()
+	b.op(rdbOpAux).str("redis-ver").str("7.2.0")
+	b.op(rdbOpSelectDB).length(2)
+	b.op(rdbOpResizeDB).length(4).length(1)
+
+	// string with an expiry and an int encoded string
+	b.op(rdbOpExpireTimeMS)
+	binary.Write(b, binary.LittleEndian, uint64(1700000000000))
+	b.op(RDBTypeString).str("str").str("hello")
+	b.op(RDBTypeString).str("int")
+	b.WriteByte(0xc0 | rdbEncInt16)
+	binary.Write(b, binary.LittleEndian, int16(-300))
+
+	b.op(RDBTypeList).str("list").length(2).str("a").str("b")
+	b.op(RDBTypeHashListpack).str("hash").str(listpack(lpStr("f1"), lpStr("v1"), lpStr("f2"), lpInt(7)))
+	b.op(RDBTypeZSet2).str("zset").length(1).str("m")
+	binary.Write(b, binary.LittleEndian, math.Float64bits(1.5))
+
+	// intset with two 16 bit members
+	b.op(RDBTypeSetIntset).str("intset").str(string([]byte{2, 0, 0, 0, 2, 0, 0, 0, 1, 0, 0xff, 0xff}))
+
+	p, err := NewRDBReader(bytes.NewReader(b.file()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		key  string
+		resp string
+	}{
+		{"str", "$5\r\nhello\r\n"},
+		{"int", "$4\r\n-300\r\n"},
+		{"list", "*2\r\n$1\r\na\r\n$1\r\nb\r\n"},
+		{"hash", "%2\r\n$2\r\nf1\r\n$2\r\nv1\r\n$2\r\nf2\r\n$1\r\n7\r\n"},
+		{"zset", "%1\r\n$1\r\nm\r\n,1.5\r\n"},
+		{"intset", "~2\r\n$1\r\n1\r\n$2\r\n-1\r\n"},
+	}
+	for i, exp := range expected {
+		entry, err := p.Next()
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", exp.key, err)
+		}
+		if entry.Key != exp.key || entry.DB != 2 {
+			t.Errorf("expected key %s but got %s in db %d", exp.key, entry.Key, entry.DB)
+		}
+		if s := entry.Value.ToRESP3String(); s != exp.resp {
+			t.Errorf("%s: expected %q but got %q", exp.key, exp.resp, s)
+		}
+		if i == 0 && entry.ExpireAt != 1700000000000 {
+			t.Errorf("unexpected expire: %d", entry.ExpireAt)
+		}
+		if i > 0 && entry.ExpireAt != 0 {
+			t.Errorf("unexpected expire of %s: %d", exp.key, entry.ExpireAt)
+		}
+	}
+
+	if _, err = p.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF but got %v", err)
+	}
+	if p.Aux["redis-ver"] != "7.2.0" {
+		t.Errorf("unexpected aux fields: %v", p.Aux)
+	}
+}
+
+func TestRDBReader_Checksum(t *testing.T) {
+	b := newRDBBuilder()
+	b.op(RDBTypeString).str("k").str("v")
+	data := b.file()
+	data[len(data)-1] ^= 0xff
+
+	p, err := NewRDBReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Next(); !errors.Is(err, ErrRDBChecksum) {
+		t.Errorf("expected ErrRDBChecksum but got %v", err)
+	}
+
+	if _, err = NewRDBReader(bytes.NewReader([]byte("RDIS00011"))); !errors.Is(err, ErrRDBInvalidHeader) {
+		t.Errorf("expected ErrRDBInvalidHeader but got %v", err)
+	}
+}
+
+func TestRDBReader_Stream(t *testing.T) {
+	nodeKey := make([]byte, 16)
+	binary.BigEndian.PutUint64(nodeKey, 1000)
+
+	lp := listpack(
+		// master entry: count, deleted, fields, field..., 0
+		lpInt(2), lpInt(1), lpInt(1), lpStr("f"), lpInt(0),
+		// same fields entry
+		lpInt(2), lpInt(0), lpInt(0), lpStr("a"), lpInt(4),
+		// deleted entry
+		lpInt(3), lpInt(1), lpInt(0), lpStr("b"), lpInt(4),
+		// entry with its own fields
+		lpInt(0), lpInt(2), lpInt(1), lpInt(1), lpStr("g"), lpStr("c"), lpInt(6),
+	)
+
+	b := newRDBBuilder()
+	b.op(RDBTypeStreamListpacks2).str("stream").length(1).str(string(nodeKey)).str(lp)
+	b.length(2)                        // length
+	b.length(1002).length(1)           // last id
+	b.length(1000).length(0)           // first id
+	b.length(1001).length(0)           // max deleted id
+	b.length(3)                        // entries added
+	b.length(1).str("group")           // one group
+	b.length(1000).length(0).length(1) // last id and entries read
+	b.length(1)
+	b.Write(nodeKey)
+	binary.Write(b, binary.LittleEndian, uint64(0))
+	b.length(1)
+	b.length(1).str("consumer")
+	binary.Write(b, binary.LittleEndian, uint64(0))
+	b.length(1)
+	b.Write(nodeKey)
+
+	p, err := NewRDBReader(bytes.NewReader(b.file()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := p.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "*2\r\n*2\r\n$6\r\n1000-0\r\n*2\r\n$1\r\nf\r\n$1\r\na\r\n*2\r\n$6\r\n1002-1\r\n*2\r\n$1\r\ng\r\n$1\r\nc\r\n"
+	if s := entry.Value.ToRESP3String(); s != expected {
+		t.Errorf("expected %q but got %q", expected, s)
+	}
+	if entry.Stream.LastID != "1002-1" || len(entry.Stream.Groups) != 1 {
+		t.Fatalf("unexpected stream metadata: %+v", entry.Stream)
+	}
+	g := entry.Stream.Groups[0]
+	if g.Name != "group" || g.Pending[0] != "1000-0" || g.Consumers[0] != "consumer" {
+		t.Errorf("unexpected group: %+v", g)
+	}
+	if _, err = p.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF but got %v", err)
+	}
+}
+
+func TestParseDump(t *testing.T) {
+	payload := []byte{RDBTypeString}
+	// LZF compressed "aaaaaaaaaa": literal "a" then a back reference of 9 bytes
+	payload = append(payload, 0xc0|rdbEncLZF, 5, 10, 0x00, 'a', 0xe0, 0x00, 0x00)
+	payload = append(payload, 11, 0)
+	var sum [8]byte
+	binary.LittleEndian.PutUint64(sum[:], crc64(payload))
+	payload = append(payload, sum[:]...)
+
+	entry, err := ParseDump(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Value.Str != "aaaaaaaaaa" {
+		t.Errorf("unexpected value: %q", entry.Value.Str)
+	}
+
+	payload[1] ^= 0xff
+	if _, err = ParseDump(payload); !errors.Is(err, ErrRDBChecksum) {
+		t.Errorf("expected ErrRDBChecksum but got %v", err)
+	}
+}
+
+func TestParseZiplist(t *testing.T) {
+	zl := []byte{0, 0, 0, 0, 0, 0, 0, 0, 4, 0}
+	zl = append(zl, 0, 0x02, 'h', 'i')         // string
+	zl = append(zl, 4, 0xf3)                   // immediate 2
+	zl = append(zl, 2, 0xfe, 0x80)             // int8 -128
+	zl = append(zl, 3, 0xc0, 0xe8, 0x03)       // int16 1000
+	zl = append(zl, 4, 0xf0, 0xff, 0xff, 0xff) // int24 -1
+	zl = append(zl, 0xff)
+
+	items, err := parseZiplist(zl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"hi", "2", "-128", "1000", "-1"}
+	if len(items) != len(expected) {
+		t.Fatalf("expected %v but got %v", expected, items)
+	}
+	for i := range expected {
+		if items[i] != expected[i] {
+			t.Errorf("expected %v but got %v", expected, items)
+		}
+	}
+}
+
+func TestParseListpack(t *testing.T) {
+	// entries of 16382 and 16383 bytes, whose backlens take 2 and 3 bytes in lpEncodeBacklen of redis
+	var body []byte
+	var expected []string
+	for _, l := range []int{16382, 16383} {
+		s := strings.Repeat("a", l-5)
+		e := []byte{0xf0} // 32 bit string
+		e = binary.LittleEndian.AppendUint32(e, uint32(len(s)))
+		e = append(e, s...)
+		if l < 16383 {
+			e = append(e, byte(l>>7), byte(l&127)|128)
+		} else {
+			e = append(e, byte(l>>14), byte(l>>7&127)|128, byte(l&127)|128)
+		}
+		body = append(body, e...)
+		expected = append(expected, s)
+	}
+	body = append(body, lpStr("x")...)
+	body = append(body, 2)
+	expected = append(expected, "x")
+
+	lp := binary.LittleEndian.AppendUint32(nil, uint32(len(body)+7))
+	lp = binary.LittleEndian.AppendUint16(lp, uint16(len(expected)))
+	lp = append(append(lp, body...), 0xff)
+
+	items, err := parseListpack(lp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != len(expected) {
+		t.Fatalf("expected %d items but got %d", len(expected), len(items))
+	}
+	for i := range expected {
+		if items[i] != expected[i] {
+			t.Errorf("unexpected item %d of %d bytes", i, len(items[i]))
+		}
+	}
+}
+
+func TestLZFDecompress(t *testing.T) {
+	// a literal run of "a", and a back reference of 12 bytes to it
+	in := []byte{0x00, 'a', 0xe0, 0x03, 0x00}
+	out, err := lzfDecompress(in, 13)
+	if err != nil || string(out) != strings.Repeat("a", 13) {
+		t.Fatalf("unexpected output %q, %v", out, err)
+	}
+
+	tests := []struct {
+		in     []byte
+		outLen uint64
+	}{
+		{in, 12},
+		{in, 14},
+		{in, 1 << 40},
+		{in, math.MaxUint64},
+		{[]byte{0x05, 'a'}, 6},
+		{[]byte{0x20, 0x05}, 3},
+		{[]byte{0xe0}, 9},
+		{nil, 1},
+	}
+	for _, tt := range tests {
+		if _, err := lzfDecompress(tt.in, tt.outLen); err != ErrRDBCorrupt {
+			t.Errorf("%x of %d: expected ErrRDBCorrupt but got %v", tt.in, tt.outLen, err)
+		}
+	}
+}
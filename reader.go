@@ -9,6 +9,7 @@ import (
 	"math/big"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/emirpasic/gods/maps/linkedhashmap"
 )
@@ -23,6 +24,9 @@ var (
 // Reader is reader to parse responses/requests from the underlying reader.
 type Reader struct {
 	*bufio.Reader
+	src   *countReader
+	err   error // set when a read is interrupted in the middle of a value
+	stats stats
 }
 
 // NewReader returns a RESP3 reader.
@@ -32,13 +36,70 @@ func NewReader(reader io.Reader) *Reader {
 
 // NewReaderSize returns a new Reader whose buffer has at least the specified size.
 func NewReaderSize(reader io.Reader, size int) *Reader {
+	src := &countReader{r: reader}
 	return &Reader{
-		Reader: bufio.NewReaderSize(reader, size),
+		Reader: bufio.NewReaderSize(src, size),
+		src:    src,
 	}
 }
 
+// countReader counts the bytes read from the underlying reader.
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	atomic.AddInt64(&c.n, int64(n))
+	return n, err
+}
+
+// count returns the number of bytes read, or 0 if the Reader is made without NewReader.
+func (c *countReader) count() int64 {
+	if c == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&c.n)
+}
+
+// source returns the underlying reader, or nil if the Reader is made without NewReader.
+func (r *Reader) source() io.Reader {
+	if r.src == nil {
+		return nil
+	}
+	return r.src.r
+}
+
+// consumed returns the number of bytes parsed.
+func (r *Reader) consumed() int64 {
+	return r.src.count() - int64(r.Buffered())
+}
+
+// Err returns the error which made the Reader unusable, or nil.
+func (r *Reader) Err() error {
+	return r.err
+}
+
 // ReadValue parses a RESP3 value.
 func (r *Reader) ReadValue() (*Value, []byte, error) {
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	return r.readFrame()
+}
+
+// readFrame reads a top-level value and counts it.
+func (r *Reader) readFrame() (*Value, []byte, error) {
+	before := r.consumed()
+	v, marker, err := r.readValue()
+	if err == nil {
+		r.stats.frame(r.consumed() - before)
+	}
+	return v, marker, err
+}
+
+func (r *Reader) readValue() (*Value, []byte, error) {
 	line, err := r.readLine()
 	if err != nil {
 		return nil, nil, err
@@ -117,10 +178,16 @@ func (r *Reader) ReadValue() (*Value, []byte, error) {
 		v.Boolean, err = r.readBoolean(line)
 	case TypeArray, TypeSet, TypePush:
 		v.Elems, err = r.readArray(line)
+		if err == nil && string(line) == "*-1\r\n" {
+			v.NullArray = true
+		}
 	case TypeMap:
 		v.KV, err = r.readMap(line)
 	}
 
+	if err == nil {
+		r.stats.value(v)
+	}
 	return v, nil, err
 }
 
@@ -170,14 +237,56 @@ func (r *Reader) readNumber(line []byte) (int64, error) {
 
 func (r *Reader) readDouble(line []byte) (float64, error) {
 	v := string(line[1 : len(line)-2])
-	if v == "inf" {
+	switch v {
+	case "inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	case "nan":
 		return math.NaN(), nil
-	} else if v == "-inf" {
-		return -math.NaN(), nil
+	}
+	if !isDouble(v) {
+		return 0, ErrInvalidSyntax
 	}
 	return strconv.ParseFloat(v, 64)
 }
 
+// isDouble checks the double grammar of the spec: [+|-]<digits>[.<digits>][<e|E>[+|-]<digits>].
+// strconv.ParseFloat accepts more forms, like hex floats, "Inf" and underscores.
+func isDouble(v string) bool {
+	i := 0
+	digits := func() bool {
+		start := i
+		for i < len(v) && v[i] >= '0' && v[i] <= '9' {
+			i++
+		}
+		return i > start
+	}
+
+	if i < len(v) && (v[i] == '+' || v[i] == '-') {
+		i++
+	}
+	if !digits() {
+		return false
+	}
+	if i < len(v) && v[i] == '.' {
+		i++
+		if !digits() {
+			return false
+		}
+	}
+	if i < len(v) && (v[i] == 'e' || v[i] == 'E') {
+		i++
+		if i < len(v) && (v[i] == '+' || v[i] == '-') {
+			i++
+		}
+		if !digits() {
+			return false
+		}
+	}
+	return i == len(v)
+}
+
 func (r *Reader) readBigNumber(line []byte) (*big.Int, error) {
 	v := string(line[1 : len(line)-2])
 	i := new(big.Int)
@@ -206,7 +315,7 @@ func (r *Reader) readArray(line []byte) ([]*Value, error) {
 
 	var rt []*Value
 	for i := 0; i < count; i++ {
-		v, streamMarkerPrefix, err := r.ReadValue()
+		v, streamMarkerPrefix, err := r.readValue()
 		if err = isError(err, streamMarkerPrefix); err != nil {
 			return nil, err
 		}
@@ -234,11 +343,11 @@ func (r *Reader) readMap(line []byte) (*linkedhashmap.Map, error) {
 
 	rt := linkedhashmap.New()
 	for i := 0; i < count; i++ {
-		k, streamMarkerPrefix, err := r.ReadValue()
+		k, streamMarkerPrefix, err := r.readValue()
 		if err = isError(err, streamMarkerPrefix); err != nil {
 			return nil, err
 		}
-		v, streamMarkerPrefix, err := r.ReadValue()
+		v, streamMarkerPrefix, err := r.readValue()
 		if err = isError(err, streamMarkerPrefix); err != nil {
 			return nil, err
 		}
@@ -255,11 +364,11 @@ func (r *Reader) readAttr(line []byte) (*linkedhashmap.Map, error) {
 
 	rt := linkedhashmap.New()
 	for i := 0; i < count; i++ {
-		k, streamMarkerPrefix, err := r.ReadValue()
+		k, streamMarkerPrefix, err := r.readValue()
 		if err = isError(err, streamMarkerPrefix); err != nil {
 			return nil, err
 		}
-		v, streamMarkerPrefix, err := r.ReadValue()
+		v, streamMarkerPrefix, err := r.readValue()
 		if err = isError(err, streamMarkerPrefix); err != nil {
 			return nil, err
 		}
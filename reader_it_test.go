@@ -2,13 +2,68 @@ package resp3
 
 import (
 	"net"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
 )
 
+// itDialer returns the dial function of an integration test and a function to call at the end of the test.
+// With RESP3_RECORD=1 the test talks to the local redis and the conversation is recorded to testdata/<name>.jsonl.
+// Otherwise the recording is replayed, so no redis server is needed. The test fails if there is no recording.
+func itDialer(t *testing.T, name string) (func() (net.Conn, error), func()) {
+	file := filepath.Join("testdata", name+".jsonl")
+
+	if os.Getenv("RESP3_RECORD") != "" {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Create(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := NewRecorder(f)
+		dial := func() (net.Conn, error) {
+			conn, err := net.DialTimeout("tcp", "127.0.0.1:6379", 5*time.Second)
+			if err != nil {
+				return nil, err
+			}
+			return rec.Wrap(conn), nil
+		}
+		return dial, func() {
+			if err := rec.Err(); err != nil {
+				t.Errorf("failed to record: %v", err)
+			}
+			f.Close()
+		}
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("no recording %s, record it with RESP3_RECORD=1 and a local redis server", file)
+	}
+	defer f.Close()
+	srv, err := NewReplayServer(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dial := func() (net.Conn, error) {
+		return net.DialTimeout("tcp", srv.Addr(), 5*time.Second)
+	}
+	return dial, func() {
+		srv.Close()
+		if err := srv.Err(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
 func TestReader_IT_Test(t *testing.T) {
-	conn, err := net.DialTimeout("tcp", "127.0.0.1:6379", 5*time.Second)
+	dial, done := itDialer(t, "it_test")
+	defer done()
+
+	conn, err := dial()
 	if err != nil {
 		t.Logf("can't found one of redis 6.0 server")
 		return
@@ -196,7 +251,7 @@ func TestReader_IT_Test(t *testing.T) {
 	t.Logf("SUBSCRIBE result: %c, %+v", resp.Type, resp.SmartResult())
 
 	{
-		conn, err := net.DialTimeout("tcp", "127.0.0.1:6379", 5*time.Second)
+		conn, err := dial()
 		if err != nil {
 			t.Logf("can't found one of redis 6.0 server")
 			return
@@ -221,7 +276,10 @@ func TestReader_IT_Test(t *testing.T) {
 }
 
 func TestReader_IT_Tracking(t *testing.T) {
-	conn, err := net.DialTimeout("tcp", "127.0.0.1:6379", 5*time.Second)
+	dial, done := itDialer(t, "it_tracking")
+	defer done()
+
+	conn, err := dial()
 	if err != nil {
 		t.Logf("can't found one of redis 6.0 server")
 		return
@@ -255,8 +313,10 @@ func TestReader_IT_Tracking(t *testing.T) {
 	}
 	t.Logf("GET result: %c, %+v", resp.Type, resp.SmartResult())
 
+	setDone := make(chan struct{})
 	go func() {
-		conn, err := net.DialTimeout("tcp", "127.0.0.1:9999", 5*time.Second)
+		defer close(setDone)
+		conn, err := dial()
 		if err != nil {
 			t.Logf("can't found one of redis 6.0 server")
 			return
@@ -272,9 +332,10 @@ func TestReader_IT_Tracking(t *testing.T) {
 		for i := 0; i < 10; i++ {
 			//PUBLISH
 			w.WriteCommand("set", "a", strconv.Itoa(i))
-			resp, _, err = r.ReadValue()
+			resp, _, err := r.ReadValue()
 			if err != nil {
-				t.Fatalf("failed to set: %v", err)
+				t.Errorf("failed to set: %v", err)
+				return
 			}
 			t.Logf("set result: %c, %+v", resp.Type, resp.SmartResult())
 			time.Sleep(200 * time.Millisecond)
@@ -295,5 +356,6 @@ func TestReader_IT_Tracking(t *testing.T) {
 			resp, _, err = r.ReadValue()
 		}
 	}
+	<-setDone
 
 }
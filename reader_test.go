@@ -104,24 +104,47 @@ func TestReader_Double(t *testing.T) {
 	if v.Double != 1.23 {
 		t.Errorf("not expected, got %v", v.Double)
 	}
-	buf.Reset()
-	buf.WriteString(",inf\r\n")
-	v, marker, err = reader.ReadValue()
-	if err = isError(err, marker); err != nil {
-		t.Errorf("failed to read: %v", err)
-	}
-	if math.IsInf(v.Double, 1) && v.Double > math.MaxFloat64 {
-		t.Errorf("not expected, got %v", v.Double)
+
+	var doubles = map[string]float64{
+		",inf\r\n":     math.Inf(1),
+		",-inf\r\n":    math.Inf(-1),
+		",-1.5\r\n":    -1.5,
+		",+3\r\n":      3,
+		",1.23e5\r\n":  1.23e5,
+		",1.5E-10\r\n": 1.5e-10,
+		",-2e+300\r\n": -2e300,
+	}
+	for s, d := range doubles {
+		buf.Reset()
+		buf.WriteString(s)
+		v, marker, err = reader.ReadValue()
+		if err = isError(err, marker); err != nil {
+			t.Errorf("failed to read %q: %v", s, err)
+			continue
+		}
+		if v.Double != d {
+			t.Errorf("not expected, got %v for %q", v.Double, s)
+		}
 	}
+
 	buf.Reset()
-	buf.WriteString(",-inf\r\n")
+	buf.WriteString(",nan\r\n")
 	v, marker, err = reader.ReadValue()
 	if err = isError(err, marker); err != nil {
 		t.Errorf("failed to read: %v", err)
 	}
-	if math.IsInf(v.Double, -1) && v.Double < math.MaxFloat64 {
+	if !math.IsNaN(v.Double) {
 		t.Errorf("not expected, got %v", v.Double)
 	}
+
+	for _, s := range []string{",Inf\r\n", ",0x1p-2\r\n", ",1_000\r\n", ",.5\r\n", ",1.\r\n", ",1e\r\n", ",NaN\r\n"} {
+		buf.Reset()
+		buf.WriteString(s)
+		reader = NewReader(buf)
+		if _, _, err = reader.ReadValue(); !errors.Is(err, ErrInvalidSyntax) {
+			t.Errorf("expected ErrInvalidSyntax for %q but got %v", s, err)
+		}
+	}
 }
 
 func TestReader_Null(t *testing.T) {
@@ -146,6 +169,19 @@ func TestReader_Null(t *testing.T) {
 	if !v.NullBulkString {
 		t.Error("the null bulk string is expected to be true")
 	}
+
+	// null array of RESP2, which isn't an empty array
+	buf.Reset()
+	buf.WriteString("*-1\r\n*0\r\n")
+	for _, null := range []bool{true, false} {
+		v, marker, err = reader.ReadValue()
+		if err = isError(err, marker); err != nil {
+			t.Fatalf("failed to read: %v", err)
+		}
+		if v.Type != TypeArray || v.NullArray != null || len(v.Elems) != 0 {
+			t.Errorf("unexpected array %+v", v)
+		}
+	}
 }
 
 func TestReader_Boolean(t *testing.T) {
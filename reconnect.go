@@ -0,0 +1,269 @@
+package resp3
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReconnectConn is a connection which reconnects when it's broken.
+//
+// The connection is dialed again with exponential backoff and jitter, which runs HELLO with AUTH and SETNAME
+// and SELECT of the dial options. The state set by commands is restored after reconnecting:
+// the database selected by SELECT, the name set by CLIENT SETNAME, CLIENT TRACKING and the subscriptions.
+//
+// A command which fails because the connection is broken is retried only if it's idempotent,
+// which is a read-only command by the command metadata, or a subscription.
+// Commands in a transaction are not retried. A ReconnectConn is not safe for concurrent use.
+type ReconnectConn struct {
+	// Dial creates a connection.
+	Dial func(ctx context.Context) (*Conn, error)
+	// MinBackoff is the delay before the first retry, 100 milliseconds if it's 0.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum delay, which doubles after every retry, 10 seconds if it's 0.
+	MaxBackoff time.Duration
+	// MaxRetries is the maximum number of retries of a command, 3 if it's 0. It's disabled if it's negative.
+	MaxRetries int
+	// Commands has the metadata of the commands, the built-in snapshot if it's nil.
+	Commands *CommandRegistry
+	// OnPush is called for push messages, like OnPush of Conn. It must be set before the first command.
+	OnPush func(push *Value)
+
+	c        *Conn
+	db       string
+	name     string
+	tracking []string
+	subs     map[string]map[string]bool // SUBSCRIBE, PSUBSCRIBE or SSUBSCRIBE -> channels
+	closed   bool
+}
+
+// NewReconnectConn returns a connection to the address, which is dialed by the first command.
+func NewReconnectConn(network, address string, opts ...DialOption) *ReconnectConn {
+	return &ReconnectConn{
+		Dial: func(ctx context.Context) (*Conn, error) {
+			return DialContext(ctx, network, address, opts...)
+		},
+	}
+}
+
+// DoContext sends a command and returns its reply like DoContext of Conn,
+// the connection is dialed again if it's broken.
+func (r *ReconnectConn) DoContext(ctx context.Context, args ...string) (*Value, error) {
+	retryable := r.idempotent(args)
+	for attempt := 0; ; attempt++ {
+		c, err := r.conn(ctx)
+		if err == nil {
+			// the transaction is lost with the connection
+			retryable = retryable && !c.multi && !c.watching
+			var v *Value
+			v, err = c.DoContext(ctx, args...)
+			if c.Err() == nil {
+				if err == nil {
+					r.track(args)
+				}
+				return v, err
+			}
+			r.drop()
+			if !retryable {
+				return nil, err
+			}
+		}
+		if ctx.Err() != nil || r.closed || attempt >= r.maxRetries() {
+			return nil, err
+		}
+		if err := r.sleep(ctx, attempt); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ReceiveContext returns the next reply like ReceiveContext of Conn, and push messages are passed to OnPush.
+// The connection is dialed again and the subscriptions are restored if it's broken,
+// so a subscriber receives the push messages until the context is done.
+func (r *ReconnectConn) ReceiveContext(ctx context.Context) (*Value, error) {
+	for attempt := 0; ; attempt++ {
+		c, err := r.conn(ctx)
+		if err == nil {
+			var v *Value
+			v, err = c.ReceiveContext(ctx)
+			if c.Err() == nil {
+				return v, err
+			}
+			r.drop()
+			attempt = -1
+		}
+		if ctx.Err() != nil || r.closed {
+			return nil, err
+		}
+		if attempt < 0 {
+			continue
+		}
+		if err := r.sleep(ctx, attempt); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Close closes the connection, it's not dialed again.
+func (r *ReconnectConn) Close() error {
+	r.closed = true
+	if r.c != nil {
+		return r.c.Close()
+	}
+	return nil
+}
+
+// conn returns the connection, or dials a new one and restores the state.
+func (r *ReconnectConn) conn(ctx context.Context) (*Conn, error) {
+	if r.closed {
+		return nil, ErrConnClosed
+	}
+	if r.c != nil {
+		return r.c, nil
+	}
+	c, err := r.Dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.OnPush = r.OnPush
+	if err := r.restore(ctx, c); err != nil {
+		c.Close()
+		return nil, err
+	}
+	r.c = c
+	return c, nil
+}
+
+func (r *ReconnectConn) drop() {
+	r.c.Close()
+	r.c = nil
+}
+
+// restore sends the commands which set the state of the connection.
+func (r *ReconnectConn) restore(ctx context.Context, c *Conn) error {
+	var cmds [][]string
+	if r.db != "" {
+		cmds = append(cmds, []string{"SELECT", r.db})
+	}
+	if r.name != "" {
+		cmds = append(cmds, []string{"CLIENT", "SETNAME", r.name})
+	}
+	if r.tracking != nil {
+		cmds = append(cmds, r.tracking)
+	}
+	for _, name := range []string{"SUBSCRIBE", "PSUBSCRIBE", "SSUBSCRIBE"} {
+		if len(r.subs[name]) == 0 {
+			continue
+		}
+		cmd := []string{name}
+		for channel := range r.subs[name] {
+			cmd = append(cmd, channel)
+		}
+		sort.Strings(cmd[1:])
+		cmds = append(cmds, cmd)
+	}
+
+	for _, cmd := range cmds {
+		if _, err := c.DoContext(ctx, cmd...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// track records the state set by a command which succeeded.
+func (r *ReconnectConn) track(args []string) {
+	if len(args) == 0 {
+		return
+	}
+	name := strings.ToUpper(args[0])
+	switch name {
+	case "SELECT":
+		if len(args) > 1 {
+			r.db = args[1]
+		}
+	case "CLIENT":
+		switch {
+		case len(args) > 2 && strings.EqualFold(args[1], "SETNAME"):
+			r.name = args[2]
+		case len(args) > 2 && strings.EqualFold(args[1], "TRACKING"):
+			r.tracking = nil
+			if strings.EqualFold(args[2], "ON") {
+				r.tracking = append([]string(nil), args...)
+			}
+		}
+	case "SUBSCRIBE", "PSUBSCRIBE", "SSUBSCRIBE":
+		if r.subs == nil {
+			r.subs = make(map[string]map[string]bool)
+		}
+		if r.subs[name] == nil {
+			r.subs[name] = make(map[string]bool)
+		}
+		for _, channel := range args[1:] {
+			r.subs[name][channel] = true
+		}
+	case "UNSUBSCRIBE", "PUNSUBSCRIBE", "SUNSUBSCRIBE":
+		name = strings.Replace(name, "UN", "", 1)
+		if len(args) == 1 {
+			delete(r.subs, name)
+		}
+		for _, channel := range args[1:] {
+			delete(r.subs[name], channel)
+		}
+	case "RESET":
+		r.db, r.tracking, r.subs = "", nil, nil
+	}
+}
+
+// idempotent checks if a command can be sent again.
+func (r *ReconnectConn) idempotent(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	if isSubscribeCommand(strings.ToUpper(args[0])) {
+		return true
+	}
+	commands := r.Commands
+	if commands == nil {
+		commands = defaultRegistry
+	}
+	info := commands.Lookup(args...)
+	return info != nil && info.ReadOnly()
+}
+
+func (r *ReconnectConn) maxRetries() int {
+	if r.MaxRetries == 0 {
+		return 3
+	}
+	return r.MaxRetries
+}
+
+// backoff returns the delay before a retry, a random duration between the half and the whole
+// of the exponential backoff.
+func (r *ReconnectConn) backoff(attempt int) time.Duration {
+	min, max := r.MinBackoff, r.MaxBackoff
+	if min <= 0 {
+		min = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 10 * time.Second
+	}
+	d := max
+	if attempt < 32 && min<<attempt > 0 && min<<attempt < max {
+		d = min << attempt
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (r *ReconnectConn) sleep(ctx context.Context, attempt int) error {
+	t := time.NewTimer(r.backoff(attempt))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
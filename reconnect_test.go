@@ -0,0 +1,108 @@
+package resp3
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReconnectConn(t *testing.T) {
+	var mu sync.Mutex
+	var cmds []string // commands of the last connection
+	ln := serveFake(t, func(args []string, write func(v *Value)) *Value {
+		mu.Lock()
+		cmds = append(cmds, strings.Join(args, " "))
+		mu.Unlock()
+		switch args[0] {
+		case "SUBSCRIBE", "PSUBSCRIBE":
+			for i, channel := range args[1:] {
+				write(NewPushValue([]*Value{
+					NewBlobStringValue(strings.ToLower(args[0])), NewBlobStringValue(channel), NewNumberValue(int64(i + 1)),
+				}))
+			}
+			return nil
+		case "UNSUBSCRIBE":
+			return NewPushValue([]*Value{NewBlobStringValue("unsubscribe"), NewBlobStringValue(args[1]), NewNumberValue(1)})
+		case "GET":
+			return NewBlobStringValue("v")
+		case "INCR":
+			return NewNumberValue(1)
+		}
+		return NewSimpleStringValue("OK")
+	})
+	defer ln.Close()
+
+	r := NewReconnectConn("tcp", ln.Addr().String())
+	r.MinBackoff = time.Millisecond
+	defer r.Close()
+	ctx := context.Background()
+
+	for _, cmd := range []string{
+		"SELECT 2", "CLIENT SETNAME app", "CLIENT TRACKING ON BCAST",
+		"SUBSCRIBE a b", "PSUBSCRIBE p*", "UNSUBSCRIBE b", "PING",
+	} {
+		if _, err := r.DoContext(ctx, strings.Fields(cmd)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a read-only command is retried on a new connection, which has the same state.
+	// PING made sure the subscriptions were received
+	mu.Lock()
+	cmds = nil
+	mu.Unlock()
+	r.c.NetConn().Close()
+	v, err := r.DoContext(ctx, "GET", "k")
+	if err != nil || v.Str != "v" {
+		t.Fatalf("expected v but got %v, %v", v, err)
+	}
+	expected := []string{
+		"SELECT 2", "CLIENT SETNAME app", "CLIENT TRACKING ON BCAST",
+		"SUBSCRIBE a", "PSUBSCRIBE p*", "GET k",
+	}
+	mu.Lock()
+	if !reflect.DeepEqual(cmds, expected) {
+		t.Errorf("expected %q but got %q", expected, cmds)
+	}
+	mu.Unlock()
+
+	// a write command is not retried
+	r.c.NetConn().Close()
+	if _, err := r.DoContext(ctx, "INCR", "k"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if v, err := r.DoContext(ctx, "INCR", "k"); err != nil || v.Integer != 1 {
+		t.Fatalf("expected 1 but got %v, %v", v, err)
+	}
+}
+
+func TestReconnectConn_Retries(t *testing.T) {
+	dialErr := errors.New("refused")
+	dials := 0
+	r := &ReconnectConn{
+		Dial: func(ctx context.Context) (*Conn, error) {
+			dials++
+			return nil, dialErr
+		},
+		MinBackoff: time.Millisecond,
+		MaxRetries: 2,
+	}
+	if _, err := r.DoContext(context.Background(), "SET", "k", "v"); err != dialErr || dials != 3 {
+		t.Errorf("expected 3 dials but got %d, %v", dials, err)
+	}
+
+	r.MaxBackoff = 8 * time.Millisecond
+	for attempt, max := range []time.Duration{1, 2, 4, 8, 8} {
+		max *= time.Millisecond
+		if d := r.backoff(attempt); d < max/2 || d > max {
+			t.Errorf("expected a backoff between %v and %v but got %v", max/2, max, d)
+		}
+	}
+	if d := r.backoff(100); d > r.MaxBackoff {
+		t.Errorf("expected at most %v but got %v", r.MaxBackoff, d)
+	}
+}
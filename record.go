@@ -0,0 +1,323 @@
+package resp3
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// ErrReplayMismatch is reported by a ReplayServer when a client sends an unexpected command.
+var ErrReplayMismatch = errors.New("resp: replay mismatch")
+
+// recordEvent is a line of a recording, a frame sent or received by one connection.
+type recordEvent struct {
+	Conn int    `json:"conn"`
+	Send string `json:"send,omitempty"`
+	Recv string `json:"recv,omitempty"`
+}
+
+// Recorder records the frames of client connections as JSON lines,
+// in the order they are written to and read from the connections.
+type Recorder struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	conns int
+	err   error
+}
+
+// NewRecorder returns a Recorder which writes the recording to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Wrap returns a connection which records everything written to and read from conn.
+// Every wrapped connection is a separate session of the recording.
+func (r *Recorder) Wrap(conn net.Conn) net.Conn {
+	r.mu.Lock()
+	r.conns++
+	id := r.conns
+	r.mu.Unlock()
+
+	return &recordConn{Conn: conn, rec: r, id: id}
+}
+
+// Err returns the first error of writing the recording.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) record(e recordEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(e); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+type recordConn struct {
+	net.Conn
+	rec *Recorder
+	id  int
+
+	sent, received framer
+}
+
+func (c *recordConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	for _, f := range c.received.feed(p[:n]) {
+		c.rec.record(recordEvent{Conn: c.id, Recv: string(f)})
+	}
+	return n, err
+}
+
+func (c *recordConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	for _, f := range c.sent.feed(p[:n]) {
+		c.rec.record(recordEvent{Conn: c.id, Send: string(f)})
+	}
+	return n, err
+}
+
+// framer splits a byte stream into RESP3 frames.
+// The parsed part of an incomplete frame is kept, so a frame is parsed only once however it's split.
+type framer struct {
+	mu   sync.Mutex
+	buf  []byte
+	pos  int // end of the values parsed in buf
+	need int // values still needed to complete the frame
+}
+
+// feed appends data and returns the complete frames.
+func (f *framer) feed(data []byte) [][]byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.buf = append(f.buf, data...)
+	var frames [][]byte
+	for len(f.buf) > 0 {
+		if f.need == 0 {
+			f.need = 1
+		}
+		ok, err := f.next()
+		if err != nil {
+			// keep invalid data in the recording as it is
+			f.pos, f.need = len(f.buf), 0
+		} else if !ok {
+			break
+		}
+		if f.need == 0 {
+			frames = append(frames, append([]byte(nil), f.buf[:f.pos]...))
+			f.buf = f.buf[f.pos:]
+			f.pos = 0
+		}
+	}
+	return frames
+}
+
+// next parses the header of the next value and the data of a blob, and returns false if it isn't complete yet.
+func (f *framer) next() (bool, error) {
+	end := bytes.Index(f.buf[f.pos:], CRLFByte)
+	if end < 0 {
+		return false, nil
+	}
+	line := f.buf[f.pos : f.pos+end+2]
+	if len(line) < 3 {
+		return false, ErrInvalidSyntax
+	}
+
+	n := 0
+	switch line[0] {
+	case TypeBlobString, TypeBlobError, TypeVerbatimString, TypeArray, TypeSet, TypePush, TypeMap, TypeAttribute:
+		var err error
+		if n, err = strconv.Atoi(string(line[1 : len(line)-2])); err != nil || n < -1 {
+			return false, ErrInvalidSyntax
+		}
+	}
+
+	size := len(line)
+	switch line[0] {
+	case TypeBlobString, TypeBlobError, TypeVerbatimString:
+		if n >= 0 {
+			size += n + 2
+		}
+		if f.pos+size > len(f.buf) {
+			return false, nil
+		}
+	case TypeArray, TypeSet, TypePush:
+		if n > 0 {
+			f.need += n
+		}
+	case TypeMap:
+		if n > 0 {
+			f.need += 2 * n
+		}
+	case TypeAttribute:
+		if n < 0 {
+			return false, ErrInvalidSyntax
+		}
+		// the attribute comes before the value it belongs to
+		f.need += 2*n + 1
+	}
+	f.pos += size
+	f.need--
+	return true, nil
+}
+
+// ReplayServer serves recorded responses. The n-th accepted connection replays the n-th session of the recording:
+// every command must be the next recorded command and is answered with the frames recorded after it.
+type ReplayServer struct {
+	ln       net.Listener
+	sessions [][]recordEvent
+
+	mu     sync.Mutex
+	next   int
+	err    error
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewReplayServer starts a replay server for a recording written by a Recorder on a random local port.
+func NewReplayServer(recording io.Reader) (*ReplayServer, error) {
+	var sessions [][]recordEvent
+	index := make(map[int]int)
+
+	scanner := bufio.NewScanner(recording)
+	scanner.Buffer(make([]byte, 64*1024), 512*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e recordEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, err
+		}
+		i, ok := index[e.Conn]
+		if !ok {
+			i = len(sessions)
+			index[e.Conn] = i
+			sessions = append(sessions, nil)
+		}
+		sessions[i] = append(sessions[i], e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &ReplayServer{ln: ln, sessions: sessions, conns: make(map[net.Conn]struct{})}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *ReplayServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Err returns the first mismatch between the recording and the commands of the clients.
+func (s *ReplayServer) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close stops the server, closes the connections and waits for the sessions to finish.
+func (s *ReplayServer) Close() error {
+	err := s.ln.Close()
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *ReplayServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		var session []recordEvent
+		if s.next < len(s.sessions) {
+			session = s.sessions[s.next]
+		}
+		s.next++
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go func() {
+			defer s.wg.Done()
+			replay(conn, session, s.fail)
+
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+func (s *ReplayServer) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// replay plays a session on conn. A mismatch is reported to fail before the error reply is sent to the client.
+func replay(conn net.Conn, session []recordEvent, fail func(error)) {
+	r := NewReader(conn)
+	for _, e := range session {
+		// the client may go before the end of the recording, that's not a mismatch
+		if e.Send == "" {
+			if _, err := io.WriteString(conn, e.Recv); err != nil {
+				return
+			}
+			continue
+		}
+
+		cmd, err := r.ReadRaw()
+		if err != nil {
+			return
+		}
+		if string(cmd) != e.Send {
+			err := fmt.Errorf("%w: expected %q but got %q", ErrReplayMismatch, e.Send, cmd)
+			fail(err)
+			io.WriteString(conn, NewSimpleErrorValue(err).ToRESP3String())
+			return
+		}
+	}
+
+	// wait for the client to close the connection, more commands are not expected
+	if cmd, err := r.ReadRaw(); err == nil {
+		err := fmt.Errorf("%w: unexpected %q after the end of the recording", ErrReplayMismatch, cmd)
+		fail(err)
+		io.WriteString(conn, NewSimpleErrorValue(err).ToRESP3String())
+	}
+}
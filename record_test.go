@@ -0,0 +1,136 @@
+package resp3
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordReplay(t *testing.T) {
+	// PING is answered with PONG, and a push message is sent before the reply of GET
+	ln := serveFake(t, func(args []string, write func(v *Value)) *Value {
+		switch args[0] {
+		case "PING":
+			return NewSimpleStringValue("PONG")
+		case "GET":
+			write(mustValue(t, ">2\r\n+invalidate\r\n*1\r\n$1\r\na\r\n"))
+			return NewBlobStringValue("1")
+		}
+		return nil
+	})
+	defer ln.Close()
+
+	// record
+	var recording bytes.Buffer
+	rec := NewRecorder(&recording)
+	conn, err := net.DialTimeout("tcp", ln.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn = rec.Wrap(conn)
+	expected := talk(t, conn)
+	conn.Close()
+	if rec.Err() != nil {
+		t.Fatal(rec.Err())
+	}
+
+	// replay
+	srv, err := NewReplayServer(bytes.NewReader(recording.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err = net.DialTimeout("tcp", srv.Addr(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replies := talk(t, conn)
+	conn.Close()
+	srv.Close()
+	if srv.Err() != nil {
+		t.Fatal(srv.Err())
+	}
+	if len(replies) != len(expected) {
+		t.Fatalf("expected %q but got %q", expected, replies)
+	}
+	for i := range expected {
+		if replies[i] != expected[i] {
+			t.Errorf("expected %q but got %q", expected[i], replies[i])
+		}
+	}
+
+	// mismatch
+	srv, err = NewReplayServer(bytes.NewReader(recording.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	conn, err = net.DialTimeout("tcp", srv.Addr(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	NewWriter(conn).WriteCommand("GET", "b")
+	v, _, err := NewReader(conn).ReadValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Type != TypeSimpleError || !errors.Is(srv.Err(), ErrReplayMismatch) {
+		t.Errorf("expected a mismatch but got %s, %v", v.ToRESP3String(), srv.Err())
+	}
+}
+
+// talk sends PING and GET a, and returns the encoded push message and replies.
+func talk(t *testing.T, conn net.Conn) []string {
+	w := NewWriter(conn)
+	r := NewReader(conn)
+
+	var rt []string
+	w.WriteCommand("PING")
+	w.WriteCommand("GET", "a")
+	for i := 0; i < 3; i++ {
+		v, _, err := r.ReadValue()
+		if err != nil {
+			t.Fatalf("failed to read: %v", err)
+		}
+		rt = append(rt, v.ToRESP3String())
+	}
+	return rt
+}
+
+func TestFramer(t *testing.T) {
+	frames := []string{
+		"+OK\r\n",
+		"$5\r\nhello\r\n",
+		"$-1\r\n",
+		"*-1\r\n",
+		"*2\r\n$3\r\nGET\r\n$1\r\nk\r\n",
+		"|1\r\n+ttl\r\n:10\r\n%1\r\n+a\r\n*2\r\n:1\r\n~1\r\n_\r\n",
+		">3\r\n$7\r\nmessage\r\n$2\r\nch\r\n=8\r\ntxt:\r\nhi\r\n",
+		"*0\r\n",
+	}
+	stream := strings.Join(frames, "")
+
+	// in one piece and byte by byte
+	for _, size := range []int{len(stream), 1} {
+		var f framer
+		var got []string
+		for i := 0; i < len(stream); i += size {
+			for _, frame := range f.feed([]byte(stream[i : i+size])) {
+				got = append(got, string(frame))
+			}
+		}
+		if !reflect.DeepEqual(got, frames) {
+			t.Errorf("expected %q but got %q", frames, got)
+		}
+	}
+
+	// invalid data is kept as it is
+	var f framer
+	if got := f.feed([]byte(":1\r\n*x\r\n:2\r\n")); len(got) != 2 || string(got[1]) != "*x\r\n:2\r\n" {
+		t.Errorf("unexpected frames %q", got)
+	}
+}
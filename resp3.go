@@ -14,14 +14,6 @@ const CRLF = "\r\n"
 var CRLFByte = []byte(CRLF)
 var StreamMarkerPrefix = []byte("$EOF:")
 
-type floatInfo struct {
-	mantbits uint
-	expbits  uint
-	bias     int
-}
-
-var float64info = floatInfo{52, 11, -1023}
-
 // https://github.com/antirez/RESP3/blob/master/spec.md
 
 // resp3 type char
@@ -68,6 +60,7 @@ type Value struct {
 	Attrs          *linkedhashmap.Map
 	StreamMarker   string
 	NullBulkString bool
+	NullArray      bool // for the RESP2 null array *-1
 }
 
 // SmartResult converts itself to a real object.
@@ -110,6 +103,9 @@ func (r *Value) SmartResult() interface{} {
 	case TypeBoolean:
 		return r.Boolean
 	case TypeArray, TypeSet, TypePush:
+		if r.NullArray {
+			return nil
+		}
 		var rt []interface{}
 		for _, elem := range r.Elems {
 			rt = append(rt, elem.SmartResult())
@@ -180,22 +176,7 @@ func (r *Value) toRESP3String(buf *strings.Builder) {
 	case TypeNumber:
 		buf.WriteString(strconv.FormatInt(r.Integer, 10))
 	case TypeDouble:
-		bits := math.Float64bits(r.Double)
-		flt := &float64info
-		neg := bits>>(flt.expbits+flt.mantbits) != 0
-		exp := int(bits>>flt.mantbits) & (1<<flt.expbits - 1)
-
-		switch exp {
-		case 1<<flt.expbits - 1:
-			if neg {
-				buf.WriteString("-inf")
-			} else {
-				buf.WriteString("inf")
-			}
-		default:
-			buf.WriteString(strconv.FormatFloat(r.Double, 'f', -1, 64))
-		}
-
+		buf.WriteString(formatDouble(r.Double))
 	case TypeBigNumber:
 		buf.WriteString(r.BigInt.String())
 	case TypeNull:
@@ -207,6 +188,11 @@ func (r *Value) toRESP3String(buf *strings.Builder) {
 			buf.WriteByte('f')
 		}
 	case TypeArray, TypeSet, TypePush:
+		if r.NullArray {
+			buf.WriteString("-1")
+			buf.Write(CRLFByte)
+			return
+		}
 		buf.WriteString(strconv.Itoa(len(r.Elems)))
 		buf.Write(CRLFByte)
 
@@ -234,6 +220,70 @@ func (r *Value) toRESP3String(buf *strings.Builder) {
 	buf.Write(CRLFByte)
 }
 
+// ToRESP2String converts this value to redis RESP2 string, the way redis replies to RESP2 clients.
+// Attributes are dropped.
+// Map -> flat array of keys and values
+// Set, Push -> array
+// Null -> null bulk string, a null array of RESP2 stays a null array
+// (redis replies null arrays to RESP2 clients for some commands, which are nulls in RESP3)
+// Double, BigNumber, VerbatimString -> bulk string
+// Boolean -> integer 1 or 0
+// BlobError -> simple error
+func (r *Value) ToRESP2String() string {
+	buf := new(strings.Builder)
+	r.toRESP2String(buf)
+	return buf.String()
+}
+
+func (r *Value) toRESP2String(buf *strings.Builder) {
+	switch r.Type {
+	case TypeSimpleString, TypeSimpleError, TypeNumber:
+		buf.WriteByte(r.Type)
+		r.toRESP3String(buf)
+	case TypeBlobString:
+		buf.WriteByte(TypeBlobString)
+		r.toRESP3String(buf)
+	case TypeBlobError:
+		buf.WriteByte(TypeSimpleError)
+		buf.WriteString(strings.NewReplacer("\r", " ", "\n", " ").Replace(r.Err))
+		buf.Write(CRLFByte)
+	case TypeNull:
+		buf.WriteString("$-1\r\n")
+	case TypeDouble:
+		NewBlobStringValue(formatDouble(r.Double)).toRESP2String(buf)
+	case TypeBigNumber:
+		NewBlobStringValue(r.BigInt.String()).toRESP2String(buf)
+	case TypeVerbatimString:
+		NewBlobStringValue(r.Str).toRESP2String(buf)
+	case TypeBoolean:
+		if r.Boolean {
+			buf.WriteString(":1\r\n")
+		} else {
+			buf.WriteString(":0\r\n")
+		}
+	case TypeArray, TypeSet, TypePush:
+		if r.NullArray {
+			buf.WriteString("*-1\r\n")
+			return
+		}
+		buf.WriteByte(TypeArray)
+		buf.WriteString(strconv.Itoa(len(r.Elems)))
+		buf.Write(CRLFByte)
+		for _, v := range r.Elems {
+			v.toRESP2String(buf)
+		}
+	case TypeMap, TypeAttribute:
+		keys, values := mapEntries(r.KV)
+		buf.WriteByte(TypeArray)
+		buf.WriteString(strconv.Itoa(2 * len(keys)))
+		buf.Write(CRLFByte)
+		for i := range keys {
+			keys[i].toRESP2String(buf)
+			values[i].toRESP2String(buf)
+		}
+	}
+}
+
 // NewBlobStringValue make a value with type BlobString
 func NewBlobStringValue(s string) *Value {
 	return &Value{Type: TypeBlobString, Str: s}
@@ -308,3 +358,21 @@ func NewAttributeValue(attrs *linkedhashmap.Map) *Value {
 func NewPushValue(elems []*Value) *Value {
 	return &Value{Type: TypePush, Elems: elems}
 }
+
+// formatDouble formats a double in the shortest form which parses back to the same float64.
+// The exponent form is only used for very large or very small numbers.
+func formatDouble(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-4 || abs >= 1e21) {
+		return strconv.FormatFloat(f, 'e', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -4,6 +4,7 @@ import (
 	"math"
 	"math/big"
 	"testing"
+	"testing/quick"
 
 	"github.com/emirpasic/gods/maps/linkedhashmap"
 )
@@ -113,26 +114,55 @@ func TestDouble(t *testing.T) {
 		t.Errorf("expected %s but got %s", expected, s)
 	}
 
-	v = &Value{
-		Type:   TypeDouble,
-		Double: math.NaN(),
+	var doubles = map[float64]string{
+		math.Inf(1):     ",inf\r\n",
+		math.Inf(-1):    ",-inf\r\n",
+		0:               ",0\r\n",
+		-2.5:            ",-2.5\r\n",
+		1e21:            ",1e+21\r\n",
+		1.5e-7:          ",1.5e-07\r\n",
+		123456789.125:   ",123456789.125\r\n",
+		math.MaxFloat64: ",1.7976931348623157e+308\r\n",
+	}
+	for d, expected := range doubles {
+		s = NewDoubleValue(d).ToRESP3String()
+		if s != expected {
+			t.Errorf("expected %s but got %s", expected, s)
+		}
 	}
 
-	s = v.ToRESP3String()
-	expected = ",inf\r\n"
+	s = NewDoubleValue(math.NaN()).ToRESP3String()
+	expected = ",nan\r\n"
 	if s != expected {
 		t.Errorf("expected %s but got %s", expected, s)
 	}
+}
 
-	v = &Value{
-		Type:   TypeDouble,
-		Double: -math.NaN(),
+func TestDouble_RoundTrip(t *testing.T) {
+	// any float64 survives ToRESP3String and FromString bit by bit
+	f := func(bits uint64) bool {
+		d := math.Float64frombits(bits)
+		v, err := FromString(NewDoubleValue(d).ToRESP3String())
+		if err != nil || v.Type != TypeDouble {
+			return false
+		}
+		if math.IsNaN(d) {
+			return math.IsNaN(v.Double)
+		}
+		return math.Float64bits(v.Double) == bits
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 10000}); err != nil {
+		t.Error(err)
 	}
 
-	s = v.ToRESP3String()
-	expected = ",-inf\r\n"
-	if s != expected {
-		t.Errorf("expected %s but got %s", expected, s)
+	// the encoded form is canonical, so encoding a parsed value gives the same string
+	g := func(d float64) bool {
+		s := NewDoubleValue(d).ToRESP3String()
+		v, err := FromString(s)
+		return err == nil && v.ToRESP3String() == s
+	}
+	if err := quick.Check(g, nil); err != nil {
+		t.Error(err)
 	}
 }
 
@@ -373,6 +403,33 @@ func TestPush(t *testing.T) {
 	}
 }
 
+func TestToRESP2String(t *testing.T) {
+	var cases = map[string]string{
+		"+OK\r\n":  "+OK\r\n",
+		"$-1\r\n":  "$-1\r\n",
+		"_\r\n":    "$-1\r\n",
+		"*-1\r\n":  "*-1\r\n",
+		",1.5\r\n": "$3\r\n1.5\r\n",
+		"#t\r\n":   ":1\r\n",
+		"(3492890328409238509324850943850943825024385\r\n": "$43\r\n3492890328409238509324850943850943825024385\r\n",
+		"=15\r\ntxt:Some string\r\n":                       "$11\r\nSome string\r\n",
+		"!22\r\nSYNTAX invalid\r\nsyntax\r\n":              "-SYNTAX invalid  syntax\r\n",
+		"%2\r\n+a\r\n:1\r\n+b\r\n_\r\n":                    "*4\r\n+a\r\n:1\r\n+b\r\n$-1\r\n",
+		"~2\r\n:1\r\n#f\r\n":                               "*2\r\n:1\r\n:0\r\n",
+		">2\r\n$7\r\nmessage\r\n$2\r\nhi\r\n":              "*2\r\n$7\r\nmessage\r\n$2\r\nhi\r\n",
+		"|1\r\n+ttl\r\n:3600\r\n*1\r\n:2039123\r\n":        "*1\r\n:2039123\r\n",
+	}
+	for data, expected := range cases {
+		v, err := FromString(data)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", data, err)
+		}
+		if s := v.ToRESP2String(); s != expected {
+			t.Errorf("expected %q but got %q", expected, s)
+		}
+	}
+}
+
 func TestStream(t *testing.T) {
 
 }
@@ -0,0 +1,168 @@
+package resp3
+
+import (
+	"context"
+	"strconv"
+)
+
+// ScanOption sets an option of SCAN, SSCAN, HSCAN and ZSCAN.
+type ScanOption func(*scanOptions)
+
+type scanOptions struct {
+	match string
+	count int
+	typ   string
+}
+
+// ScanMatch only returns the elements which match the glob pattern.
+func ScanMatch(pattern string) ScanOption {
+	return func(o *scanOptions) {
+		o.match = pattern
+	}
+}
+
+// ScanCount hints the server how many elements to return in a batch.
+func ScanCount(count int) ScanOption {
+	return func(o *scanOptions) {
+		o.count = count
+	}
+}
+
+// ScanType only returns the keys of the type, like string or hash. It's only supported by SCAN.
+func ScanType(typ string) ScanOption {
+	return func(o *scanOptions) {
+		o.typ = typ
+	}
+}
+
+// ScanIterator iterates over the elements returned by SCAN, SSCAN, HSCAN or ZSCAN.
+// Batches are fetched when they are needed, until the cursor returned by the server is "0".
+// As SCAN guarantees, an element may be returned more than once.
+//
+//	it := resp3.HScan(conn, "hash", resp3.ScanMatch("user:*"))
+//	for it.Next(ctx) {
+//		fmt.Println(it.Element().Str, it.Value().Str)
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type ScanIterator struct {
+	d      Doer
+	cmd    string
+	key    string
+	opts   scanOptions
+	pairs  bool
+	cursor string
+
+	batch []*Value
+	elem  *Value
+	value *Value
+	err   error
+}
+
+// Scan iterates over the keys of the database.
+func Scan(d Doer, opts ...ScanOption) *ScanIterator {
+	return newScanIterator(d, "SCAN", "", false, opts)
+}
+
+// SScan iterates over the members of a set.
+func SScan(d Doer, key string, opts ...ScanOption) *ScanIterator {
+	return newScanIterator(d, "SSCAN", key, false, opts)
+}
+
+// HScan iterates over the fields and values of a hash.
+func HScan(d Doer, key string, opts ...ScanOption) *ScanIterator {
+	return newScanIterator(d, "HSCAN", key, true, opts)
+}
+
+// ZScan iterates over the members and scores of a sorted set.
+func ZScan(d Doer, key string, opts ...ScanOption) *ScanIterator {
+	return newScanIterator(d, "ZSCAN", key, true, opts)
+}
+
+func newScanIterator(d Doer, cmd, key string, pairs bool, opts []ScanOption) *ScanIterator {
+	it := &ScanIterator{d: d, cmd: cmd, key: key, pairs: pairs}
+	for _, opt := range opts {
+		opt(&it.opts)
+	}
+	return it
+}
+
+// Next advances to the next element. It returns false at the end or on an error, which is returned by Err.
+func (it *ScanIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	for len(it.batch) == 0 {
+		if it.cursor == "0" {
+			return false
+		}
+		if err := it.fetch(ctx); err != nil {
+			it.err = err
+			return false
+		}
+	}
+
+	it.elem, it.value = it.batch[0], nil
+	if it.pairs {
+		it.value = it.batch[1]
+		it.batch = it.batch[2:]
+	} else {
+		it.batch = it.batch[1:]
+	}
+	return true
+}
+
+func (it *ScanIterator) fetch(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	cursor := it.cursor
+	if cursor == "" {
+		cursor = "0"
+	}
+	args := []string{it.cmd}
+	if it.cmd != "SCAN" {
+		args = append(args, it.key)
+	}
+	args = append(args, cursor)
+	if it.opts.match != "" {
+		args = append(args, "MATCH", it.opts.match)
+	}
+	if it.opts.count > 0 {
+		args = append(args, "COUNT", strconv.Itoa(it.opts.count))
+	}
+	if it.opts.typ != "" {
+		args = append(args, "TYPE", it.opts.typ)
+	}
+
+	v, err := it.d.DoContext(ctx, args...)
+	if err != nil {
+		return err
+	}
+	// a cursor and a batch
+	if v.Type != TypeArray || len(v.Elems) != 2 || v.Elems[1].Type != TypeArray ||
+		(it.pairs && len(v.Elems[1].Elems)%2 != 0) {
+		return ErrUnexpectedReply
+	}
+	it.cursor = v.Elems[0].Str
+	it.batch = v.Elems[1].Elems
+	return nil
+}
+
+// Element returns the current element: a key, a member of a set, a field of a hash or a member of a sorted set.
+func (it *ScanIterator) Element() *Value {
+	return it.elem
+}
+
+// Value returns the value of the current field of HSCAN, or the score of the current member of ZSCAN.
+// It returns nil for SCAN and SSCAN.
+func (it *ScanIterator) Value() *Value {
+	return it.value
+}
+
+// Err returns the error which stopped the iteration.
+func (it *ScanIterator) Err() error {
+	return it.err
+}
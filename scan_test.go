@@ -0,0 +1,111 @@
+package resp3
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type doerFunc func(ctx context.Context, args ...string) (*Value, error)
+
+func (f doerFunc) DoContext(ctx context.Context, args ...string) (*Value, error) {
+	return f(ctx, args...)
+}
+
+// scanDoer replies the elements in batches of two, the cursor is the index of the next batch.
+// The second batch is empty, as SCAN may return.
+func scanDoer(elems []string, calls *[][]string) Doer {
+	batches := [][]string{elems[:2], nil}
+	for i := 2; i < len(elems); i += 2 {
+		end := i + 2
+		if end > len(elems) {
+			end = len(elems)
+		}
+		batches = append(batches, elems[i:end])
+	}
+
+	return doerFunc(func(ctx context.Context, args ...string) (*Value, error) {
+		*calls = append(*calls, args)
+		i := 1
+		if args[0] != "SCAN" {
+			i = 2
+		}
+		cursor, _ := strconv.Atoi(args[i])
+
+		var batch []*Value
+		for _, elem := range batches[cursor] {
+			batch = append(batch, NewBlobStringValue(elem))
+		}
+		next := strconv.Itoa((cursor + 1) % len(batches))
+		return NewArrayValue([]*Value{NewBlobStringValue(next), NewArrayValue(batch)}), nil
+	})
+}
+
+func TestScan(t *testing.T) {
+	var calls [][]string
+	d := scanDoer([]string{"a", "b", "c", "d", "e"}, &calls)
+	it := Scan(d, ScanMatch("*"), ScanCount(2), ScanType("string"))
+
+	var keys []string
+	for it.Next(context.Background()) {
+		if it.Value() != nil {
+			t.Errorf("unexpected value %v", it.Value())
+		}
+		keys = append(keys, it.Element().Str)
+	}
+	if it.Err() != nil {
+		t.Fatal(it.Err())
+	}
+	if !reflect.DeepEqual(keys, []string{"a", "b", "c", "d", "e"}) {
+		t.Errorf("unexpected keys %q", keys)
+	}
+	if len(calls) != 4 || !reflect.DeepEqual(calls[0], []string{"SCAN", "0", "MATCH", "*", "COUNT", "2", "TYPE", "string"}) {
+		t.Errorf("unexpected calls %q", calls)
+	}
+	if it.Next(context.Background()) {
+		t.Errorf("expected the end")
+	}
+}
+
+func TestHScan(t *testing.T) {
+	var calls [][]string
+	d := scanDoer([]string{"f1", "v1", "f2", "v2"}, &calls)
+	it := HScan(d, "h")
+
+	var pairs []string
+	for it.Next(context.Background()) {
+		pairs = append(pairs, it.Element().Str+"="+it.Value().Str)
+	}
+	if it.Err() != nil || !reflect.DeepEqual(pairs, []string{"f1=v1", "f2=v2"}) {
+		t.Errorf("unexpected pairs %q, %v", pairs, it.Err())
+	}
+	if calls[0][0] != "HSCAN" || calls[0][1] != "h" || calls[0][2] != "0" {
+		t.Errorf("unexpected calls %q", calls)
+	}
+
+	// an odd batch
+	bad := doerFunc(func(ctx context.Context, args ...string) (*Value, error) {
+		return FromString("*2\r\n$1\r\n0\r\n*1\r\n$1\r\na\r\n")
+	})
+	it = ZScan(bad, "z")
+	if it.Next(context.Background()) || it.Err() != ErrUnexpectedReply {
+		t.Errorf("expected ErrUnexpectedReply but got %v", it.Err())
+	}
+}
+
+func TestScan_Context(t *testing.T) {
+	var calls [][]string
+	d := scanDoer([]string{"a", "b", "c", "d", "e"}, &calls)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	it := SScan(d, "s")
+	n := 0
+	for it.Next(ctx) {
+		n++
+		cancel()
+	}
+	if n != 2 || it.Err() != context.Canceled {
+		t.Errorf("expected to stop after the first batch but got %d, %v", n, it.Err())
+	}
+}
@@ -0,0 +1,111 @@
+package resp3
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Script is a Lua script. It's run by EVALSHA and loaded with SCRIPT LOAD if the server doesn't have it.
+//
+// The reply is returned as it is, so a script which calls redis.setresp(3) and returns RESP3 types,
+// like maps, doubles and booleans, keeps them.
+type Script struct {
+	src  string
+	hash string
+}
+
+// NewScript returns a script with the source.
+func NewScript(src string) *Script {
+	sum := sha1.Sum([]byte(src))
+	return &Script{src: src, hash: hex.EncodeToString(sum[:])}
+}
+
+// Hash returns the SHA1 digest of the script.
+func (s *Script) Hash() string {
+	return s.hash
+}
+
+// Load loads the script with SCRIPT LOAD.
+func (s *Script) Load(ctx context.Context, d Doer) error {
+	_, err := d.DoContext(ctx, "SCRIPT", "LOAD", s.src)
+	return err
+}
+
+// Run runs the script with EVALSHA. If the server returns NOSCRIPT, the script is loaded and run again.
+func (s *Script) Run(ctx context.Context, d Doer, keys []string, args ...string) (*Value, error) {
+	return s.run(ctx, d, "EVALSHA", keys, args)
+}
+
+// RunRO is like Run but it runs the script with EVALSHA_RO, which can be sent to replicas.
+func (s *Script) RunRO(ctx context.Context, d Doer, keys []string, args ...string) (*Value, error) {
+	return s.run(ctx, d, "EVALSHA_RO", keys, args)
+}
+
+func (s *Script) run(ctx context.Context, d Doer, cmd string, keys, args []string) (*Value, error) {
+	v, err := d.DoContext(ctx, callArgs(cmd, s.hash, keys, args)...)
+	if !hasErrorCode(err, "NOSCRIPT") {
+		return v, err
+	}
+	if err := s.Load(ctx, d); err != nil {
+		return nil, err
+	}
+	return d.DoContext(ctx, callArgs(cmd, s.hash, keys, args)...)
+}
+
+// Library is a function library of redis 7. It's loaded with FUNCTION LOAD if one of its functions isn't found.
+type Library struct {
+	code string
+}
+
+// NewLibrary returns a library with the code, which starts with a shebang like "#!lua name=mylib".
+func NewLibrary(code string) *Library {
+	return &Library{code: code}
+}
+
+// Load loads the library with FUNCTION LOAD REPLACE and returns its name.
+func (l *Library) Load(ctx context.Context, d Doer) (string, error) {
+	v, err := d.DoContext(ctx, "FUNCTION", "LOAD", "REPLACE", l.code)
+	if err != nil {
+		return "", err
+	}
+	return v.Str, nil
+}
+
+// Call calls a function of the library with FCALL. If the function isn't found, the library is loaded and the function is called again.
+func (l *Library) Call(ctx context.Context, d Doer, function string, keys []string, args ...string) (*Value, error) {
+	return l.call(ctx, d, "FCALL", function, keys, args)
+}
+
+// CallRO is like Call but it calls a read-only function with FCALL_RO.
+func (l *Library) CallRO(ctx context.Context, d Doer, function string, keys []string, args ...string) (*Value, error) {
+	return l.call(ctx, d, "FCALL_RO", function, keys, args)
+}
+
+func (l *Library) call(ctx context.Context, d Doer, cmd, function string, keys, args []string) (*Value, error) {
+	v, err := d.DoContext(ctx, callArgs(cmd, function, keys, args)...)
+	var e Error
+	if !errors.As(err, &e) || !strings.Contains(string(e), "Function not found") {
+		return v, err
+	}
+	if _, err := l.Load(ctx, d); err != nil {
+		return nil, err
+	}
+	return d.DoContext(ctx, callArgs(cmd, function, keys, args)...)
+}
+
+// callArgs builds EVALSHA or FCALL: cmd name numkeys keys... args...
+func callArgs(cmd, name string, keys, args []string) []string {
+	rt := make([]string, 0, 3+len(keys)+len(args))
+	rt = append(rt, cmd, name, strconv.Itoa(len(keys)))
+	rt = append(rt, keys...)
+	return append(rt, args...)
+}
+
+func hasErrorCode(err error, code string) bool {
+	var e Error
+	return errors.As(err, &e) && e.Code() == code
+}
@@ -0,0 +1,116 @@
+package resp3
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// scriptHandler is a fake server with SCRIPT LOAD, EVALSHA, FUNCTION LOAD and FCALL, and returns the number of loads.
+// Scripts and functions reply a map with a double and a boolean, like a script which calls redis.setresp(3).
+func scriptHandler(t *testing.T) (fakeHandler, func() int) {
+	var mu sync.Mutex
+	scripts := make(map[string]bool)
+	functions := make(map[string]bool)
+	loads := 0
+	reply := mustValue(t, "%2\r\n+score\r\n,1.5\r\n+ok\r\n#t\r\n")
+
+	handle := func(args []string, write func(v *Value)) *Value {
+		mu.Lock()
+		defer mu.Unlock()
+		switch strings.ToUpper(args[0]) {
+		case "SCRIPT":
+			loads++
+			sha := NewScript(args[2]).Hash()
+			scripts[sha] = true
+			return NewBlobStringValue(sha)
+		case "EVALSHA", "EVALSHA_RO":
+			if !scripts[args[1]] {
+				return &Value{Type: TypeSimpleError, Err: "NOSCRIPT No matching script. Please use EVAL."}
+			}
+			return reply
+		case "FUNCTION":
+			loads++
+			// #!lua name=<name>
+			name := strings.TrimPrefix(strings.Fields(args[3])[1], "name=")
+			functions[name+".get"] = true
+			return NewBlobStringValue(name)
+		case "FCALL", "FCALL_RO":
+			if !functions["mylib."+args[1]] {
+				return &Value{Type: TypeSimpleError, Err: "ERR Function not found"}
+			}
+			return reply
+		}
+		return &Value{Type: TypeSimpleError, Err: "ERR unknown command"}
+	}
+	return handle, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return loads
+	}
+}
+
+func TestScript(t *testing.T) {
+	handle, loads := scriptHandler(t)
+	ln := serveFake(t, handle)
+	defer ln.Close()
+	c, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	s := NewScript("redis.setresp(3) return {map={score=1.5, ok=true}}")
+	if len(s.Hash()) != 40 {
+		t.Fatalf("unexpected hash %s", s.Hash())
+	}
+	for i := 0; i < 2; i++ {
+		v, err := s.Run(ctx, c, []string{"k"}, "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the RESP3 types are kept
+		keys, values := mapEntries(v.KV)
+		if v.Type != TypeMap || len(keys) != 2 || values[0].Type != TypeDouble || values[0].Double != 1.5 || values[1].Type != TypeBoolean {
+			t.Errorf("unexpected reply %s", v.ToRESP3String())
+		}
+	}
+	if loads() != 1 {
+		t.Errorf("expected the script to be loaded once but got %d", loads())
+	}
+
+	_, err = NewScript("return 1").RunRO(ctx, c, nil)
+	if err != nil || loads() != 2 {
+		t.Errorf("expected RunRO to load the script: %v, %d", err, loads())
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	handle, loads := scriptHandler(t)
+	ln := serveFake(t, handle)
+	defer ln.Close()
+	c, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	lib := NewLibrary("#!lua name=mylib\nredis.register_function('get', function(keys, args) return 1 end)")
+	for i := 0; i < 2; i++ {
+		v, err := lib.Call(ctx, c, "get", []string{"k"})
+		if err != nil || v.Type != TypeMap {
+			t.Fatalf("unexpected reply %v, %v", v, err)
+		}
+	}
+	if loads() != 1 {
+		t.Errorf("expected the library to be loaded once but got %d", loads())
+	}
+
+	_, err = lib.CallRO(ctx, c, "missing", nil)
+	if err == nil || err.(Error).Code() != "ERR" || loads() != 2 {
+		t.Errorf("expected an error after loading the library: %v, %d", err, loads())
+	}
+}
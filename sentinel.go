@@ -0,0 +1,363 @@
+package resp3
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors of Sentinel
+var (
+	ErrMasterNotFound = errors.New("resp: primary not found by the sentinels")
+	ErrRoleMismatch   = errors.New("resp: unexpected role of the server")
+	ErrNoSentinels    = errors.New("resp: no sentinel addresses")
+)
+
+// sentinelRetryInterval is how long the watcher waits before it connects to the next sentinel.
+const sentinelRetryInterval = time.Second
+
+// Sentinel is a client of a primary monitored by Redis Sentinel.
+//
+// The primary is discovered by SENTINEL GET-MASTER-ADDR-BY-NAME and checked by ROLE after connecting.
+// Commands are sent by a pool of connections to the primary. A watcher subscribes to +switch-master on a sentinel,
+// and the pool is replaced when the primary is switched, so commands are sent to the new primary after a failover.
+type Sentinel struct {
+	// MasterName is the name of the primary in the configuration of the sentinels.
+	MasterName string
+	// Addrs are the addresses of the sentinels. They are asked in order, and the one which replies is asked first later.
+	Addrs []string
+	// DialOptions are used to connect to the primary and the replicas.
+	DialOptions []DialOption
+	// SentinelDialOptions are used to connect to the sentinels.
+	SentinelDialOptions []DialOption
+	// OnSwitch is called when the primary is switched.
+	OnSwitch func(oldAddr, newAddr string)
+
+	mu        sync.Mutex
+	sentinels []string // Addrs ordered by the replies
+	addr      string
+	pool      *Pool
+	stop      context.CancelFunc
+	done      chan struct{}
+	closed    bool
+}
+
+// NewSentinel returns a client of the primary named masterName, monitored by the sentinels.
+func NewSentinel(masterName string, sentinelAddrs []string, opts ...DialOption) *Sentinel {
+	return &Sentinel{
+		MasterName:  masterName,
+		Addrs:       sentinelAddrs,
+		DialOptions: opts,
+	}
+}
+
+// MasterAddr asks the sentinels in order for the address of the primary.
+func (s *Sentinel) MasterAddr(ctx context.Context) (string, error) {
+	var addr string
+	err := s.ask(ctx, func(c *Conn) error {
+		v, err := c.DoContext(ctx, "SENTINEL", "GET-MASTER-ADDR-BY-NAME", s.MasterName)
+		if err != nil {
+			return err
+		}
+		if isNil(v) {
+			return ErrMasterNotFound
+		}
+		if v.Type != TypeArray || len(v.Elems) != 2 {
+			return ErrUnexpectedReply
+		}
+		addr = net.JoinHostPort(v.Elems[0].Str, v.Elems[1].Str)
+		return nil
+	})
+	return addr, err
+}
+
+// ReplicaAddrs asks the sentinels in order for the addresses of the replicas which are up.
+func (s *Sentinel) ReplicaAddrs(ctx context.Context) ([]string, error) {
+	var addrs []string
+	err := s.ask(ctx, func(c *Conn) error {
+		v, err := c.DoContext(ctx, "SENTINEL", "REPLICAS", s.MasterName)
+		if err != nil {
+			return err
+		}
+		if v.Type != TypeArray {
+			return ErrUnexpectedReply
+		}
+		for _, elem := range v.Elems {
+			f, err := fieldsOf(elem)
+			if err != nil {
+				return err
+			}
+			if isReplicaDown(f.str("flags")) {
+				continue
+			}
+			addrs = append(addrs, net.JoinHostPort(f.str("ip"), f.str("port")))
+		}
+		return nil
+	})
+	return addrs, err
+}
+
+func isReplicaDown(flags string) bool {
+	for _, flag := range strings.Split(flags, ",") {
+		switch flag {
+		case "s_down", "o_down", "disconnected":
+			return true
+		}
+	}
+	return false
+}
+
+// sentinelAddrs returns the addresses of the sentinels, the last one which replied is the first.
+func (s *Sentinel) sentinelAddrs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sentinels == nil {
+		s.sentinels = append([]string(nil), s.Addrs...)
+	}
+	return append([]string(nil), s.sentinels...)
+}
+
+// ask calls fn with the sentinels in order until it succeeds, and moves the sentinel to the front.
+// ErrMasterNotFound is returned if a sentinel doesn't know the primary, otherwise the last error.
+func (s *Sentinel) ask(ctx context.Context, fn func(c *Conn) error) error {
+	addrs := s.sentinelAddrs()
+	if len(addrs) == 0 {
+		return ErrNoSentinels
+	}
+	err := ErrMasterNotFound
+	notFound := false
+	for i, addr := range addrs {
+		var c *Conn
+		c, err = DialContext(ctx, "tcp", addr, s.SentinelDialOptions...)
+		if err == nil {
+			err = fn(c)
+			c.Close()
+		}
+		if err == nil {
+			if i > 0 {
+				s.mu.Lock()
+				s.sentinels = append([]string{addr}, append(addrs[:i:i], addrs[i+1:]...)...)
+				s.mu.Unlock()
+			}
+			return nil
+		}
+		notFound = notFound || err == ErrMasterNotFound
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	if notFound {
+		return ErrMasterNotFound
+	}
+	return err
+}
+
+// DialMaster connects to the primary and checks its role.
+// The primary is discovered again if it can't be connected or it's not a primary anymore.
+func (s *Sentinel) DialMaster(ctx context.Context) (*Conn, error) {
+	s.mu.Lock()
+	addr := s.addr
+	s.mu.Unlock()
+
+	if addr != "" {
+		c, err := s.dial(ctx, addr, "master")
+		if err == nil {
+			return c, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+	}
+
+	addr, err := s.MasterAddr(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.switchTo(addr)
+	return s.dial(ctx, addr, "master")
+}
+
+// DialReplica connects to one of the replicas which are up and checks its role.
+func (s *Sentinel) DialReplica(ctx context.Context) (*Conn, error) {
+	addrs, err := s.ReplicaAddrs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = ErrMasterNotFound
+	for _, addr := range addrs {
+		var c *Conn
+		if c, err = s.dial(ctx, addr, "slave"); err == nil {
+			return c, nil
+		}
+	}
+	return nil, err
+}
+
+// dial connects to a server and checks its role by ROLE.
+func (s *Sentinel) dial(ctx context.Context, addr, role string) (*Conn, error) {
+	c, err := DialContext(ctx, "tcp", addr, s.DialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	v, err := c.DoContext(ctx, "ROLE")
+	if err == nil && (v.Type != TypeArray || len(v.Elems) == 0 || v.Elems[0].Str != role) {
+		err = ErrRoleMismatch
+	}
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// DoContext sends a command to the primary. The primary is discovered again after an I/O error
+// or a READONLY error, which is returned by a primary demoted to a replica.
+// The command is not retried.
+func (s *Sentinel) DoContext(ctx context.Context, args ...string) (*Value, error) {
+	p, err := s.getPool()
+	if err != nil {
+		return nil, err
+	}
+	v, err := p.DoContext(ctx, args...)
+	if err != nil && ctx.Err() == nil && (!isReplyError(err) || hasErrorCode(err, "READONLY")) {
+		if addr, err := s.MasterAddr(ctx); err == nil {
+			s.switchTo(addr)
+		}
+	}
+	return v, err
+}
+
+// getPool returns the pool of the primary and starts the watcher at the first time.
+func (s *Sentinel) getPool() (*Pool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrPoolClosed
+	}
+	if len(s.Addrs) == 0 {
+		return nil, ErrNoSentinels
+	}
+	if s.stop == nil {
+		var ctx context.Context
+		ctx, s.stop = context.WithCancel(context.Background())
+		s.done = make(chan struct{})
+		go s.watch(ctx)
+	}
+	if s.pool == nil {
+		s.pool = &Pool{Dial: s.DialMaster}
+	}
+	return s.pool, nil
+}
+
+// switchTo replaces the pool if the primary is switched. Connections to the old primary are closed.
+func (s *Sentinel) switchTo(addr string) {
+	s.mu.Lock()
+	old, p := s.addr, s.pool
+	if addr == old {
+		s.mu.Unlock()
+		return
+	}
+	s.addr, s.pool = addr, nil
+	s.mu.Unlock()
+
+	if p != nil {
+		p.Close()
+	}
+	if old != "" && s.OnSwitch != nil {
+		s.OnSwitch(old, addr)
+	}
+}
+
+// watch subscribes to +switch-master on the sentinels in turn until the context is canceled.
+// It waits for a while after all sentinels fail. A sentinel fails unless a message is received from it
+// or it's watched for the retry interval, so a sentinel which drops the connection after SUBSCRIBE isn't dialed in a loop.
+func (s *Sentinel) watch(ctx context.Context) {
+	defer close(s.done)
+	failures := 0
+	for i := 0; ; i++ {
+		addrs := s.sentinelAddrs()
+		n := len(addrs)
+		if n == 0 {
+			return
+		}
+		addr := addrs[i%n]
+		start := time.Now()
+		if s.subscribe(ctx, addr) || time.Since(start) >= sentinelRetryInterval {
+			failures = 0
+		} else {
+			failures++
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if failures < n {
+			continue
+		}
+
+		failures = 0
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(sentinelRetryInterval):
+		}
+	}
+}
+
+// subscribe watches a sentinel until the connection is broken, it returns true if a message is received.
+func (s *Sentinel) subscribe(ctx context.Context, addr string) bool {
+	c, err := DialContext(ctx, "tcp", addr, s.SentinelDialOptions...)
+	if err != nil {
+		return false
+	}
+	defer c.Close()
+
+	received := false
+	c.OnPush = func(v *Value) {
+		// message, +switch-master, <name> <old ip> <old port> <new ip> <new port>
+		if len(v.Elems) != 3 || v.Elems[0].Str != "message" || v.Elems[1].Str != "+switch-master" {
+			return
+		}
+		received = true
+		fields := strings.Fields(v.Elems[2].Str)
+		if len(fields) == 5 && fields[0] == s.MasterName {
+			s.switchTo(net.JoinHostPort(fields[3], fields[4]))
+		}
+	}
+	if _, err := c.DoContext(ctx, "SUBSCRIBE", "+switch-master"); err != nil {
+		return false
+	}
+	// the primary may be switched when no sentinel was watched
+	if addr, err := s.MasterAddr(ctx); err == nil {
+		s.switchTo(addr)
+	}
+	for {
+		if _, err := c.ReceiveContext(ctx); err != nil && !isReplyError(err) {
+			return received
+		}
+	}
+}
+
+// Close stops the watcher and closes the connections.
+func (s *Sentinel) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	stop, done, p := s.stop, s.done, s.pool
+	s.pool = nil
+	s.mu.Unlock()
+
+	if stop != nil {
+		stop()
+		<-done
+	}
+	if p != nil {
+		p.Close()
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package resp3
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/emirpasic/gods/maps/linkedhashmap"
+)
+
+// fakeHandler returns the reply of a command to a fake server, or nil if it has no reply.
+// write writes a value to the connection, or closes it if the value is nil, it's safe to call it from other goroutines.
+type fakeHandler func(args []string, write func(v *Value)) *Value
+
+// serveFake serves RESP3 commands by handle. HELLO is answered with proto 3.
+func serveFake(t *testing.T, handle fakeHandler) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serveFakeOn(t, ln, func() fakeHandler { return handle })
+	return ln
+}
+
+// serveFakeOn is like serveFake on a listener, with a handler returned by newHandle for every connection.
+func serveFakeOn(t *testing.T, ln net.Listener, newHandle func() fakeHandler) {
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			handle := newHandle()
+			go func() {
+				defer conn.Close()
+				var mu sync.Mutex
+				write := func(v *Value) {
+					mu.Lock()
+					defer mu.Unlock()
+					if v == nil {
+						conn.Close()
+						return
+					}
+					conn.Write([]byte(v.ToRESP3String()))
+				}
+				r := NewReader(conn)
+				for {
+					cmd, _, err := r.ReadValue()
+					if err != nil {
+						return
+					}
+					var args []string
+					for _, elem := range cmd.Elems {
+						args = append(args, elem.Str)
+					}
+					if strings.EqualFold(args[0], "HELLO") {
+						write(mustValue(t, "%1\r\n+proto\r\n:3\r\n"))
+						continue
+					}
+					if reply := handle(args, write); reply != nil {
+						write(reply)
+					}
+				}
+			}()
+		}
+	}()
+}
+
+// fakeNode is a fake Redis server with ROLE, SET and GET, GET returns the name of the node.
+type fakeNode struct {
+	name string
+	mu   sync.Mutex
+	role string
+	ln   net.Listener
+}
+
+func newFakeNode(t *testing.T, name, role string) *fakeNode {
+	n := &fakeNode{name: name, role: role}
+	n.ln = serveFake(t, func(args []string, write func(v *Value)) *Value {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		switch strings.ToUpper(args[0]) {
+		case "ROLE":
+			return NewArrayValue([]*Value{NewBlobStringValue(n.role)})
+		case "SET":
+			if n.role != "master" {
+				return &Value{Type: TypeSimpleError, Err: "READONLY You can't write against a read only replica."}
+			}
+			return NewSimpleStringValue("OK")
+		case "GET":
+			return NewBlobStringValue(n.name)
+		}
+		return &Value{Type: TypeSimpleError, Err: "ERR unknown command"}
+	})
+	return n
+}
+
+func (n *fakeNode) setRole(role string) {
+	n.mu.Lock()
+	n.role = role
+	n.mu.Unlock()
+}
+
+func (n *fakeNode) addr() string {
+	return n.ln.Addr().String()
+}
+
+// fakeSentinel is a fake sentinel of the primary "mymaster", failover pushes +switch-master to the subscribers.
+type fakeSentinel struct {
+	mu          sync.Mutex
+	master      string
+	replicas    []string
+	subscribers []func(v *Value)
+	ln          net.Listener
+}
+
+func newFakeSentinel(t *testing.T, master string, replicas ...string) *fakeSentinel {
+	s := &fakeSentinel{master: master, replicas: replicas}
+	s.ln = serveFake(t, func(args []string, write func(v *Value)) *Value {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		switch strings.ToUpper(args[0]) + " " + strings.ToUpper(args[1]) {
+		case "SENTINEL GET-MASTER-ADDR-BY-NAME":
+			if args[2] != "mymaster" {
+				return NewNullValue()
+			}
+			host, port, _ := net.SplitHostPort(s.master)
+			return NewArrayValue([]*Value{NewBlobStringValue(host), NewBlobStringValue(port)})
+		case "SENTINEL REPLICAS":
+			var elems []*Value
+			for i, addr := range append([]string{"127.0.0.1:1"}, s.replicas...) {
+				host, port, _ := net.SplitHostPort(addr)
+				flags := "slave"
+				if i == 0 {
+					flags = "slave,s_down"
+				}
+				kv := linkedhashmap.New()
+				kv.Put(NewBlobStringValue("ip"), NewBlobStringValue(host))
+				kv.Put(NewBlobStringValue("port"), NewBlobStringValue(port))
+				kv.Put(NewBlobStringValue("flags"), NewBlobStringValue(flags))
+				elems = append(elems, NewMapValue(kv))
+			}
+			return NewArrayValue(elems)
+		case "SUBSCRIBE +SWITCH-MASTER":
+			s.subscribers = append(s.subscribers, write)
+			return NewPushValue([]*Value{NewBlobStringValue("subscribe"), NewBlobStringValue(args[1]), NewNumberValue(1)})
+		}
+		return &Value{Type: TypeSimpleError, Err: "ERR unknown command"}
+	})
+	return s
+}
+
+func (s *fakeSentinel) failover(newMaster string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	oldHost, oldPort, _ := net.SplitHostPort(s.master)
+	newHost, newPort, _ := net.SplitHostPort(newMaster)
+	s.master = newMaster
+	msg := strings.Join([]string{"mymaster", oldHost, oldPort, newHost, newPort}, " ")
+	for _, write := range s.subscribers {
+		write(NewPushValue([]*Value{NewBlobStringValue("message"), NewBlobStringValue("+switch-master"), NewBlobStringValue(msg)}))
+	}
+}
+
+func TestSentinel(t *testing.T) {
+	a := newFakeNode(t, "a", "master")
+	defer a.ln.Close()
+	b := newFakeNode(t, "b", "slave")
+	defer b.ln.Close()
+	sentinel := newFakeSentinel(t, a.addr(), b.addr())
+	defer sentinel.ln.Close()
+	ctx := context.Background()
+
+	// the first sentinel is down
+	s := NewSentinel("mymaster", []string{"127.0.0.1:1", sentinel.ln.Addr().String()})
+	switched := make(chan string, 1)
+	s.OnSwitch = func(oldAddr, newAddr string) {
+		switched <- newAddr
+	}
+	defer s.Close()
+
+	if v, err := s.DoContext(ctx, "GET", "k"); err != nil || v.Str != "a" {
+		t.Fatalf("expected a but got %v, %v", v, err)
+	}
+	if addrs := s.sentinelAddrs(); addrs[0] != sentinel.ln.Addr().String() {
+		t.Errorf("expected the sentinel to be moved to the front: %v", addrs)
+	}
+	replica, err := s.DialReplica(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := replica.Do("GET", "k"); v.Str != "b" {
+		t.Errorf("expected b but got %v", v)
+	}
+	replica.Close()
+
+	// wait for the watcher
+	for i := 0; ; i++ {
+		sentinel.mu.Lock()
+		n := len(sentinel.subscribers)
+		sentinel.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if i == 100 {
+			t.Fatal("the watcher didn't subscribe")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	a.setRole("slave")
+	b.setRole("master")
+	sentinel.failover(b.addr())
+	select {
+	case addr := <-switched:
+		if addr != b.addr() {
+			t.Errorf("expected %s but got %s", b.addr(), addr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the primary isn't switched")
+	}
+	if v, err := s.DoContext(ctx, "GET", "k"); err != nil || v.Str != "b" {
+		t.Errorf("expected b but got %v, %v", v, err)
+	}
+
+	// unknown primary
+	if _, err := NewSentinel("other", s.Addrs).MasterAddr(ctx); err != ErrMasterNotFound {
+		t.Errorf("expected ErrMasterNotFound but got %v", err)
+	}
+}
+
+func TestSentinel_Role(t *testing.T) {
+	a := newFakeNode(t, "a", "slave")
+	defer a.ln.Close()
+	sentinel := newFakeSentinel(t, a.addr())
+	defer sentinel.ln.Close()
+
+	s := NewSentinel("mymaster", []string{sentinel.ln.Addr().String()})
+	if _, err := s.DialMaster(context.Background()); err != ErrRoleMismatch {
+		t.Errorf("expected ErrRoleMismatch but got %v", err)
+	}
+}
+
+func TestSentinel_NoAddrs(t *testing.T) {
+	s := NewSentinel("mymaster", nil)
+	defer s.Close()
+	ctx := context.Background()
+	if _, err := s.DoContext(ctx, "PING"); err != ErrNoSentinels {
+		t.Errorf("expected ErrNoSentinels but got %v", err)
+	}
+	if _, err := s.MasterAddr(ctx); err != ErrNoSentinels {
+		t.Errorf("expected ErrNoSentinels but got %v", err)
+	}
+	if _, err := s.DialReplica(ctx); err != ErrNoSentinels {
+		t.Errorf("expected ErrNoSentinels but got %v", err)
+	}
+}
+
+func TestSentinel_WatchBackoff(t *testing.T) {
+	// the sentinel drops the connection after SUBSCRIBE
+	var subscribes int32
+	sentinel := serveFake(t, func(args []string, write func(v *Value)) *Value {
+		if strings.EqualFold(args[0], "SUBSCRIBE") {
+			atomic.AddInt32(&subscribes, 1)
+			write(NewPushValue([]*Value{NewBlobStringValue("subscribe"), NewBlobStringValue(args[1]), NewNumberValue(1)}))
+			write(nil)
+			return nil
+		}
+		return &Value{Type: TypeSimpleError, Err: "ERR unknown command"}
+	})
+	defer sentinel.Close()
+
+	s := NewSentinel("mymaster", []string{sentinel.Addr().String()})
+	if _, err := s.getPool(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(sentinelRetryInterval / 2)
+	s.Close()
+	if n := atomic.LoadInt32(&subscribes); n != 1 {
+		t.Errorf("expected 1 SUBSCRIBE before the retry interval but got %d", n)
+	}
+}
@@ -0,0 +1,129 @@
+package resp3
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+// Stats is a snapshot of the counters of a Reader or a Writer.
+type Stats struct {
+	// Bytes is the number of bytes read from the underlying reader, or written to the underlying writer.
+	Bytes int64
+	// Frames is the number of top-level values read, or commands written.
+	Frames int64
+	// MaxFrameSize is the size of the largest frame in bytes.
+	MaxFrameSize int64
+	// Types is the number of values by type, including the nested values and attributes.
+	// Values read by ReadRaw are only counted as frames.
+	Types map[byte]int64
+	// BlobSizes is the histogram of the lengths of blob strings, verbatim strings and blob errors.
+	BlobSizes Histogram
+	// AggregateLens is the histogram of the number of elements of arrays, sets and pushes,
+	// and the number of entries of maps and attributes.
+	AggregateLens Histogram
+}
+
+// Histogram counts sizes in buckets of powers of two:
+// Histogram[0] counts 0, and Histogram[i] counts the sizes in [2^(i-1), 2^i).
+type Histogram [64]int64
+
+// Count returns the number of sizes counted.
+func (h *Histogram) Count() int64 {
+	var n int64
+	for _, c := range h {
+		n += c
+	}
+	return n
+}
+
+// stats are the counters of a Reader or a Writer, which are updated by the reading or writing goroutine
+// and read by Stats of any goroutine.
+type stats struct {
+	frames     int64
+	maxFrame   int64
+	types      [256]int64
+	blobs      Histogram
+	aggregates Histogram
+}
+
+func (s *stats) frame(size int64) {
+	atomic.AddInt64(&s.frames, 1)
+	for {
+		max := atomic.LoadInt64(&s.maxFrame)
+		if size <= max || atomic.CompareAndSwapInt64(&s.maxFrame, max, size) {
+			return
+		}
+	}
+}
+
+func (s *stats) value(v *Value) {
+	atomic.AddInt64(&s.types[v.Type], 1)
+	if v.Attrs != nil {
+		atomic.AddInt64(&s.types[TypeAttribute], 1)
+		s.aggregate(v.Attrs.Size())
+	}
+	switch v.Type {
+	case TypeBlobString:
+		if !v.NullBulkString {
+			s.blob(len(v.Str))
+		}
+	case TypeVerbatimString:
+		s.blob(len(v.StrFmt) + 1 + len(v.Str))
+	case TypeBlobError:
+		s.blob(len(v.Err))
+	case TypeArray, TypeSet, TypePush:
+		s.aggregate(len(v.Elems))
+	case TypeMap:
+		s.aggregate(v.KV.Size())
+	}
+}
+
+// command counts a command of argc arguments, which are counted by arg.
+func (s *stats) command(size int64, argc int) {
+	s.frame(size)
+	atomic.AddInt64(&s.types[TypeArray], 1)
+	s.aggregate(argc)
+}
+
+func (s *stats) arg(n int) {
+	atomic.AddInt64(&s.types[TypeBlobString], 1)
+	s.blob(n)
+}
+
+func (s *stats) blob(n int) {
+	atomic.AddInt64(&s.blobs[bits.Len64(uint64(n))], 1)
+}
+
+func (s *stats) aggregate(n int) {
+	atomic.AddInt64(&s.aggregates[bits.Len64(uint64(n))], 1)
+}
+
+func (s *stats) snapshot(bytes int64) Stats {
+	st := Stats{
+		Bytes:        bytes,
+		Frames:       atomic.LoadInt64(&s.frames),
+		MaxFrameSize: atomic.LoadInt64(&s.maxFrame),
+		Types:        make(map[byte]int64),
+	}
+	for t := range s.types {
+		if n := atomic.LoadInt64(&s.types[t]); n > 0 {
+			st.Types[byte(t)] = n
+		}
+	}
+	for i := range s.blobs {
+		st.BlobSizes[i] = atomic.LoadInt64(&s.blobs[i])
+		st.AggregateLens[i] = atomic.LoadInt64(&s.aggregates[i])
+	}
+	return st
+}
+
+// Stats returns the counters of the Reader. It can be called by another goroutine while reading.
+func (r *Reader) Stats() Stats {
+	return r.stats.snapshot(r.src.count())
+}
+
+// Stats returns the counters of the Writer. It can be called by another goroutine while writing.
+// Bytes are counted when they are flushed.
+func (w *Writer) Stats() Stats {
+	return w.stats.snapshot(w.out.count())
+}
@@ -0,0 +1,99 @@
+package resp3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReader_Stats(t *testing.T) {
+	r := NewReader(strings.NewReader("*2\r\n$3\r\nfoo\r\n:1\r\n" +
+		"%1\r\n+a\r\n$0\r\n\r\n" +
+		"|1\r\n+k\r\n+v\r\n$5\r\nhello\r\n" +
+		":7\r\n"))
+
+	// Stats can be called while reading
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Stats()
+	}()
+	for i := 0; i < 3; i++ {
+		if _, _, err := r.ReadValue(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := r.ReadRaw(); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	s := r.Stats()
+	if s.Bytes != 58 || s.Frames != 4 || s.MaxFrameSize != 23 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	types := map[byte]int64{
+		TypeArray: 1, TypeMap: 1, TypeAttribute: 1, TypeBlobString: 3, TypeSimpleString: 3, TypeNumber: 1,
+	}
+	if !reflect.DeepEqual(s.Types, types) {
+		t.Errorf("expected %v but got %v", types, s.Types)
+	}
+	// blob strings of 0, 3 and 5 bytes, aggregates of 2, 1 and 1 elements
+	if s.BlobSizes[0] != 1 || s.BlobSizes[2] != 1 || s.BlobSizes[3] != 1 || s.BlobSizes.Count() != 3 {
+		t.Errorf("unexpected blob sizes %v", s.BlobSizes)
+	}
+	if s.AggregateLens[1] != 2 || s.AggregateLens[2] != 1 || s.AggregateLens.Count() != 3 {
+		t.Errorf("unexpected aggregate lengths %v", s.AggregateLens)
+	}
+}
+
+func TestWriter_Stats(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.WriteCommand("SET", "k", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteByteCommand([]byte("GET"), []byte("k")); err != nil {
+		t.Fatal(err)
+	}
+
+	s := w.Stats()
+	if s.Bytes != int64(buf.Len()) || s.Frames != 2 || s.MaxFrameSize != 31 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	if s.Types[TypeArray] != 2 || s.Types[TypeBlobString] != 5 {
+		t.Errorf("unexpected types %v", s.Types)
+	}
+	if s.BlobSizes[1] != 2 || s.BlobSizes[2] != 2 || s.BlobSizes[3] != 1 {
+		t.Errorf("unexpected blob sizes %v", s.BlobSizes)
+	}
+	if s.AggregateLens[2] != 2 {
+		t.Errorf("unexpected aggregate lengths %v", s.AggregateLens)
+	}
+}
+
+func TestStats_WithoutCounter(t *testing.T) {
+	// a Reader and a Writer made without NewReader and NewWriter count frames but no bytes
+	r := &Reader{Reader: bufio.NewReader(strings.NewReader(":1\r\n+OK\r\n"))}
+	if _, _, err := r.ReadValue(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.ReadValueContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s := r.Stats(); s.Bytes != 0 || s.Frames != 2 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+
+	var buf bytes.Buffer
+	w := &Writer{Writer: bufio.NewWriter(&buf)}
+	if err := w.WriteCommandContext(context.Background(), "PING"); err != nil {
+		t.Fatal(err)
+	}
+	if s := w.Stats(); s.Bytes != 0 || s.Frames != 1 || buf.String() != "*1\r\n$4\r\nPING\r\n" {
+		t.Errorf("unexpected stats %+v of %q", s, buf.String())
+	}
+}
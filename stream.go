@@ -0,0 +1,576 @@
+package resp3
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// XEntry is an entry of a stream.
+type XEntry struct {
+	ID string
+	// Fields is nil if the entry is deleted but it's still pending in a consumer group.
+	Fields map[string]string
+}
+
+// XStream is the entries of a stream returned by XREAD and XREADGROUP.
+type XStream struct {
+	Stream  string
+	Entries []XEntry
+}
+
+// decodeXEntry decodes [id, [field, value ...]].
+func decodeXEntry(v *Value) (XEntry, error) {
+	if v.Type != TypeArray || len(v.Elems) != 2 {
+		return XEntry{}, ErrUnexpectedReply
+	}
+	e := XEntry{ID: v.Elems[0].Str}
+	// a deleted entry has null fields, _ of RESP3 or *-1 of RESP2
+	if isNil(v.Elems[1]) {
+		return e, nil
+	}
+	keys, values, err := pairs(v.Elems[1])
+	if err != nil {
+		return XEntry{}, err
+	}
+	e.Fields = make(map[string]string, len(keys))
+	for i := range keys {
+		e.Fields[keys[i].Str] = values[i].Str
+	}
+	return e, nil
+}
+
+func decodeXEntries(v *Value) ([]XEntry, error) {
+	if v.Type != TypeArray {
+		return nil, ErrUnexpectedReply
+	}
+	entries := make([]XEntry, len(v.Elems))
+	for i, elem := range v.Elems {
+		e, err := decodeXEntry(elem)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = e
+	}
+	return entries, nil
+}
+
+// XRangeCmd is XRANGE or XREVRANGE key start end [COUNT count].
+type XRangeCmd struct {
+	args []string
+}
+
+// XRange returns XRANGE key start end, "-" and "+" are the first and the last IDs.
+func XRange(key, start, end string) *XRangeCmd {
+	return &XRangeCmd{args: []string{"XRANGE", key, start, end}}
+}
+
+// XRevRange returns XREVRANGE key end start.
+func XRevRange(key, end, start string) *XRangeCmd {
+	return &XRangeCmd{args: []string{"XREVRANGE", key, end, start}}
+}
+
+// Count returns at most count entries.
+func (c *XRangeCmd) Count(count int64) *XRangeCmd {
+	c.args = append(c.args, "COUNT", strconv.FormatInt(count, 10))
+	return c
+}
+
+// Args returns the command.
+func (c *XRangeCmd) Args() []string {
+	return c.args
+}
+
+// Decode decodes a reply.
+func (c *XRangeCmd) Decode(v *Value) ([]XEntry, error) {
+	return decodeXEntries(v)
+}
+
+// Do sends the command and decodes the reply.
+func (c *XRangeCmd) Do(ctx context.Context, d Doer) ([]XEntry, error) {
+	v, err := d.DoContext(ctx, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(v)
+}
+
+// XReadCmd is XREAD [COUNT count] [BLOCK milliseconds] STREAMS key [key ...] id [id ...],
+// or XREADGROUP GROUP group consumer [COUNT count] [BLOCK milliseconds] [NOACK] STREAMS key [key ...] id [id ...].
+type XReadCmd struct {
+	opts []string
+	keys []string
+	ids  []string
+}
+
+// XRead returns XREAD, the streams are added by Stream.
+func XRead() *XReadCmd {
+	return &XReadCmd{opts: []string{"XREAD"}}
+}
+
+// XReadGroup returns XREADGROUP GROUP group consumer, the streams are added by Stream.
+// The ID ">" reads the new entries, and other IDs read the pending entries of the consumer.
+func XReadGroup(group, consumer string) *XReadCmd {
+	return &XReadCmd{opts: []string{"XREADGROUP", "GROUP", group, consumer}}
+}
+
+// Count returns at most count entries of each stream.
+func (c *XReadCmd) Count(count int64) *XReadCmd {
+	c.opts = append(c.opts, "COUNT", strconv.FormatInt(count, 10))
+	return c
+}
+
+// Block waits for entries if there are none, 0 waits forever.
+func (c *XReadCmd) Block(timeout time.Duration) *XReadCmd {
+	c.opts = append(c.opts, "BLOCK", strconv.FormatInt(timeout.Milliseconds(), 10))
+	return c
+}
+
+// NoAck doesn't add the entries to the pending entries list, with XREADGROUP.
+func (c *XReadCmd) NoAck() *XReadCmd {
+	c.opts = append(c.opts, "NOACK")
+	return c
+}
+
+// Stream reads the entries of a stream after the ID.
+func (c *XReadCmd) Stream(key, id string) *XReadCmd {
+	c.keys = append(c.keys, key)
+	c.ids = append(c.ids, id)
+	return c
+}
+
+// Args returns the command.
+func (c *XReadCmd) Args() []string {
+	args := append(append([]string(nil), c.opts...), "STREAMS")
+	args = append(args, c.keys...)
+	return append(args, c.ids...)
+}
+
+// Decode decodes a reply, a map of streams in RESP3 or an array of [stream, entries] in RESP2.
+// It returns nil if the block timed out.
+func (c *XReadCmd) Decode(v *Value) ([]XStream, error) {
+	if isNil(v) {
+		return nil, nil
+	}
+	var keys, values []*Value
+	switch v.Type {
+	case TypeMap:
+		keys, values = mapEntries(v.KV)
+	case TypeArray:
+		for _, elem := range v.Elems {
+			if elem.Type != TypeArray || len(elem.Elems) != 2 {
+				return nil, ErrUnexpectedReply
+			}
+			keys = append(keys, elem.Elems[0])
+			values = append(values, elem.Elems[1])
+		}
+	default:
+		return nil, ErrUnexpectedReply
+	}
+
+	streams := make([]XStream, len(keys))
+	for i := range keys {
+		entries, err := decodeXEntries(values[i])
+		if err != nil {
+			return nil, err
+		}
+		streams[i] = XStream{Stream: keys[i].Str, Entries: entries}
+	}
+	return streams, nil
+}
+
+// Do sends the command and decodes the reply, it returns nil if BLOCK times out.
+func (c *XReadCmd) Do(ctx context.Context, d Doer) ([]XStream, error) {
+	v, err := d.DoContext(ctx, c.Args()...)
+	if err == ErrNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(v)
+}
+
+// XAck returns XACK key group id [id ...].
+func XAck(key, group string, ids ...string) *IntCmd {
+	return &IntCmd{args: append([]string{"XACK", key, group}, ids...)}
+}
+
+// XDel returns XDEL key id [id ...].
+func XDel(key string, ids ...string) *IntCmd {
+	return &IntCmd{args: append([]string{"XDEL", key}, ids...)}
+}
+
+// XGroupCreateCmd is XGROUP CREATE key group id [MKSTREAM].
+type XGroupCreateCmd struct {
+	args []string
+}
+
+// XGroupCreate returns XGROUP CREATE key group id, "$" is the last ID of the stream.
+func XGroupCreate(key, group, id string) *XGroupCreateCmd {
+	return &XGroupCreateCmd{args: []string{"XGROUP", "CREATE", key, group, id}}
+}
+
+// MkStream creates the stream if it doesn't exist.
+func (c *XGroupCreateCmd) MkStream() *XGroupCreateCmd {
+	c.args = append(c.args, "MKSTREAM")
+	return c
+}
+
+// Args returns the command.
+func (c *XGroupCreateCmd) Args() []string {
+	return c.args
+}
+
+// Do sends the command. It returns a BUSYGROUP Error if the group exists.
+func (c *XGroupCreateCmd) Do(ctx context.Context, d Doer) error {
+	_, err := d.DoContext(ctx, c.args...)
+	return err
+}
+
+// XAutoClaimCmd is XAUTOCLAIM key group consumer min-idle-time start [COUNT count].
+type XAutoClaimCmd struct {
+	args []string
+}
+
+// XAutoClaim returns XAUTOCLAIM, which claims the pending entries idle for longer than minIdle from the ID start.
+func XAutoClaim(key, group, consumer string, minIdle time.Duration, start string) *XAutoClaimCmd {
+	return &XAutoClaimCmd{args: []string{"XAUTOCLAIM", key, group, consumer, strconv.FormatInt(minIdle.Milliseconds(), 10), start}}
+}
+
+// Count claims at most count entries, 100 by default.
+func (c *XAutoClaimCmd) Count(count int64) *XAutoClaimCmd {
+	c.args = append(c.args, "COUNT", strconv.FormatInt(count, 10))
+	return c
+}
+
+// Args returns the command.
+func (c *XAutoClaimCmd) Args() []string {
+	return c.args
+}
+
+// Decode decodes a reply: the ID to claim from next time, "0-0" after the whole list is scanned,
+// the claimed entries, and the IDs of the deleted entries which are removed from the pending entries list, since Redis 7.0.
+func (c *XAutoClaimCmd) Decode(v *Value) (next string, entries []XEntry, deleted []string, err error) {
+	if v.Type != TypeArray || len(v.Elems) < 2 {
+		return "", nil, nil, ErrUnexpectedReply
+	}
+	if entries, err = decodeXEntries(v.Elems[1]); err != nil {
+		return "", nil, nil, err
+	}
+	if len(v.Elems) > 2 {
+		deleted = stringElems(v.Elems[2])
+	}
+	return v.Elems[0].Str, entries, deleted, nil
+}
+
+// Do sends the command and decodes the reply.
+func (c *XAutoClaimCmd) Do(ctx context.Context, d Doer) (string, []XEntry, []string, error) {
+	v, err := d.DoContext(ctx, c.args...)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return c.Decode(v)
+}
+
+// XPendingSummary is the summary of the pending entries of a group.
+type XPendingSummary struct {
+	Count           int64
+	Lowest, Highest string
+	// Consumers are the numbers of the pending entries of the consumers.
+	Consumers map[string]int64
+}
+
+// XPendingCmd is XPENDING key group.
+type XPendingCmd struct {
+	args []string
+}
+
+// XPending returns XPENDING key group.
+func XPending(key, group string) *XPendingCmd {
+	return &XPendingCmd{args: []string{"XPENDING", key, group}}
+}
+
+// Args returns the command.
+func (c *XPendingCmd) Args() []string {
+	return c.args
+}
+
+// Decode decodes a reply: [count, lowest id, highest id, [[consumer, count] ...]].
+func (c *XPendingCmd) Decode(v *Value) (*XPendingSummary, error) {
+	if v.Type != TypeArray || len(v.Elems) != 4 {
+		return nil, ErrUnexpectedReply
+	}
+	s := &XPendingSummary{
+		Count:     v.Elems[0].Integer,
+		Lowest:    v.Elems[1].Str,
+		Highest:   v.Elems[2].Str,
+		Consumers: make(map[string]int64),
+	}
+	for _, elem := range v.Elems[3].Elems {
+		if elem.Type != TypeArray || len(elem.Elems) != 2 {
+			return nil, ErrUnexpectedReply
+		}
+		// the count is a string
+		n, err := strconv.ParseInt(elem.Elems[1].Str, 10, 64)
+		if err != nil {
+			return nil, ErrUnexpectedReply
+		}
+		s.Consumers[elem.Elems[0].Str] = n
+	}
+	return s, nil
+}
+
+// Do sends the command and decodes the reply.
+func (c *XPendingCmd) Do(ctx context.Context, d Doer) (*XPendingSummary, error) {
+	v, err := d.DoContext(ctx, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(v)
+}
+
+// XPendingEntry is a pending entry of a group.
+type XPendingEntry struct {
+	ID         string
+	Consumer   string
+	Idle       time.Duration
+	Deliveries int64
+}
+
+// XPendingRangeCmd is XPENDING key group [IDLE min-idle-time] start end count [consumer].
+type XPendingRangeCmd struct {
+	key, group        string
+	idle              []string
+	start, end, count string
+	consumer          string
+}
+
+// XPendingRange returns XPENDING key group start end count.
+func XPendingRange(key, group, start, end string, count int64) *XPendingRangeCmd {
+	return &XPendingRangeCmd{key: key, group: group, start: start, end: end, count: strconv.FormatInt(count, 10)}
+}
+
+// Idle only returns the entries idle for longer than minIdle.
+func (c *XPendingRangeCmd) Idle(minIdle time.Duration) *XPendingRangeCmd {
+	c.idle = []string{"IDLE", strconv.FormatInt(minIdle.Milliseconds(), 10)}
+	return c
+}
+
+// Consumer only returns the entries of the consumer.
+func (c *XPendingRangeCmd) Consumer(consumer string) *XPendingRangeCmd {
+	c.consumer = consumer
+	return c
+}
+
+// Args returns the command.
+func (c *XPendingRangeCmd) Args() []string {
+	args := append([]string{"XPENDING", c.key, c.group}, c.idle...)
+	args = append(args, c.start, c.end, c.count)
+	if c.consumer != "" {
+		args = append(args, c.consumer)
+	}
+	return args
+}
+
+// Decode decodes a reply: [[id, consumer, idle milliseconds, deliveries] ...].
+func (c *XPendingRangeCmd) Decode(v *Value) ([]XPendingEntry, error) {
+	if v.Type != TypeArray {
+		return nil, ErrUnexpectedReply
+	}
+	entries := make([]XPendingEntry, len(v.Elems))
+	for i, elem := range v.Elems {
+		if elem.Type != TypeArray || len(elem.Elems) != 4 {
+			return nil, ErrUnexpectedReply
+		}
+		e := elem.Elems
+		entries[i] = XPendingEntry{
+			ID:         e[0].Str,
+			Consumer:   e[1].Str,
+			Idle:       time.Duration(e[2].Integer) * time.Millisecond,
+			Deliveries: e[3].Integer,
+		}
+	}
+	return entries, nil
+}
+
+// Do sends the command and decodes the reply.
+func (c *XPendingRangeCmd) Do(ctx context.Context, d Doer) ([]XPendingEntry, error) {
+	v, err := d.DoContext(ctx, c.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(v)
+}
+
+// XStreamInfo is the reply of XINFO STREAM.
+type XStreamInfo struct {
+	Length          int64
+	RadixTreeKeys   int64
+	RadixTreeNodes  int64
+	Groups          int64
+	LastGeneratedID string
+	// FirstEntry and LastEntry are nil if the stream is empty.
+	FirstEntry, LastEntry *XEntry
+}
+
+// XInfoStreamCmd is XINFO STREAM key.
+type XInfoStreamCmd struct {
+	args []string
+}
+
+// XInfoStream returns XINFO STREAM key.
+func XInfoStream(key string) *XInfoStreamCmd {
+	return &XInfoStreamCmd{args: []string{"XINFO", "STREAM", key}}
+}
+
+// Args returns the command.
+func (c *XInfoStreamCmd) Args() []string {
+	return c.args
+}
+
+// Decode decodes a reply, a map in RESP3 or a flat array in RESP2.
+func (c *XInfoStreamCmd) Decode(v *Value) (*XStreamInfo, error) {
+	f, err := fieldsOf(v)
+	if err != nil {
+		return nil, err
+	}
+	info := &XStreamInfo{
+		Length:          f.integer("length"),
+		RadixTreeKeys:   f.integer("radix-tree-keys"),
+		RadixTreeNodes:  f.integer("radix-tree-nodes"),
+		Groups:          f.integer("groups"),
+		LastGeneratedID: f.str("last-generated-id"),
+	}
+	for name, entry := range map[string]**XEntry{"first-entry": &info.FirstEntry, "last-entry": &info.LastEntry} {
+		if v := f[name]; v != nil && !isNil(v) {
+			e, err := decodeXEntry(v)
+			if err != nil {
+				return nil, err
+			}
+			*entry = &e
+		}
+	}
+	return info, nil
+}
+
+// Do sends the command and decodes the reply.
+func (c *XInfoStreamCmd) Do(ctx context.Context, d Doer) (*XStreamInfo, error) {
+	v, err := d.DoContext(ctx, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(v)
+}
+
+// XGroupInfo is a group in the reply of XINFO GROUPS.
+type XGroupInfo struct {
+	Name            string
+	Consumers       int64
+	Pending         int64
+	LastDeliveredID string
+	// EntriesRead and Lag are added in Redis 7.0, Lag is -1 if it's unknown.
+	EntriesRead int64
+	Lag         int64
+}
+
+// XConsumerInfo is a consumer in the reply of XINFO CONSUMERS.
+type XConsumerInfo struct {
+	Name    string
+	Pending int64
+	Idle    time.Duration
+}
+
+// XInfoGroupsCmd is XINFO GROUPS key.
+type XInfoGroupsCmd struct {
+	args []string
+}
+
+// XInfoGroups returns XINFO GROUPS key.
+func XInfoGroups(key string) *XInfoGroupsCmd {
+	return &XInfoGroupsCmd{args: []string{"XINFO", "GROUPS", key}}
+}
+
+// Args returns the command.
+func (c *XInfoGroupsCmd) Args() []string {
+	return c.args
+}
+
+// Decode decodes a reply, an array of maps in RESP3 or of flat arrays in RESP2.
+func (c *XInfoGroupsCmd) Decode(v *Value) ([]XGroupInfo, error) {
+	if v.Type != TypeArray {
+		return nil, ErrUnexpectedReply
+	}
+	groups := make([]XGroupInfo, len(v.Elems))
+	for i, elem := range v.Elems {
+		f, err := fieldsOf(elem)
+		if err != nil {
+			return nil, err
+		}
+		groups[i] = XGroupInfo{
+			Name:            f.str("name"),
+			Consumers:       f.integer("consumers"),
+			Pending:         f.integer("pending"),
+			LastDeliveredID: f.str("last-delivered-id"),
+			EntriesRead:     f.integer("entries-read"),
+			Lag:             -1,
+		}
+		if lag := f["lag"]; lag != nil && lag.Type == TypeNumber {
+			groups[i].Lag = lag.Integer
+		}
+	}
+	return groups, nil
+}
+
+// Do sends the command and decodes the reply.
+func (c *XInfoGroupsCmd) Do(ctx context.Context, d Doer) ([]XGroupInfo, error) {
+	v, err := d.DoContext(ctx, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(v)
+}
+
+// XInfoConsumersCmd is XINFO CONSUMERS key group.
+type XInfoConsumersCmd struct {
+	args []string
+}
+
+// XInfoConsumers returns XINFO CONSUMERS key group.
+func XInfoConsumers(key, group string) *XInfoConsumersCmd {
+	return &XInfoConsumersCmd{args: []string{"XINFO", "CONSUMERS", key, group}}
+}
+
+// Args returns the command.
+func (c *XInfoConsumersCmd) Args() []string {
+	return c.args
+}
+
+// Decode decodes a reply, an array of maps in RESP3 or of flat arrays in RESP2.
+func (c *XInfoConsumersCmd) Decode(v *Value) ([]XConsumerInfo, error) {
+	if v.Type != TypeArray {
+		return nil, ErrUnexpectedReply
+	}
+	consumers := make([]XConsumerInfo, len(v.Elems))
+	for i, elem := range v.Elems {
+		f, err := fieldsOf(elem)
+		if err != nil {
+			return nil, err
+		}
+		consumers[i] = XConsumerInfo{
+			Name:    f.str("name"),
+			Pending: f.integer("pending"),
+			Idle:    time.Duration(f.integer("idle")) * time.Millisecond,
+		}
+	}
+	return consumers, nil
+}
+
+// Do sends the command and decodes the reply.
+func (c *XInfoConsumersCmd) Do(ctx context.Context, d Doer) ([]XConsumerInfo, error) {
+	v, err := d.DoContext(ctx, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(v)
+}
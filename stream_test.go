@@ -0,0 +1,70 @@
+package resp3
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestXReadCmd_Decode(t *testing.T) {
+	want := []XStream{{"s", []XEntry{{"1-0", map[string]string{"a": "1"}}, {"2-0", nil}}}}
+	cmd := XReadGroup("g", "c").Count(10).Block(time.Second).NoAck().Stream("s", ">")
+	if got := strings.Join(cmd.Args(), " "); got != "XREADGROUP GROUP g c COUNT 10 BLOCK 1000 NOACK STREAMS s >" {
+		t.Errorf("unexpected args %s", got)
+	}
+	for _, data := range []string{
+		"%1\r\n$1\r\ns\r\n*2\r\n*2\r\n$3\r\n1-0\r\n*2\r\n$1\r\na\r\n$1\r\n1\r\n*2\r\n$3\r\n2-0\r\n_\r\n",
+		"*1\r\n*2\r\n$1\r\ns\r\n*2\r\n*2\r\n$3\r\n1-0\r\n*2\r\n$1\r\na\r\n$1\r\n1\r\n*2\r\n$3\r\n2-0\r\n*-1\r\n",
+	} {
+		got, err := cmd.Decode(mustValue(t, data))
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: expected %v but got %v, %v", data, want, got, err)
+		}
+	}
+	if got, err := XRead().Decode(NewNullValue()); got != nil || err != nil {
+		t.Errorf("expected nil but got %v, %v", got, err)
+	}
+}
+
+func TestStream_Decode(t *testing.T) {
+	next, entries, deleted, err := (&XAutoClaimCmd{}).Decode(mustValue(t,
+		"*3\r\n+0-0\r\n*1\r\n*2\r\n+1-0\r\n*2\r\n+a\r\n+1\r\n*1\r\n+2-0\r\n"))
+	if err != nil || next != "0-0" || len(entries) != 1 || entries[0].Fields["a"] != "1" || !reflect.DeepEqual(deleted, []string{"2-0"}) {
+		t.Errorf("unexpected %v, %v, %v, %v", next, entries, deleted, err)
+	}
+
+	summary, err := (&XPendingCmd{}).Decode(mustValue(t,
+		"*4\r\n:3\r\n+1-0\r\n+3-0\r\n*2\r\n*2\r\n+c1\r\n+2\r\n*2\r\n+c2\r\n+1\r\n"))
+	want := &XPendingSummary{3, "1-0", "3-0", map[string]int64{"c1": 2, "c2": 1}}
+	if err != nil || !reflect.DeepEqual(summary, want) {
+		t.Errorf("expected %+v but got %+v, %v", want, summary, err)
+	}
+
+	cmd := XPendingRange("s", "g", "-", "+", 10).Idle(time.Minute).Consumer("c1")
+	if got := strings.Join(cmd.Args(), " "); got != "XPENDING s g IDLE 60000 - + 10 c1" {
+		t.Errorf("unexpected args %s", got)
+	}
+	pending, err := cmd.Decode(mustValue(t, "*1\r\n*4\r\n+1-0\r\n+c1\r\n:1500\r\n:2\r\n"))
+	if err != nil || !reflect.DeepEqual(pending, []XPendingEntry{{"1-0", "c1", 1500 * time.Millisecond, 2}}) {
+		t.Errorf("unexpected %+v, %v", pending, err)
+	}
+
+	info, err := XInfoStream("s").Decode(mustValue(t,
+		"%4\r\n+length\r\n:2\r\n+last-generated-id\r\n+2-0\r\n+first-entry\r\n*2\r\n+1-0\r\n*2\r\n+a\r\n+1\r\n+last-entry\r\n_\r\n"))
+	if err != nil || info.Length != 2 || info.LastGeneratedID != "2-0" || info.FirstEntry.ID != "1-0" || info.LastEntry != nil {
+		t.Errorf("unexpected %+v, %v", info, err)
+	}
+
+	groups, err := XInfoGroups("s").Decode(mustValue(t,
+		"*1\r\n*8\r\n+name\r\n+g\r\n+consumers\r\n:2\r\n+pending\r\n:1\r\n+lag\r\n_\r\n"))
+	if err != nil || !reflect.DeepEqual(groups, []XGroupInfo{{Name: "g", Consumers: 2, Pending: 1, Lag: -1}}) {
+		t.Errorf("unexpected %+v, %v", groups, err)
+	}
+
+	consumers, err := XInfoConsumers("s", "g").Decode(mustValue(t,
+		"*1\r\n%3\r\n+name\r\n+c1\r\n+pending\r\n:1\r\n+idle\r\n:10\r\n"))
+	if err != nil || !reflect.DeepEqual(consumers, []XConsumerInfo{{"c1", 1, 10 * time.Millisecond}}) {
+		t.Errorf("unexpected %+v, %v", consumers, err)
+	}
+}
@@ -0,0 +1,148 @@
+package resp3
+
+import (
+	"context"
+	"time"
+)
+
+// StreamWorker consumes a stream as a consumer of a group.
+//
+// Run creates the group if it doesn't exist, handles the pending entries of the consumer left by a previous run,
+// then reads new entries with XREADGROUP BLOCK. Entries are acknowledged by XACK when the handler succeeds,
+// otherwise they stay pending. Pending entries of any consumer which are idle for longer than ClaimMinIdle
+// are claimed by XAUTOCLAIM and handled again.
+//
+//	w := resp3.NewStreamWorker(pool, "orders", "billing", "worker-1", func(ctx context.Context, e resp3.XEntry) error {
+//		return bill(e.Fields["order"])
+//	})
+//	err := w.Run(ctx)
+type StreamWorker struct {
+	Stream, Group, Consumer string
+	// Handler handles an entry, it's acknowledged if the handler returns nil.
+	Handler func(ctx context.Context, e XEntry) error
+
+	// Count is the number of entries in a read, 10 if it's 0.
+	Count int64
+	// Block is how long a read waits for new entries, 5 seconds if it's 0.
+	Block time.Duration
+	// ClaimMinIdle claims the pending entries idle for longer, 0 disables claiming.
+	ClaimMinIdle time.Duration
+	// ClaimInterval is how often the pending entries are claimed, ClaimMinIdle if it's 0.
+	ClaimInterval time.Duration
+
+	d Doer
+}
+
+// NewStreamWorker returns a worker of the consumer in the group, which claims pending entries idle for longer than a minute.
+func NewStreamWorker(d Doer, stream, group, consumer string, handler func(ctx context.Context, e XEntry) error) *StreamWorker {
+	return &StreamWorker{
+		Stream:       stream,
+		Group:        group,
+		Consumer:     consumer,
+		Handler:      handler,
+		ClaimMinIdle: time.Minute,
+		d:            d,
+	}
+}
+
+// Run consumes the stream until the context is done or a command fails.
+func (w *StreamWorker) Run(ctx context.Context) error {
+	err := XGroupCreate(w.Stream, w.Group, "$").MkStream().Do(ctx, w.d)
+	if err != nil && !hasErrorCode(err, "BUSYGROUP") {
+		return err
+	}
+
+	// the pending entries of this consumer, from the beginning
+	for id := "0"; ; {
+		streams, err := XReadGroup(w.Group, w.Consumer).Count(w.count()).Stream(w.Stream, id).Do(ctx, w.d)
+		if err != nil {
+			return err
+		}
+		entries := entriesOf(streams)
+		if len(entries) == 0 {
+			break
+		}
+		if err := w.handle(ctx, entries); err != nil {
+			return err
+		}
+		id = entries[len(entries)-1].ID
+	}
+
+	var claimed time.Time
+	for {
+		if w.ClaimMinIdle > 0 && time.Since(claimed) >= w.claimInterval() {
+			if err := w.claim(ctx); err != nil {
+				return err
+			}
+			claimed = time.Now()
+		}
+
+		block := w.Block
+		if block <= 0 {
+			block = 5 * time.Second
+		}
+		streams, err := XReadGroup(w.Group, w.Consumer).Count(w.count()).Block(block).Stream(w.Stream, ">").Do(ctx, w.d)
+		if err != nil {
+			return err
+		}
+		if err := w.handle(ctx, entriesOf(streams)); err != nil {
+			return err
+		}
+	}
+}
+
+// claim claims and handles the idle pending entries.
+func (w *StreamWorker) claim(ctx context.Context) error {
+	for start := "0-0"; ; {
+		next, entries, _, err := XAutoClaim(w.Stream, w.Group, w.Consumer, w.ClaimMinIdle, start).Count(w.count()).Do(ctx, w.d)
+		if err != nil {
+			return err
+		}
+		if err := w.handle(ctx, entries); err != nil {
+			return err
+		}
+		if next == "0-0" || next == "" {
+			return nil
+		}
+		start = next
+	}
+}
+
+// handle calls the handler and acknowledges the entries which are handled.
+// Deleted entries are acknowledged without handling.
+func (w *StreamWorker) handle(ctx context.Context, entries []XEntry) error {
+	var ids []string
+	for _, e := range entries {
+		if e.Fields != nil && w.Handler(ctx, e) != nil {
+			continue
+		}
+		ids = append(ids, e.ID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := XAck(w.Stream, w.Group, ids...).Do(ctx, w.d)
+	return err
+}
+
+func (w *StreamWorker) count() int64 {
+	if w.Count <= 0 {
+		return 10
+	}
+	return w.Count
+}
+
+func (w *StreamWorker) claimInterval() time.Duration {
+	if w.ClaimInterval <= 0 {
+		return w.ClaimMinIdle
+	}
+	return w.ClaimInterval
+}
+
+func entriesOf(streams []XStream) []XEntry {
+	var entries []XEntry
+	for _, s := range streams {
+		entries = append(entries, s.Entries...)
+	}
+	return entries
+}
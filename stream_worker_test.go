@@ -0,0 +1,123 @@
+package resp3
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// streamDoer is a fake stream with a consumer group, the entries with nil fields are deleted.
+type streamDoer struct {
+	mu        sync.Mutex
+	entries   []XEntry
+	delivered int               // the entries before are delivered
+	pending   map[string]string // id -> consumer
+	acked     []string
+}
+
+func (s *streamDoer) DoContext(ctx context.Context, args ...string) (*Value, error) {
+	// the last XACK is sent after the handler cancels the context
+	if err := ctx.Err(); err != nil && args[0] != "XACK" {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entryValue := func(e XEntry) *Value {
+		if e.Fields == nil {
+			return NewArrayValue([]*Value{NewBlobStringValue(e.ID), NewNullValue()})
+		}
+		var fields []*Value
+		for k, v := range e.Fields {
+			fields = append(fields, NewBlobStringValue(k), NewBlobStringValue(v))
+		}
+		return NewArrayValue([]*Value{NewBlobStringValue(e.ID), NewArrayValue(fields)})
+	}
+
+	switch args[0] {
+	case "XGROUP":
+		return nil, Error("BUSYGROUP Consumer Group name already exists")
+	case "XREADGROUP":
+		consumer, id := args[3], args[len(args)-1]
+		count, _ := strconv.Atoi(args[5])
+		var batch []*Value
+		for i, e := range s.entries {
+			if len(batch) == count {
+				break
+			}
+			if id == ">" && i >= s.delivered {
+				s.pending[e.ID] = consumer
+				s.delivered = i + 1
+				batch = append(batch, entryValue(e))
+			} else if id != ">" && s.pending[e.ID] == consumer && e.ID > id {
+				batch = append(batch, entryValue(e))
+			}
+		}
+		if id == ">" && len(batch) == 0 {
+			return NewNullValue(), nil
+		}
+		return FromString("%1\r\n$1\r\ns\r\n" + NewArrayValue(batch).ToRESP3String())
+	case "XAUTOCLAIM":
+		var claimed []*Value
+		for _, e := range s.entries {
+			if _, ok := s.pending[e.ID]; ok {
+				s.pending[e.ID] = args[3]
+				claimed = append(claimed, entryValue(e))
+			}
+		}
+		return NewArrayValue([]*Value{NewBlobStringValue("0-0"), NewArrayValue(claimed), NewArrayValue(nil)}), nil
+	case "XACK":
+		for _, id := range args[3:] {
+			delete(s.pending, id)
+			s.acked = append(s.acked, id)
+		}
+		return NewNumberValue(int64(len(args) - 3)), nil
+	}
+	return nil, Error("ERR unknown command")
+}
+
+func TestStreamWorker(t *testing.T) {
+	s := &streamDoer{
+		entries: []XEntry{
+			{"0-1", map[string]string{"n": "0"}}, {"0-2", nil},
+			{"1-0", map[string]string{"n": "1"}}, {"2-0", map[string]string{"n": "2"}}, {"3-0", map[string]string{"n": "3"}},
+		},
+		delivered: 2,
+		pending:   map[string]string{"0-1": "w", "0-2": "w"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var handled []string
+	failed := false
+	w := NewStreamWorker(s, "s", "g", "w", func(ctx context.Context, e XEntry) error {
+		if e.ID == "2-0" && !failed {
+			failed = true
+			return Error("ERR failure")
+		}
+		handled = append(handled, e.ID)
+		if len(handled) == 4 {
+			cancel()
+		}
+		return nil
+	})
+	w.Count = 2
+	w.ClaimMinIdle, w.ClaimInterval = time.Millisecond, time.Millisecond
+
+	if err := w.Run(ctx); err != context.Canceled {
+		t.Errorf("expected context.Canceled but got %v", err)
+	}
+	sort.Strings(handled)
+	if want := []string{"0-1", "1-0", "2-0", "3-0"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("expected %v but got %v", want, handled)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) != 0 || len(s.acked) != 5 {
+		t.Errorf("unexpected pending %v, acked %v", s.pending, s.acked)
+	}
+}
@@ -0,0 +1,220 @@
+package resp3
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/emirpasic/gods/maps/linkedhashmap"
+)
+
+// Frame is a top-level value found in a captured byte stream.
+// A corrupt region of the stream is returned as a Frame with a nil Value and a non-nil Err.
+type Frame struct {
+	Offset int    // offset of the first byte in the stream
+	Raw    []byte // the exact bytes of the frame
+	Value  *Value
+	Err    error
+}
+
+// DecodeTrace splits a captured RESP3 byte stream into frames.
+// It doesn't stop at invalid data: the bytes up to the next line which starts a valid value
+// are returned as one corrupt frame and decoding continues from there.
+// A truncated value at the end of the stream is returned as a corrupt frame with io.ErrUnexpectedEOF.
+func DecodeTrace(data []byte) []*Frame {
+	d := newTraceDecoder(data)
+	var frames []*Frame
+	off := 0
+	for off < len(data) {
+		f, err := d.decode(off)
+		if err == nil {
+			frames = append(frames, f)
+			off += len(f.Raw)
+			continue
+		}
+
+		next := d.resync(off + 1)
+		frames = append(frames, &Frame{Offset: off, Raw: data[off:next:next], Err: err})
+		off = next
+	}
+	return frames
+}
+
+// traceDecoder decodes frames at any offset of a stream with one Reader.
+type traceDecoder struct {
+	data []byte
+	src  *bytes.Reader
+	r    *Reader
+}
+
+func newTraceDecoder(data []byte) *traceDecoder {
+	src := bytes.NewReader(data)
+	return &traceDecoder{data: data, src: src, r: NewReaderSize(src, 4096)}
+}
+
+func (d *traceDecoder) decode(off int) (*Frame, error) {
+	if !isValueType(d.data[off]) {
+		return nil, ErrInvalidSyntax
+	}
+
+	d.reset(d.data[off:])
+	raw, err := d.r.ReadRaw()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d.reset(raw)
+	v, _, err := d.r.ReadValue()
+	if err != nil {
+		return nil, err
+	}
+	if !hasValidTypes(v) {
+		return nil, ErrInvalidSyntax
+	}
+	return &Frame{Offset: off, Raw: d.data[off : off+len(raw) : off+len(raw)], Value: v}, nil
+}
+
+// reset makes the Reader read data.
+func (d *traceDecoder) reset(data []byte) {
+	d.src.Reset(data)
+	d.r.Reader.Reset(d.r.src)
+	d.r.err = nil
+}
+
+// resync finds the next line start from which a valid frame can be decoded.
+func (d *traceDecoder) resync(from int) int {
+	for i := from; i < len(d.data); i++ {
+		if d.data[i-1] != '\n' || !isValueType(d.data[i]) {
+			continue
+		}
+		if _, err := d.decode(i); err == nil {
+			return i
+		}
+	}
+	return len(d.data)
+}
+
+func isValueType(t byte) bool {
+	switch t {
+	case TypeBlobString, TypeSimpleString, TypeSimpleError, TypeNumber, TypeNull, TypeDouble,
+		TypeBoolean, TypeBlobError, TypeVerbatimString, TypeBigNumber,
+		TypeArray, TypeMap, TypeSet, TypeAttribute, TypePush:
+		return true
+	}
+	return false
+}
+
+func hasValidTypes(v *Value) bool {
+	if v == nil || !isValueType(v.Type) {
+		return false
+	}
+	for _, elem := range v.Elems {
+		if !hasValidTypes(elem) {
+			return false
+		}
+	}
+	keys, values := mapEntries(v.KV)
+	attrKeys, attrValues := mapEntries(v.Attrs)
+	for _, elem := range append(append(append(keys, values...), attrKeys...), attrValues...) {
+		if !hasValidTypes(elem) {
+			return false
+		}
+	}
+	return true
+}
+
+// Tree returns a tree view of the value which shows the type of every node:
+//
+//	array(2)
+//	├─ number: 1
+//	└─ map(1)
+//	   ├─ key simple_string: "a"
+//	   └─ val double: 1.5
+func (r *Value) Tree() string {
+	buf := new(strings.Builder)
+	r.tree(buf, "", "", "")
+	return buf.String()
+}
+
+func (r *Value) tree(buf *strings.Builder, label, first, rest string) {
+	buf.WriteString(first)
+	buf.WriteString(label)
+	buf.WriteString(r.describe())
+	buf.WriteByte('\n')
+
+	type child struct {
+		label string
+		v     *Value
+	}
+	var children []child
+	if r.Attrs != nil && r.Attrs.Size() > 0 {
+		children = append(children, child{v: &Value{Type: TypeAttribute, KV: r.Attrs}})
+	}
+	switch r.Type {
+	case TypeArray, TypeSet, TypePush:
+		for _, elem := range r.Elems {
+			children = append(children, child{v: elem})
+		}
+	case TypeMap, TypeAttribute:
+		keys, values := mapEntries(r.KV)
+		for i := range keys {
+			children = append(children, child{label: "key ", v: keys[i]}, child{label: "val ", v: values[i]})
+		}
+	}
+
+	for i, c := range children {
+		if i == len(children)-1 {
+			c.v.tree(buf, c.label, rest+"└─ ", rest+"   ")
+		} else {
+			c.v.tree(buf, c.label, rest+"├─ ", rest+"│  ")
+		}
+	}
+}
+
+func (r *Value) describe() string {
+	name := typeNames[r.Type]
+	switch r.Type {
+	case TypeSimpleString:
+		return name + ": " + quote(r.Str)
+	case TypeBlobString:
+		if r.NullBulkString {
+			return name + ": (null)"
+		}
+		return name + ": " + quote(r.Str)
+	case TypeVerbatimString:
+		return name + ": " + r.StrFmt + ":" + quote(r.Str)
+	case TypeSimpleError, TypeBlobError:
+		return name + ": " + quote(r.Err)
+	case TypeNumber:
+		return name + ": " + strconv.FormatInt(r.Integer, 10)
+	case TypeDouble:
+		return name + ": " + formatDouble(r.Double)
+	case TypeBigNumber:
+		return name + ": " + r.BigInt.String()
+	case TypeBoolean:
+		return name + ": " + strconv.FormatBool(r.Boolean)
+	case TypeNull:
+		return name
+	case TypeArray, TypeSet, TypePush:
+		if r.NullArray {
+			return name + ": (null)"
+		}
+		return name + "(" + strconv.Itoa(len(r.Elems)) + ")"
+	case TypeMap:
+		return name + "(" + strconv.Itoa(mapSize(r.KV)) + ")"
+	case TypeAttribute:
+		return "attribute(" + strconv.Itoa(mapSize(r.KV)) + ")"
+	}
+	return "unknown type " + quote(string(r.Type))
+}
+
+func mapSize(m *linkedhashmap.Map) int {
+	if m == nil {
+		return 0
+	}
+	return m.Size()
+}
@@ -0,0 +1,84 @@
+package resp3
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDecodeTrace(t *testing.T) {
+	data := "+OK\r\n" +
+		"*2\r\n:1\r\n$5\r\nhello\r\n" +
+		"garbage\r\n:12x\r\n" +
+		"|1\r\n+ttl\r\n:3\r\n#t\r\n" +
+		"$10\r\nabc"
+
+	frames := DecodeTrace([]byte(data))
+	expected := []struct {
+		offset int
+		raw    string
+		err    error
+	}{
+		{0, "+OK\r\n", nil},
+		{5, "*2\r\n:1\r\n$5\r\nhello\r\n", nil},
+		{24, "garbage\r\n:12x\r\n", ErrInvalidSyntax},
+		{39, "|1\r\n+ttl\r\n:3\r\n#t\r\n", nil},
+		{57, "$10\r\nabc", io.ErrUnexpectedEOF},
+	}
+	if len(frames) != len(expected) {
+		t.Fatalf("expected %d frames but got %d", len(expected), len(frames))
+	}
+	for i, exp := range expected {
+		f := frames[i]
+		if f.Offset != exp.offset || string(f.Raw) != exp.raw {
+			t.Errorf("expected frame %q at %d but got %q at %d", exp.raw, exp.offset, f.Raw, f.Offset)
+		}
+		if exp.err == nil && (f.Err != nil || f.Value == nil) {
+			t.Errorf("expected a valid frame at %d but got %v", f.Offset, f.Err)
+		}
+		if exp.err != nil && (!errors.Is(f.Err, exp.err) || f.Value != nil) {
+			t.Errorf("expected %v at %d but got %v", exp.err, f.Offset, f.Err)
+		}
+	}
+}
+
+func TestDecodeTrace_LongCorruption(t *testing.T) {
+	// every line of the corrupt region starts like a value
+	lines := 100000
+	corrupt := strings.Repeat(":x\r\n", lines)
+	data := []byte(corrupt + "+OK\r\n")
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	frames := DecodeTrace(data)
+	runtime.ReadMemStats(&after)
+	if len(frames) != 2 || len(frames[0].Raw) != len(corrupt) || frames[1].Value == nil {
+		t.Fatalf("unexpected frames %v", frames)
+	}
+	// a buffer isn't allocated for every line
+	if n := after.TotalAlloc - before.TotalAlloc; n > uint64(lines)*1024 {
+		t.Errorf("allocated %d bytes for %d lines", n, lines)
+	}
+}
+
+func TestValue_Tree(t *testing.T) {
+	v, err := FromString("|1\r\n+ttl\r\n:3\r\n*3\r\n:1\r\n%1\r\n+a\r\n,1.5\r\n$-1\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "array(3)\n" +
+		"├─ attribute(1)\n" +
+		"│  ├─ key simple_string: \"ttl\"\n" +
+		"│  └─ val number: 3\n" +
+		"├─ number: 1\n" +
+		"├─ map(1)\n" +
+		"│  ├─ key simple_string: \"a\"\n" +
+		"│  └─ val double: 1.5\n" +
+		"└─ blob_string: (null)\n"
+	if s := v.Tree(); s != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, s)
+	}
+}
@@ -0,0 +1,154 @@
+package resp3
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTxAborted is returned by Exec when a watched key was changed and EXEC returned null, or a null array of RESP2.
+var ErrTxAborted = errors.New("resp: transaction aborted")
+
+// TxResult is the result of a command in a transaction.
+type TxResult struct {
+	Args  []string
+	Value *Value
+	// Err is an Error if the command was rejected when it was queued or failed when it was executed.
+	Err error
+}
+
+// Tx is a MULTI/EXEC transaction on a connection.
+// Commands are queued locally and sent with MULTI and EXEC in one round trip by Exec.
+type Tx struct {
+	conn *Conn
+	cmds [][]string
+}
+
+// NewTx returns a transaction on the connection.
+func NewTx(c *Conn) *Tx {
+	return &Tx{conn: c}
+}
+
+// Conn returns the connection of the transaction, to read values between WATCH and EXEC.
+func (tx *Tx) Conn() *Conn {
+	return tx.conn
+}
+
+// Watch watches keys, EXEC fails if one of them is changed before it.
+func (tx *Tx) Watch(ctx context.Context, keys ...string) error {
+	_, err := tx.conn.DoContext(ctx, append([]string{"WATCH"}, keys...)...)
+	return err
+}
+
+// Queue adds a command to the transaction.
+func (tx *Tx) Queue(args ...string) {
+	tx.cmds = append(tx.cmds, args)
+}
+
+// Discard drops the queued commands and unwatches the keys.
+// It doesn't send DISCARD, the commands are queued locally and MULTI is only sent by Exec.
+func (tx *Tx) Discard(ctx context.Context) error {
+	tx.cmds = nil
+	if !tx.conn.watching {
+		return nil
+	}
+	_, err := tx.conn.DoContext(ctx, "UNWATCH")
+	return err
+}
+
+// Exec sends the queued commands in MULTI and EXEC and returns their results in order.
+//
+// It returns ErrTxAborted if a watched key was changed.
+// If a command is rejected when it's queued, EXEC fails with an EXECABORT Error
+// and the error of the command is in its result.
+func (tx *Tx) Exec(ctx context.Context) ([]TxResult, error) {
+	cmds := tx.cmds
+	tx.cmds = nil
+
+	ctx, done := tx.conn.hookPipeline(ctx, cmds)
+	results, err := tx.exec(ctx, cmds)
+	var replies []*Value
+	for _, r := range results {
+		replies = append(replies, r.Value)
+	}
+	done(replies, err)
+	return results, err
+}
+
+func (tx *Tx) exec(ctx context.Context, cmds [][]string) ([]TxResult, error) {
+	c := tx.conn
+	// receive the replies of the commands sent before
+	for c.pending > 0 {
+		if _, err := c.ReceiveContext(ctx); err != nil && !isReplyError(err) {
+			return nil, err
+		}
+	}
+
+	if err := c.Send("MULTI"); err != nil {
+		return nil, err
+	}
+	for _, cmd := range cmds {
+		if err := c.Send(cmd...); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.Send("EXEC"); err != nil {
+		return nil, err
+	}
+	if err := c.FlushContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err := c.ReceiveContext(ctx); err != nil {
+		return nil, err
+	}
+	results := make([]TxResult, len(cmds))
+	for i, cmd := range cmds {
+		results[i].Args = cmd
+		// +QUEUED
+		if _, err := c.ReceiveContext(ctx); err != nil {
+			if !isReplyError(err) {
+				return nil, err
+			}
+			results[i].Err = err
+		}
+	}
+
+	v, err := c.ReceiveContext(ctx)
+	if err != nil {
+		return results, err
+	}
+	if v.Type == TypeNull || v.NullArray {
+		return nil, ErrTxAborted
+	}
+	for i, elem := range v.Elems {
+		if i == len(results) {
+			break
+		}
+		results[i].Value = elem
+		if elem.Type == TypeSimpleError || elem.Type == TypeBlobError {
+			results[i].Err = Error(elem.Err)
+		}
+	}
+	return results, nil
+}
+
+// WatchTx runs an optimistic transaction: it watches the keys and calls fn,
+// which reads with tx.Conn() and queues commands, then executes the transaction.
+// The whole is retried if a watched key is changed, up to maxRetries times, then ErrTxAborted is returned.
+// The transaction is discarded if fn returns an error.
+func (c *Conn) WatchTx(ctx context.Context, keys []string, maxRetries int, fn func(tx *Tx) error) ([]TxResult, error) {
+	for i := 0; ; i++ {
+		tx := NewTx(c)
+		if err := tx.Watch(ctx, keys...); err != nil {
+			return nil, err
+		}
+		if err := fn(tx); err != nil {
+			tx.Discard(ctx)
+			return nil, err
+		}
+		results, err := tx.Exec(ctx)
+		if err != ErrTxAborted || i >= maxRetries {
+			return results, err
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package resp3
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// txServer is a fake server with MULTI, EXEC, DISCARD, WATCH, UNWATCH, GET, SET and INCR.
+func txServer(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	data := make(map[string]string)
+	versions := make(map[string]int)
+	exec := func(args []string) *Value {
+		switch strings.ToUpper(args[0]) {
+		case "GET":
+			v, ok := data[args[1]]
+			if !ok {
+				return NewNullValue()
+			}
+			return NewBlobStringValue(v)
+		case "SET":
+			data[args[1]] = args[2]
+			versions[args[1]]++
+			return NewSimpleStringValue("OK")
+		case "INCR":
+			n, err := strconv.ParseInt(data[args[1]], 10, 64)
+			if err != nil && data[args[1]] != "" {
+				return &Value{Type: TypeSimpleError, Err: "ERR value is not an integer or out of range"}
+			}
+			data[args[1]] = strconv.FormatInt(n+1, 10)
+			versions[args[1]]++
+			return NewNumberValue(n + 1)
+		}
+		return nil
+	}
+
+	// a transaction is a state of the connection
+	serveFakeOn(t, ln, func() fakeHandler {
+		var watched map[string]int
+		var queue [][]string
+		multi, failed := false, false
+		return func(args []string, write func(v *Value)) *Value {
+			mu.Lock()
+			defer mu.Unlock()
+			switch name := strings.ToUpper(args[0]); {
+			case name == "MULTI":
+				multi, failed, queue = true, false, nil
+				return NewSimpleStringValue("OK")
+			case name == "EXEC":
+				defer func() { multi, watched = false, nil }()
+				if failed {
+					return &Value{Type: TypeSimpleError, Err: "EXECABORT Transaction discarded because of previous errors."}
+				}
+				for k, v := range watched {
+					if versions[k] != v {
+						return NewNullValue()
+					}
+				}
+				reply := NewArrayValue(nil)
+				for _, q := range queue {
+					reply.Elems = append(reply.Elems, exec(q))
+				}
+				return reply
+			case name == "DISCARD":
+				multi, watched = false, nil
+				return NewSimpleStringValue("OK")
+			case name == "WATCH":
+				if watched == nil {
+					watched = make(map[string]int)
+				}
+				for _, k := range args[1:] {
+					watched[k] = versions[k]
+				}
+				return NewSimpleStringValue("OK")
+			case name == "UNWATCH":
+				watched = nil
+				return NewSimpleStringValue("OK")
+			case multi:
+				if name != "GET" && name != "SET" && name != "INCR" {
+					failed = true
+					return &Value{Type: TypeSimpleError, Err: "ERR unknown command"}
+				}
+				queue = append(queue, args)
+				return NewSimpleStringValue("QUEUED")
+			}
+			if reply := exec(args); reply != nil {
+				return reply
+			}
+			return &Value{Type: TypeSimpleError, Err: "ERR unknown command"}
+		}
+	})
+	return ln
+}
+
+func TestTx(t *testing.T) {
+	ln := txServer(t)
+	defer ln.Close()
+	c, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	tx := NewTx(c)
+	tx.Queue("SET", "a", "x")
+	tx.Queue("INCR", "a")
+	tx.Queue("INCR", "n")
+	results, err := tx.Exec(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || results[0].Value.Str != "OK" || results[0].Err != nil ||
+		results[1].Err == nil || results[2].Value.Integer != 1 {
+		t.Errorf("unexpected results %+v", results)
+	}
+
+	// after a command whose reply isn't received yet
+	c.Send("SET", "b", "1")
+	c.Flush()
+	tx.Queue("GET", "b")
+	results, err = tx.Exec(ctx)
+	if err != nil || len(results) != 1 || results[0].Value.Str != "1" {
+		t.Errorf("unexpected results %+v, %v", results, err)
+	}
+
+	// rejected when queued
+	tx.Queue("SET", "a", "1")
+	tx.Queue("NOPE")
+	results, err = tx.Exec(ctx)
+	if !strings.HasPrefix(err.Error(), "EXECABORT") || results[0].Err != nil || results[1].Err == nil {
+		t.Errorf("expected EXECABORT but got %+v, %v", results, err)
+	}
+
+	// a watched key is changed
+	other, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	tx.Watch(ctx, "n")
+	other.Do("INCR", "n")
+	tx.Queue("INCR", "n")
+	if _, err := tx.Exec(ctx); err != ErrTxAborted {
+		t.Errorf("expected ErrTxAborted but got %v", err)
+	}
+
+	tx.Watch(ctx, "n")
+	tx.Queue("INCR", "n")
+	tx.Discard(ctx)
+	if c.watching || c.pending != 0 || c.multi {
+		t.Errorf("unexpected state after DISCARD: %+v", c)
+	}
+}
+
+func TestWatchTx(t *testing.T) {
+	ln := txServer(t)
+	defer ln.Close()
+	ctx := context.Background()
+
+	// concurrent increments by GET and SET
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := Dial("tcp", ln.Addr().String())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer c.Close()
+			for j := 0; j < 10; j++ {
+				_, err := c.WatchTx(ctx, []string{"counter"}, 100, func(tx *Tx) error {
+					v, err := tx.Conn().DoContext(ctx, "GET", "counter")
+					if err != nil {
+						return err
+					}
+					n, _ := strconv.Atoi(v.Str)
+					tx.Queue("SET", "counter", strconv.Itoa(n+1))
+					return nil
+				})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	c, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	v, err := c.Do("GET", "counter")
+	if err != nil || v.Str != "40" {
+		t.Errorf("expected 40 but got %v, %v", v, err)
+	}
+
+	// fn fails
+	failure := errors.New("failure")
+	_, err = c.WatchTx(ctx, []string{"counter"}, 1, func(tx *Tx) error {
+		return failure
+	})
+	if err != failure || c.watching {
+		t.Errorf("expected the error of fn but got %v", err)
+	}
+}
+
+func TestTx_RESP2(t *testing.T) {
+	// EXEC replies the null array of RESP2 if any command is queued
+	queued := 0
+	ln := serveFake(t, func(args []string, write func(v *Value)) *Value {
+		switch args[0] {
+		case "MULTI":
+			queued = 0
+			return NewSimpleStringValue("OK")
+		case "EXEC":
+			if queued > 0 {
+				return mustValue(t, "*-1\r\n")
+			}
+			return mustValue(t, "*0\r\n")
+		}
+		queued++
+		return NewSimpleStringValue("QUEUED")
+	})
+	defer ln.Close()
+	c, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	// an empty transaction isn't aborted
+	tx := NewTx(c)
+	if results, err := tx.Exec(ctx); err != nil || len(results) != 0 {
+		t.Errorf("unexpected results %+v, %v", results, err)
+	}
+
+	tx.Queue("INCR", "n")
+	if _, err := tx.Exec(ctx); err != ErrTxAborted {
+		t.Errorf("expected ErrTxAborted but got %v", err)
+	}
+}
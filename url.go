@@ -0,0 +1,139 @@
+package resp3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidURL is returned by ParseURL for a URL which isn't redis://, rediss:// or unix://, or has invalid parameters.
+var ErrInvalidURL = errors.New("resp: invalid URL")
+
+// ParseURL parses a URL of a server and returns the network, the address and the options of Dial:
+//
+//	redis://[[username]:password@]host[:port][/db][?params]
+//	rediss://[[username]:password@]host[:port][/db][?params]
+//	unix://[[username]:password@]/path/to/socket[?params]
+//
+// rediss connects with TLS. The parameters are db, protocol, client_name, dial_timeout, read_timeout and write_timeout.
+// Timeouts are durations like 500ms, or seconds like 1.5.
+// The port is 6379 and the host is localhost by default.
+func ParseURL(rawURL string) (network, address string, opts []DialOption, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	switch u.Scheme {
+	case "redis", "rediss":
+		network = "tcp"
+		host, port := u.Hostname(), u.Port()
+		if host == "" {
+			host = "localhost"
+		}
+		if port == "" {
+			port = "6379"
+		}
+		address = net.JoinHostPort(host, port)
+		if db := strings.Trim(u.Path, "/"); db != "" {
+			n, err := strconv.Atoi(db)
+			if err != nil {
+				return "", "", nil, ErrInvalidURL
+			}
+			opts = append(opts, DialDatabase(n))
+		}
+		if u.Scheme == "rediss" {
+			opts = append(opts, DialTLSConfig(&tls.Config{ServerName: host}))
+		}
+	case "unix":
+		network, address = "unix", u.Path
+		if address == "" {
+			return "", "", nil, ErrInvalidURL
+		}
+	default:
+		return "", "", nil, ErrInvalidURL
+	}
+
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			opts = append(opts, DialAuth(u.User.Username(), password))
+		}
+	}
+
+	for name, values := range u.Query() {
+		value := values[len(values)-1]
+		switch name {
+		case "db":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return "", "", nil, ErrInvalidURL
+			}
+			opts = append(opts, DialDatabase(n))
+		case "protocol":
+			n, err := strconv.Atoi(value)
+			if err != nil || (n != 2 && n != 3) {
+				return "", "", nil, ErrInvalidURL
+			}
+			opts = append(opts, DialProtocol(n))
+		case "client_name":
+			opts = append(opts, DialClientName(value))
+		case "dial_timeout", "read_timeout", "write_timeout":
+			d, err := parseURLDuration(value)
+			if err != nil {
+				return "", "", nil, ErrInvalidURL
+			}
+			switch name {
+			case "dial_timeout":
+				opts = append(opts, DialTimeout(d))
+			case "read_timeout":
+				opts = append(opts, DialReadTimeout(d))
+			default:
+				opts = append(opts, DialWriteTimeout(d))
+			}
+		default:
+			return "", "", nil, ErrInvalidURL
+		}
+	}
+	return network, address, opts, nil
+}
+
+// parseURLDuration parses a duration like 500ms, or seconds like 1.5.
+func parseURLDuration(s string) (time.Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(f * float64(time.Second)), nil
+}
+
+// DialURL connects to the server of a URL parsed by ParseURL.
+// The options override the options of the URL, like DialTLSConfig with client certificates for rediss.
+func DialURL(ctx context.Context, rawURL string, opts ...DialOption) (*Conn, error) {
+	network, address, urlOpts, err := ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return DialContext(ctx, network, address, append(urlOpts, opts...)...)
+}
+
+// NewPoolURL returns a pool of connections to the server of a URL, like DialURL.
+func NewPoolURL(rawURL string, opts ...DialOption) (*Pool, error) {
+	network, address, urlOpts, err := ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(urlOpts, opts...)
+	return &Pool{
+		Dial: func(ctx context.Context) (*Conn, error) {
+			return DialContext(ctx, network, address, opts...)
+		},
+	}, nil
+}
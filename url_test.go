@@ -0,0 +1,183 @@
+package resp3
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		network string
+		address string
+		opts    dialOptions
+		tls     bool
+	}{
+		{"redis://", "tcp", "localhost:6379", dialOptions{}, false},
+		{"redis://:secret@127.0.0.1:7000/2", "tcp", "127.0.0.1:7000", dialOptions{password: "secret", db: 2}, false},
+		{"redis://app:secret@[::1]/?db=3&client_name=worker&protocol=2", "tcp", "[::1]:6379",
+			dialOptions{username: "app", password: "secret", db: 3, clientName: "worker", protocol: 2}, false},
+		{"rediss://redis.example.com?dial_timeout=2s&read_timeout=0.5&write_timeout=100ms", "tcp", "redis.example.com:6379",
+			dialOptions{dialTimeout: 2 * time.Second, readTimeout: 500 * time.Millisecond, writeTimeout: 100 * time.Millisecond}, true},
+		{"unix:///var/run/redis.sock?db=1", "unix", "/var/run/redis.sock", dialOptions{db: 1}, false},
+		{"unix://:secret@/tmp/redis.sock", "unix", "/tmp/redis.sock", dialOptions{password: "secret"}, false},
+	}
+	for _, tt := range tests {
+		network, address, opts, err := ParseURL(tt.url)
+		if err != nil {
+			t.Errorf("%s: %v", tt.url, err)
+			continue
+		}
+		var o dialOptions
+		for _, opt := range opts {
+			opt(&o)
+		}
+		if (o.tlsConfig != nil) != tt.tls {
+			t.Errorf("%s: unexpected TLS config %v", tt.url, o.tlsConfig)
+		}
+		o.tlsConfig = nil
+		if network != tt.network || address != tt.address || !reflect.DeepEqual(o, tt.opts) {
+			t.Errorf("%s: expected %s %s %+v but got %s %s %+v", tt.url, tt.network, tt.address, tt.opts, network, address, o)
+		}
+	}
+
+	for _, url := range []string{
+		"http://localhost", "redis://localhost/db", "redis://localhost?db=x", "redis://localhost?protocol=4",
+		"redis://localhost?timeout=1s", "unix://", "redis://localhost?read_timeout=soon",
+	} {
+		if _, _, _, err := ParseURL(url); err != ErrInvalidURL {
+			t.Errorf("%s: expected ErrInvalidURL but got %v", url, err)
+		}
+	}
+}
+
+// newCert returns a certificate signed by the parent, or a self-signed CA if the parent is nil.
+func newCert(t *testing.T, name string, parent *tls.Certificate, usage x509.ExtKeyUsage) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	signer, signerKey := template, interface{}(key)
+	if parent == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestDialURL_TLS(t *testing.T) {
+	ca := newCert(t, "ca", nil, x509.ExtKeyUsageAny)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+	server := newCert(t, "redis.internal", &ca, x509.ExtKeyUsageServerAuth)
+	client := newCert(t, "app", &ca, x509.ExtKeyUsageClientAuth)
+
+	sni := make(chan string, 2)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln = tls.NewListener(ln, &tls.Config{
+		Certificates: []tls.Certificate{server},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			sni <- hello.ServerName
+			return nil, nil
+		},
+	})
+	defer ln.Close()
+	serveFakeOn(t, ln, func() fakeHandler {
+		return func(args []string, write func(v *Value)) *Value {
+			return NewSimpleStringValue("PONG")
+		}
+	})
+
+	ctx := context.Background()
+	url := "rediss://" + ln.Addr().String()
+	c, err := DialURL(ctx, url, DialTLSConfig(&tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{client},
+		ServerName:   "redis.internal",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if v, err := c.Do("PING"); err != nil || v.Str != "PONG" {
+		t.Errorf("expected PONG but got %v, %v", v, err)
+	}
+	if name := <-sni; name != "redis.internal" {
+		t.Errorf("expected SNI redis.internal but got %q", name)
+	}
+
+	// the server requires a client certificate
+	if _, err := DialURL(ctx, url, DialTLSConfig(&tls.Config{RootCAs: pool, ServerName: "redis.internal"})); err == nil {
+		t.Error("expected an error without a client certificate")
+	}
+}
+
+func TestDialURL_Unix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "redis.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	var mu sync.Mutex
+	var names []string
+	serveFakeOn(t, ln, func() fakeHandler {
+		return func(args []string, write func(v *Value)) *Value {
+			mu.Lock()
+			defer mu.Unlock()
+			names = append(names, args[0])
+			return NewSimpleStringValue("OK")
+		}
+	})
+
+	c, err := DialURL(context.Background(), "unix://"+path+"?db=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.Do("PING"); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(names) != 2 || names[0] != "SELECT" {
+		t.Errorf("expected SELECT after HELLO but got %v", names)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"io"
 	"strconv" // for converting integers to strings
+	"sync/atomic"
 )
 
 // Writer is a redis client writer.
@@ -15,17 +16,60 @@ import (
 // so this is the only type the client needs to send to a server.
 type Writer struct {
 	*bufio.Writer
+	dst   io.Writer
+	out   *countWriter
+	err   error // set when a write is interrupted
+	stats stats
 }
 
 // NewWriter returns a redis client writer.
 func NewWriter(writer io.Writer) *Writer {
+	out := &countWriter{w: writer}
 	return &Writer{
-		Writer: bufio.NewWriter(writer),
+		Writer: bufio.NewWriter(out),
+		dst:    writer,
+		out:    out,
 	}
 }
 
+// countWriter counts the bytes written to the underlying writer.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	atomic.AddInt64(&c.n, int64(n))
+	return n, err
+}
+
+// count returns the number of bytes written, or 0 if the Writer is made without NewWriter.
+func (c *countWriter) count() int64 {
+	if c == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&c.n)
+}
+
+// written returns the number of bytes written, including the buffered ones.
+func (w *Writer) written() int64 {
+	return w.out.count() + int64(w.Buffered())
+}
+
 // WriteCommand writes a redis command.
 func (w *Writer) WriteCommand(args ...string) (err error) {
+	if w.err != nil {
+		return w.err
+	}
+	w.writeCommand(args)
+	return w.Flush()
+}
+
+// writeCommand writes a redis command to the buffer.
+// Errors are kept by bufio.Writer and returned by Flush.
+func (w *Writer) writeCommand(args []string) {
+	before := w.written()
 	// write the array flag
 	w.WriteByte(TypeArray)
 	w.WriteString(strconv.Itoa(len(args)))
@@ -37,12 +81,17 @@ func (w *Writer) WriteCommand(args ...string) (err error) {
 		w.Write(CRLFByte)
 		w.WriteString(arg)
 		w.Write(CRLFByte)
+		w.stats.arg(len(arg))
 	}
-	return w.Flush()
+	w.stats.command(w.written()-before, len(args))
 }
 
 // WriteByteCommand writes a redis command in bytes.
 func (w *Writer) WriteByteCommand(args ...[]byte) (err error) {
+	if w.err != nil {
+		return w.err
+	}
+	before := w.written()
 	// write the array flag
 	w.WriteByte(TypeArray)
 	w.WriteString(strconv.Itoa(len(args)))
@@ -54,6 +103,8 @@ func (w *Writer) WriteByteCommand(args ...[]byte) (err error) {
 		w.Write(CRLFByte)
 		w.Write(arg)
 		w.Write(CRLFByte)
+		w.stats.arg(len(arg))
 	}
+	w.stats.command(w.written()-before, len(args))
 	return w.Flush()
 }